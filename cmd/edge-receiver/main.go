@@ -1,48 +1,430 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/events"
 	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/Tuhis/edge-receiver/pkg/schemaregistry"
 	_ "github.com/joho/godotenv/autoload"
 	"go.uber.org/zap"
 )
 
+// channelSaturationThreshold is the fraction of the messaging channel that
+// may be filled before the pod is no longer ready to take requests.
+const channelSaturationThreshold = 0.9
+
 func main() {
 
 	// Init logger
 	logger, _ := zap.NewProduction()
 
+	// Cancelled on a termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+
 	// Read KAFKA_INGRESS_TOPIC from environment
 	kafkaIngressTopic := os.Getenv("KAFKA_INGRESS_TOPIC")
 	if kafkaIngressTopic == "" {
 		kafkaIngressTopic = "ruuvi-event-ingress"
 	}
 
+	// Read DELIVERY_MODE from environment. In "sync" mode requests are only
+	// answered after Kafka has acknowledged the events.
+	var ingressOptions []internal.IngressOption
+	var deliveryTimeout time.Duration
+	switch deliveryMode := os.Getenv("DELIVERY_MODE"); deliveryMode {
+	case "", "async":
+	case "sync":
+		deliveryTimeout = durationFromEnv(logger.Sugar(), "DELIVERY_TIMEOUT", 10*time.Second)
+		ingressOptions = append(ingressOptions, internal.WithSynchronousAck(deliveryTimeout))
+	default:
+		logger.Sugar().Fatalf("Invalid DELIVERY_MODE: %s", deliveryMode)
+	}
+
+	// Read KAFKA_MESSAGE_KEY_FIELD from environment. Setting it to an empty
+	// string disables message keys.
+	if keyField, ok := os.LookupEnv("KAFKA_MESSAGE_KEY_FIELD"); ok {
+		ingressOptions = append(ingressOptions, internal.WithMessageKeyField(keyField))
+	}
+
+	// Read CLOCK_SKEW_TOLERANCE and CLOCK_SKEW_POLICY from environment. They
+	// decide what happens to events observed too far in the future.
+	skewPolicy := internal.ClampClockSkew
+	if s := os.Getenv("CLOCK_SKEW_POLICY"); s != "" {
+		var err error
+		if skewPolicy, err = internal.ParseClockSkewPolicy(s); err != nil {
+			logger.Sugar().Fatalf("Invalid CLOCK_SKEW_POLICY: %v", err)
+		}
+	}
+	ingressOptions = append(ingressOptions, internal.WithClockSkewTolerance(
+		durationFromEnv(logger.Sugar(), "CLOCK_SKEW_TOLERANCE", internal.DefaultClockSkewTolerance), skewPolicy))
+
+	// Read RANGE_POLICY and RANGE_LIMITS_FILE from environment. Measurements
+	// with readings outside their bounds are flagged or rejected.
+	if policyName := os.Getenv("RANGE_POLICY"); policyName != "off" {
+		rangePolicy := internal.FlagOutOfRange
+		if policyName != "" {
+			var err error
+			if rangePolicy, err = internal.ParseRangePolicy(policyName); err != nil {
+				logger.Sugar().Fatalf("Invalid RANGE_POLICY: %v", err)
+			}
+		}
+
+		validator, err := events.NewRangeValidator(events.RangeLimits{})
+		if rangeLimitsFile := os.Getenv("RANGE_LIMITS_FILE"); rangeLimitsFile != "" {
+			validator, err = internal.LoadRangeLimits(rangeLimitsFile)
+		}
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to load range limits: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithRangeValidation(validator, rangePolicy))
+	}
+
+	// Read DERIVED_QUANTITIES from environment. It lists the quantities
+	// computed from each measurement, or "all".
+	if s := os.Getenv("DERIVED_QUANTITIES"); s != "" {
+		derivations, err := events.ParseDerivations(s)
+		if err != nil {
+			logger.Sugar().Fatalf("Invalid DERIVED_QUANTITIES: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithEnricher(events.NewEnricher(derivations...)))
+	}
+
+	// Read EVENT_FORMAT from environment. It selects the flat legacy events,
+	// the versioned envelope, or both during a migration.
+	eventFormat := events.LegacyFormat
+	if s := os.Getenv("EVENT_FORMAT"); s != "" {
+		var err error
+		if eventFormat, err = events.ParseEventFormat(s); err != nil {
+			logger.Sugar().Fatalf("Invalid EVENT_FORMAT: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithEventFormat(eventFormat))
+	}
+
+	// Read the encoding of the ingress topic from KAFKA_TOPIC_ENCODINGS or
+	// KAFKA_VALUE_SERIALIZER. Avro and JSON Schema records carry the ID of
+	// their schema in SCHEMA_REGISTRY_URL.
+	encoding, err := kafkawrapper.TopicEncoding(kafkaIngressTopic)
+	if err != nil {
+		logger.Sugar().Fatalf("Invalid encoding of %s: %v", kafkaIngressTopic, err)
+	}
+	switch encoding {
+	case kafkawrapper.EncodingProtobuf:
+		serializer, err := kafkawrapper.NewProtobufSerializer(eventFormat)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to create protobuf serializer: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithSerializer(serializer))
+	case kafkawrapper.EncodingAvro, kafkawrapper.EncodingJSONSchema:
+		serializer, err := newRegistrySerializer(encoding, kafkaIngressTopic, eventFormat)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to create %s serializer: %v", encoding, err)
+		}
+
+		// Fail fast if the schema cannot be registered, rather than
+		// rejecting every event
+		id, err := serializer.SchemaID(ctx)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to register schema: %v", err)
+		}
+		logger.Sugar().Infow("Serializing events with a schema registry", "encoding", encoding, "schema_id", id)
+		ingressOptions = append(ingressOptions, internal.WithSerializer(serializer))
+	}
+
+	// Read DEDUP_WINDOW from environment. When set, repeated copies of a
+	// measurement within the window are acknowledged but not produced, and
+	// with DEDUP_AGGREGATE the gateways that received them are listed.
+	var dedup *internal.Deduplicator
+	if os.Getenv("DEDUP_WINDOW") != "" {
+		var dedupOptions []internal.DeduplicatorOption
+		if aggregate, _ := strconv.ParseBool(os.Getenv("DEDUP_AGGREGATE")); aggregate {
+			dedupOptions = append(dedupOptions, internal.AggregateReceptions())
+		}
+
+		// Held requests are answered only after the hold, so in sync mode a
+		// hold as long as the delivery timeout would fail every one of them
+		hold := durationFromEnv(logger.Sugar(), "DEDUP_HOLD", 0)
+		if deliveryTimeout > 0 && hold >= deliveryTimeout {
+			logger.Sugar().Fatalf("DEDUP_HOLD (%s) must be shorter than DELIVERY_TIMEOUT (%s)", hold, deliveryTimeout)
+		}
+
+		var err error
+		dedup, err = internal.NewDeduplicator(
+			durationFromEnv(logger.Sugar(), "DEDUP_WINDOW", 0),
+			intFromEnv(logger.Sugar(), "DEDUP_CAPACITY", 100000),
+			hold,
+			dedupOptions...)
+		if err != nil {
+			logger.Sugar().Fatalf("Invalid deduplication settings: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithDeduplicator(dedup))
+	}
+
+	// Read SHUTDOWN_TIMEOUT from environment. It should be shorter than the
+	// termination grace period of the pod.
+	shutdownTimeout := durationFromEnv(logger.Sugar(), "SHUTDOWN_TIMEOUT", 25*time.Second)
+
+	// Read SHUTDOWN_DRAIN_DELAY from environment. Requests are still served
+	// for this long after /ready starts failing, until the readiness probe
+	// has taken the pod out of the Service. It is part of SHUTDOWN_TIMEOUT.
+	drainDelay := durationFromEnv(logger.Sugar(), "SHUTDOWN_DRAIN_DELAY", 10*time.Second)
+	if drainDelay >= shutdownTimeout {
+		logger.Sugar().Fatalf("SHUTDOWN_DRAIN_DELAY (%s) must be shorter than SHUTDOWN_TIMEOUT (%s)", drainDelay, shutdownTimeout)
+	}
+
 	// Initialize Kafka producer
 	kf := kafkawrapper.NewKafkaProducer(logger.Sugar())
 
 	// Create Kafka messaging channel
-	messageChannel := make(chan string, 100)
+	messageChannel := make(chan kafkawrapper.Message, 100)
+
+	producerDone := make(chan struct{})
+	go func() {
+		kf.ProduceMessagesFromChan(messageChannel, kafkaIngressTopic)
+		close(producerDone)
+	}()
+
+	// Configure HTTPS and the authentication of the ingress endpoints
+	tlsConfig, clientCertificates := tlsConfigFromEnvironment(logger.Sugar(), ctx.Done())
+	authenticators := authenticatorsFromEnvironment(logger.Sugar(), ctx.Done())
+	if clientCertificates != nil {
+		authenticators = append(authenticators, clientCertificates)
+	}
+
+	if len(authenticators) == 0 {
+		logger.Sugar().Warn("No authentication is configured, ingress endpoints are open to everyone")
+	}
+
+	// Read RATE_LIMITS_FILE from environment. It limits the events accepted
+	// per source, so that a single sender cannot fill the messaging channel.
+	if rateLimitsFile := os.Getenv("RATE_LIMITS_FILE"); rateLimitsFile != "" {
+		limiter, err := internal.LoadRateLimits(rateLimitsFile)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to load rate limits: %v", err)
+		}
+		go limiter.Watch(durationFromEnv(logger.Sugar(), "RATE_LIMITS_RELOAD_INTERVAL", 30*time.Second), ctx.Done())
+
+		ingressOptions = append(ingressOptions, internal.WithRateLimiter(limiter))
+	}
+
+	// Read TRUSTED_PROXIES from environment. Requests from these proxies are
+	// rate limited by the client address in X-Forwarded-For instead of the
+	// address of the proxy.
+	if trustedProxies := os.Getenv("TRUSTED_PROXIES"); trustedProxies != "" {
+		proxies, err := internal.ParseTrustedProxies(trustedProxies)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed to parse TRUSTED_PROXIES: %v", err)
+		}
+		ingressOptions = append(ingressOptions, internal.WithTrustedProxies(proxies))
+	}
+
+	// Without synchronous acknowledgements events are spooled, and events
+	// that do not fit in a full spool are dropped unless they are refused
+	if deliveryTimeout == 0 {
+		ingressOptions = append(ingressOptions, internal.WithSpoolFullCheck(kf.SpoolFull))
+	}
+
+	// Cancelled on shutdown to end the event streams, which would otherwise
+	// keep their requests open until the shutdown deadline
+	streamsCtx, endStreams := context.WithCancel(context.Background())
+	ingressOptions = append(ingressOptions, internal.WithShutdown(streamsCtx))
+
+	// Ingress requests being handled, which may still send to the messaging
+	// channel
+	var inFlight sync.WaitGroup
 
-	go kf.ProduceMessagesFromChan(messageChannel, kafkaIngressTopic)
+	ingressHandler := func(name string, handler http.HandlerFunc) http.Handler {
+		for _, authenticate := range authenticators {
+			handler = authenticate(handler)
+		}
+		instrumented := metrics.InstrumentHandler(name, handler)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inFlight.Add(1)
+			defer inFlight.Done()
+			instrumented.ServeHTTP(w, r)
+		})
+	}
 
-	http.HandleFunc("/event", internal.CreateIncomingEventHandler(messageChannel))
+	http.Handle("/event", ingressHandler("event", internal.CreateIncomingEventHandler(messageChannel, ingressOptions...)))
+	http.Handle("/events", ingressHandler("events", internal.CreateIncomingBatchHandler(messageChannel, ingressOptions...)))
+	http.Handle("/gateway", ingressHandler("gateway", internal.CreateGatewayHandler(messageChannel, ingressOptions...)))
 
-	// Add k8s health and liveliness check endpoints.
-	// TODO: Add more sophisticated checks.
-	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprint(w, "OK")
+	// Expose Prometheus metrics
+	metrics.RegisterGauge("channel_depth", "Messages waiting in the messaging channel.", func() float64 {
+		return float64(len(messageChannel))
+	})
+	metrics.RegisterGauge("kafka_pending_messages", "Messages written to Kafka that have not been acknowledged yet.", func() float64 {
+		return float64(kf.PendingMessages())
 	})
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprint(w, "OK")
+	metrics.RegisterGauge("spool_depth", "Messages waiting in the spool.", func() float64 {
+		return float64(kf.SpoolDepth())
 	})
+	http.Handle("/metrics", metrics.Handler())
+
+	// Set when shutting down, so that the pod is taken out of the Service
+	// before the server stops accepting requests
+	var shuttingDown atomic.Bool
+
+	// Add k8s readiness and liveness check endpoints
+	http.HandleFunc("/ready", internal.CreateHealthHandler(map[string]internal.HealthCheck{
+		"server": func(ctx context.Context) error {
+			if shuttingDown.Load() {
+				return errors.New("shutting down")
+			}
+			return nil
+		},
+		"channel": internal.ChannelCheck(messageChannel, channelSaturationThreshold),
+		"kafka":   internal.ProducerReadinessCheck(kf),
+	}))
+	http.HandleFunc("/health", internal.CreateHealthHandler(map[string]internal.HealthCheck{
+		"producer": internal.ProducerLivenessCheck(kf),
+	}))
+
+	server := &http.Server{Addr: ":8088", TLSConfig: tlsConfig}
+
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			fmt.Println("Starting HTTPS server on port 8088")
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			fmt.Println("Starting server on port 8088")
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	// Wait for a termination signal
+	<-ctx.Done()
+	stop()
+
+	logger.Sugar().Infow("Shutting down", "timeout", shutdownTimeout, "drain_delay", drainDelay)
+	shuttingDown.Store(true)
+
+	shutdown(logger.Sugar(), server, endStreams, &inFlight, dedup, kf, messageChannel, producerDone, drainDelay, shutdownTimeout)
+	logger.Sync()
+}
+
+// shutdown keeps serving requests for drainDelay, while the pod is taken out
+// of the Service, then ends the event streams, stops accepting requests and
+// lets in-flight requests finish, releases the measurements held for
+// deduplication, drains the messaging channel and flushes and closes the
+// producer and its spool. Requests that have not finished when the timeout,
+// which includes drainDelay, expires are cut off, and the messages that are
+// still buffered at that point are logged, as they are lost if the process
+// is killed before the flush completes.
+func shutdown(logger *zap.SugaredLogger, server *http.Server, endStreams context.CancelFunc, inFlight *sync.WaitGroup,
+	dedup *internal.Deduplicator, kf kafkawrapper.IKafkaProducer,
+	messageChannel chan kafkawrapper.Message, producerDone <-chan struct{}, drainDelay, timeout time.Duration) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	time.Sleep(drainDelay)
+
+	flushed := make(chan struct{})
+	defer close(flushed)
+	go func() {
+		select {
+		case <-ctx.Done():
+			logger.Errorw("Shutdown deadline expired, still flushing messages",
+				"buffered", len(messageChannel)+kf.PendingMessages(),
+				"spooled", kf.SpoolDepth())
+		case <-flushed:
+		}
+	}()
+
+	// Event streams are answered with the lines read so far
+	endStreams()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Errorf("Failed to finish in-flight requests: %v", err)
+		// Cut off the remaining connections, so that their handlers return
+		server.Close()
+	}
+
+	// Handlers may send to the channel until they have returned
+	inFlight.Wait()
+
+	if dedup != nil {
+		dedup.Close()
+	}
+
+	// No more messages can arrive, so let the producer drain the channel
+	close(messageChannel)
+	<-producerDone
+
+	if err := kf.Close(); err != nil {
+		logger.Errorf("Failed to close Kafka producer: %v", err)
+		return
+	}
+	logger.Infow("Shutdown complete", "spooled", kf.SpoolDepth())
+}
+
+// durationFromEnv reads a duration such as "10s" from the environment.
+func durationFromEnv(logger *zap.SugaredLogger, name string, defaultValue time.Duration) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		logger.Fatalf("Invalid %s: %s", name, s)
+	}
+	return d
+}
+
+// intFromEnv reads a positive integer from the environment.
+func intFromEnv(logger *zap.SugaredLogger, name string, defaultValue int) int {
+	s := os.Getenv(name)
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i <= 0 {
+		logger.Fatalf("Invalid %s: %s", name, s)
+	}
+	return i
+}
+
+// newRegistrySerializer returns the serializer of the encoding for the values
+// of topic, configured from the SCHEMA_REGISTRY_* environment.
+func newRegistrySerializer(encoding kafkawrapper.Encoding, topic string, format events.EventFormat) (*schemaregistry.Serializer, error) {
+	registryURL := os.Getenv("SCHEMA_REGISTRY_URL")
+	if registryURL == "" {
+		return nil, errors.New("SCHEMA_REGISTRY_URL must be set")
+	}
+
+	var clientOptions []schemaregistry.ClientOption
+	if username := os.Getenv("SCHEMA_REGISTRY_USERNAME"); username != "" {
+		clientOptions = append(clientOptions, schemaregistry.WithBasicAuth(username, os.Getenv("SCHEMA_REGISTRY_PASSWORD")))
+	}
+	client := schemaregistry.NewClient(registryURL, clientOptions...)
+
+	var serializerOptions []schemaregistry.SerializerOption
+	if autoRegister, err := strconv.ParseBool(os.Getenv("SCHEMA_REGISTRY_AUTO_REGISTER")); err == nil && !autoRegister {
+		serializerOptions = append(serializerOptions, schemaregistry.WithoutAutoRegister())
+	}
 
-	fmt.Println("Starting server on port 8088")
-	if err := http.ListenAndServe(":8088", nil); err != nil {
-		panic(err)
+	subject := schemaregistry.SubjectForTopic(topic)
+	if encoding == kafkawrapper.EncodingAvro {
+		return schemaregistry.NewAvroSerializer(client, subject, format, serializerOptions...)
 	}
+	return schemaregistry.NewJSONSchemaSerializer(client, subject, format, serializerOptions...)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"go.uber.org/zap"
+)
+
+// authenticator wraps an ingress handler with an authentication check.
+type authenticator func(http.HandlerFunc) http.HandlerFunc
+
+// tlsConfigFromEnvironment reads TLS_CERT_FILE and TLS_KEY_FILE from
+// environment. It returns nil if they are not set, in which case plain HTTP
+// is served. If TLS_CLIENT_CA_FILE is set as well, clients may present
+// certificates issued by those CAs, and the returned authenticator requires
+// them on the ingress endpoints.
+func tlsConfigFromEnvironment(logger *zap.SugaredLogger, stop <-chan struct{}) (*tls.Config, authenticator) {
+	certFile := os.Getenv("TLS_CERT_FILE")
+	keyFile := os.Getenv("TLS_KEY_FILE")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		logger.Fatal("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	clientCAFile := os.Getenv("TLS_CLIENT_CA_FILE")
+	reloadInterval := durationFromEnv(logger, "TLS_RELOAD_INTERVAL", time.Minute)
+
+	certificates, err := internal.LoadCertificates(certFile, keyFile, clientCAFile)
+	if err != nil {
+		logger.Fatalf("Failed to load TLS certificates: %v", err)
+	}
+	go certificates.Watch(reloadInterval, stop)
+
+	if clientCAFile == "" {
+		return certificates.TLSConfig(), nil
+	}
+
+	// TLS_CLIENT_SOURCES_FILE binds certificates to sources. Without it a
+	// certificate may send events for the sources named in it.
+	var sources *internal.ClientCertificateSources
+	if sourcesFile := os.Getenv("TLS_CLIENT_SOURCES_FILE"); sourcesFile != "" {
+		sources, err = internal.LoadClientCertificateSources(sourcesFile)
+		if err != nil {
+			logger.Fatalf("Failed to load client certificate sources: %v", err)
+		}
+		go sources.Watch(reloadInterval, stop)
+	}
+
+	return certificates.TLSConfig(), func(next http.HandlerFunc) http.HandlerFunc {
+		return internal.RequireClientCertificate(sources, next)
+	}
+}
+
+// authenticatorsFromEnvironment reads API_KEYS_FILE and SIGNING_KEYS_FILE from
+// environment. When set, the ingress endpoints require an API key or an HMAC
+// signature and accept events only for the sources bound to it.
+func authenticatorsFromEnvironment(logger *zap.SugaredLogger, stop <-chan struct{}) []authenticator {
+	var authenticators []authenticator
+
+	if apiKeysFile := os.Getenv("API_KEYS_FILE"); apiKeysFile != "" {
+		apiKeys, err := internal.LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			logger.Fatalf("Failed to load API keys: %v", err)
+		}
+		go apiKeys.Watch(durationFromEnv(logger, "API_KEYS_RELOAD_INTERVAL", 30*time.Second), stop)
+
+		authenticators = append(authenticators, func(next http.HandlerFunc) http.HandlerFunc {
+			return internal.RequireAPIKey(apiKeys, next)
+		})
+	}
+
+	if signingKeysFile := os.Getenv("SIGNING_KEYS_FILE"); signingKeysFile != "" {
+		signingKeys, err := internal.LoadSigningKeys(signingKeysFile)
+		if err != nil {
+			logger.Fatalf("Failed to load signing keys: %v", err)
+		}
+		go signingKeys.Watch(durationFromEnv(logger, "SIGNING_KEYS_RELOAD_INTERVAL", 30*time.Second), stop)
+
+		replayWindow := durationFromEnv(logger, "SIGNATURE_REPLAY_WINDOW", 5*time.Minute)
+		authenticators = append(authenticators, func(next http.HandlerFunc) http.HandlerFunc {
+			return internal.RequireSignature(signingKeys, replayWindow, next)
+		})
+	}
+
+	return authenticators
+}
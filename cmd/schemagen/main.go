@@ -0,0 +1,34 @@
+// Command schemagen writes the JSON Schema of each schema version of the
+// produced events to docs/schemas.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func main() {
+	out := flag.String("out", "docs/schemas", "directory to write the schemas to")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+
+	for _, version := range events.SchemaVersions {
+		schema, err := events.JSONSchema(version)
+		if err != nil {
+			log.Fatalf("Failed to generate schema version %s: %v", version, err)
+		}
+
+		path := filepath.Join(*out, fmt.Sprintf("v%s.schema.json", version))
+		if err := os.WriteFile(path, append(schema, '\n'), 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key of a request. The key
+// may also be sent as a bearer token in the Authorization header.
+const APIKeyHeader = "X-Api-Key"
+
+// AllSources in the sources of an API key allows the key to send events for
+// any source.
+const AllSources = "*"
+
+// identity is an authenticated sender and the sources it may send events
+// for.
+type identity struct {
+	name       string
+	sources    map[string]bool
+	allSources bool
+}
+
+func newIdentity(name string, sources []string) *identity {
+	id := &identity{name: name, sources: make(map[string]bool, len(sources))}
+	for _, source := range sources {
+		if source == AllSources {
+			id.allSources = true
+		}
+		id.sources[source] = true
+	}
+	return id
+}
+
+// allows reports whether the identity may send events for source.
+func (id *identity) allows(source string) bool {
+	return id.allSources || id.sources[source]
+}
+
+type identityKey struct{}
+
+// withIdentity adds an authenticated identity to the context. A request
+// authenticated in several ways carries several identities.
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	ids, _ := ctx.Value(identityKey{}).([]*identity)
+	ids = append(ids[:len(ids):len(ids)], id)
+	return context.WithValue(ctx, identityKey{}, ids)
+}
+
+// checkSource returns an error if the sender of the request may not send
+// events for source. Every identity of the request must allow the source;
+// requests that have not been authenticated are not restricted.
+func checkSource(ctx context.Context, source string) error {
+	ids, _ := ctx.Value(identityKey{}).([]*identity)
+	for _, id := range ids {
+		if !id.allows(source) {
+			return fmt.Errorf("source %s is not allowed for %s", source, id.name)
+		}
+	}
+	return nil
+}
+
+// APIKeyEntry is an API key in the API key file.
+type APIKeyEntry struct {
+	// Name identifies the key in logs, e.g. the name of the gateway.
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// Sources are the source UUIDs the key may send events for, or "*"
+	// for any source.
+	Sources []string `json:"sources"`
+}
+
+// APIKeyStore holds the API keys read from a JSON file containing a list of
+// APIKeyEntry objects. Keys are stored by their SHA-256 hash, so that looking
+// up a key does not leak its value through timing.
+type APIKeyStore struct {
+	file reloadableFile
+
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]*identity
+}
+
+// LoadAPIKeys reads the API keys from the file at path.
+func LoadAPIKeys(path string) (*APIKeyStore, error) {
+	store := &APIKeyStore{}
+	store.file = reloadableFile{path: path, parse: store.parse}
+
+	if _, err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload reads the API key file again and reports whether it had changed. If
+// the file cannot be read or parsed, the previously loaded keys are kept.
+func (s *APIKeyStore) Reload() (bool, error) {
+	return s.file.reload()
+}
+
+// Watch reloads the API key file every interval until stop is closed, so that
+// keys can be rotated without a restart.
+func (s *APIKeyStore) Watch(interval time.Duration, stop <-chan struct{}) {
+	s.file.watch(interval, stop)
+}
+
+func (s *APIKeyStore) parse(content []byte) error {
+	keys, err := parseAPIKeys(content)
+	if err != nil {
+		return fmt.Errorf("invalid API key file %s: %w", s.file.path, err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *APIKeyStore) lookup(key string) (*identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.keys[sha256.Sum256([]byte(key))]
+	return id, ok
+}
+
+func parseAPIKeys(content []byte) (map[[sha256.Size]byte]*identity, error) {
+	var entries []APIKeyEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[[sha256.Size]byte]*identity, len(entries))
+	for i, entry := range entries {
+		if entry.Key == "" || len(entry.Sources) == 0 {
+			return nil, fmt.Errorf("key %d (%s) must have a key and sources", i, entry.Name)
+		}
+
+		hash := sha256.Sum256([]byte(entry.Key))
+		if _, ok := keys[hash]; ok {
+			return nil, fmt.Errorf("key %d (%s) is a duplicate", i, entry.Name)
+		}
+		keys[hash] = newIdentity(entry.Name, entry.Sources)
+	}
+	return keys, nil
+}
+
+// apiKeyFromRequest returns the API key from the X-Api-Key header or a bearer
+// token in the Authorization header, or an empty string if there is none.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return token
+	}
+
+	return ""
+}
+
+// RequireAPIKey returns a handler that only passes requests with a valid API
+// key on to next. The sources the key is bound to are stored in the request
+// context, and events for other sources are rejected by the ingress handlers.
+func RequireAPIKey(store *APIKeyStore, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Empty keys are never stored, so a missing key is not found
+		id, ok := store.lookup(apiKeyFromRequest(r))
+		if !ok {
+			log.Printf("Rejected request without a valid API key: %s %s from %s\n", r.Method, r.URL.Path, r.RemoteAddr)
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="edge-receiver"`)
+			writeError(w, http.StatusUnauthorized, apiresponse.ApiResponse{
+				Message: apiresponse.Unauthorized,
+				Code:    apiresponse.CodeUnauthorized,
+				Detail:  "a valid API key is required",
+			})
+			return
+		}
+
+		next(w, r.WithContext(withIdentity(r.Context(), id)))
+	}
+}
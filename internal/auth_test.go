@@ -0,0 +1,163 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+const testAPIKeys = `[
+	{"name": "livingroom", "key": "secret-1", "sources": ["7d01818b-0332-4adf-99c1-13f833e59c6b"]},
+	{"name": "admin", "key": "secret-2", "sources": ["*"]}
+]`
+
+func writeKeyFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, testAPIKeys)
+
+	store, err := internal.LoadAPIKeys(path)
+	if err != nil {
+		t.Fatalf("failed to load API keys: %v", err)
+	}
+
+	const body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+	otherSource := strings.Replace(body, "7d01818b-0332-4adf-99c1-13f833e59c6b", "11111111-2222-3333-4444-555555555555", 1)
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		body            string
+		expectedStatus  int
+		expectedMessage apiresponse.ApiResponseMessage
+	}{
+		{
+			name:            "API key header",
+			headers:         map[string]string{"X-Api-Key": "secret-1"},
+			body:            body,
+			expectedStatus:  http.StatusOK,
+			expectedMessage: apiresponse.Ok,
+		},
+		{
+			name:            "bearer token",
+			headers:         map[string]string{"Authorization": "Bearer secret-1"},
+			body:            body,
+			expectedStatus:  http.StatusOK,
+			expectedMessage: apiresponse.Ok,
+		},
+		{
+			name:            "missing key",
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.Unauthorized,
+		},
+		{
+			name:            "unknown key",
+			headers:         map[string]string{"X-Api-Key": "secret-3"},
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.Unauthorized,
+		},
+		{
+			name:            "source not bound to key",
+			headers:         map[string]string{"X-Api-Key": "secret-1"},
+			body:            otherSource,
+			expectedStatus:  http.StatusForbidden,
+			expectedMessage: apiresponse.SourceNotAllowed,
+		},
+		{
+			name:            "key allowed for all sources",
+			headers:         map[string]string{"X-Api-Key": "secret-2"},
+			body:            otherSource,
+			expectedStatus:  http.StatusOK,
+			expectedMessage: apiresponse.Ok,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := internal.RequireAPIKey(store, internal.CreateIncomingEventHandler(messageChan))
+
+			req := httptest.NewRequest("POST", "/event", strings.NewReader(tt.body))
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+
+			var response apiresponse.ApiResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Message != tt.expectedMessage {
+				t.Errorf("handler returned wrong message: got %v want %v", response.Message, tt.expectedMessage)
+			}
+
+			if forwarded := len(messageChan) == 1; forwarded != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("event forwarded: got %v want %v", forwarded, tt.expectedStatus == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestAPIKeyStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, testAPIKeys)
+
+	store, err := internal.LoadAPIKeys(path)
+	if err != nil {
+		t.Fatalf("failed to load API keys: %v", err)
+	}
+
+	status := func(key string) int {
+		req := httptest.NewRequest("POST", "/event", nil)
+		req.Header.Set("X-Api-Key", key)
+		rr := httptest.NewRecorder()
+		internal.RequireAPIKey(store, func(w http.ResponseWriter, r *http.Request) {})(rr, req)
+		return rr.Code
+	}
+
+	if changed, err := store.Reload(); err != nil || changed {
+		t.Errorf("Reload() of unchanged file = %v, %v, want false, nil", changed, err)
+	}
+
+	// Rotate the key
+	writeKeyFile(t, path, `[{"name": "livingroom", "key": "secret-4", "sources": ["*"]}]`)
+	if changed, err := store.Reload(); err != nil || !changed {
+		t.Errorf("Reload() of changed file = %v, %v, want true, nil", changed, err)
+	}
+	if got := status("secret-1"); got != http.StatusUnauthorized {
+		t.Errorf("old key: got status %v want %v", got, http.StatusUnauthorized)
+	}
+	if got := status("secret-4"); got != http.StatusOK {
+		t.Errorf("new key: got status %v want %v", got, http.StatusOK)
+	}
+
+	// A broken file keeps the previous keys
+	writeKeyFile(t, path, `[{"name": "broken"`)
+	if _, err := store.Reload(); err == nil {
+		t.Error("Reload() of invalid file succeeded")
+	}
+	if got := status("secret-4"); got != http.StatusOK {
+		t.Errorf("key after failed reload: got status %v want %v", got, http.StatusOK)
+	}
+}
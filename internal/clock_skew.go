@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// ClockSkewPolicy decides what happens to events observed further in the
+// future than the clock skew tolerance allows.
+type ClockSkewPolicy string
+
+const (
+	// ClampClockSkew replaces the observation time with the receive time.
+	ClampClockSkew ClockSkewPolicy = "clamp"
+	// RejectClockSkew rejects the event with 400.
+	RejectClockSkew ClockSkewPolicy = "reject"
+)
+
+// DefaultClockSkewTolerance is how far in the future an observation time may
+// be unless configured otherwise.
+const DefaultClockSkewTolerance = time.Minute
+
+// ParseClockSkewPolicy returns the policy with the given name.
+func ParseClockSkewPolicy(s string) (ClockSkewPolicy, error) {
+	switch policy := ClockSkewPolicy(s); policy {
+	case ClampClockSkew, RejectClockSkew:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown clock skew policy %q", s)
+	}
+}
+
+// WithClockSkewTolerance sets how far in the future the observation time of
+// an event may be compared to the time it was received, and what is done to
+// events beyond that.
+func WithClockSkewTolerance(tolerance time.Duration, policy ClockSkewPolicy) IngressOption {
+	return func(in *ingress) {
+		in.skewTolerance = tolerance
+		in.skewPolicy = policy
+	}
+}
+
+// checkObservedAt returns the observation time of an event received at
+// receivedAt, or an error if the event must be rejected. Observation times
+// in the past are accepted as is, since gateways upload buffered
+// measurements late.
+func (in *ingress) checkObservedAt(source string, observedAt *time.Time, receivedAt time.Time) (*time.Time, error) {
+	if observedAt == nil {
+		return nil, nil
+	}
+
+	skew := observedAt.Sub(receivedAt)
+	metrics.ClockSkew.Observe(skew.Seconds())
+
+	if skew <= in.skewTolerance {
+		return observedAt, nil
+	}
+
+	if in.skewPolicy == RejectClockSkew {
+		metrics.FutureTimestamps.WithLabelValues(string(RejectClockSkew)).Inc()
+		log.Printf("Rejected event from %s observed %v in the future\n", source, skew)
+		return nil, fmt.Errorf("observed %v in the future, at most %v is allowed", skew.Round(time.Second), in.skewTolerance)
+	}
+
+	metrics.FutureTimestamps.WithLabelValues(string(ClampClockSkew)).Inc()
+	log.Printf("Clamped observation time of event from %s observed %v in the future\n", source, skew)
+	return &receivedAt, nil
+}
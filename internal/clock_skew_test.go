@@ -0,0 +1,123 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestClockSkew(t *testing.T) {
+	const body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b",%s"data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	observed := func(offset time.Duration) string {
+		return fmt.Sprintf(`"observed_at":%q,`, time.Now().Add(offset).UTC().Format(time.RFC3339Nano))
+	}
+
+	tests := []struct {
+		name   string
+		policy internal.ClockSkewPolicy
+		body   string
+		status int
+		// Expected offset of the observation time from the receive time, or
+		// nil if it is not set
+		offset *time.Duration
+	}{
+		{
+			name:   "no observation time",
+			policy: internal.ClampClockSkew,
+			body:   fmt.Sprintf(body, ""),
+			status: http.StatusOK,
+		},
+		{
+			name:   "buffered measurement",
+			policy: internal.RejectClockSkew,
+			body:   fmt.Sprintf(body, observed(-time.Hour)),
+			status: http.StatusOK,
+			offset: durationPtr(-time.Hour),
+		},
+		{
+			name:   "future within tolerance",
+			policy: internal.RejectClockSkew,
+			body:   fmt.Sprintf(body, observed(30*time.Second)),
+			status: http.StatusOK,
+			offset: durationPtr(30 * time.Second),
+		},
+		{
+			name:   "future clamped",
+			policy: internal.ClampClockSkew,
+			body:   fmt.Sprintf(body, observed(time.Hour)),
+			status: http.StatusOK,
+			offset: durationPtr(0),
+		},
+		{
+			name:   "future rejected",
+			policy: internal.RejectClockSkew,
+			body:   fmt.Sprintf(body, observed(time.Hour)),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := internal.CreateIncomingEventHandler(messageChan, internal.WithClockSkewTolerance(time.Minute, tt.policy))
+
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status != http.StatusOK {
+				if len(messageChan) != 0 {
+					t.Error("expected the event not to be produced")
+				}
+				return
+			}
+
+			message := <-messageChan
+			var event struct {
+				ObservedAt *time.Time `json:"observed_at"`
+				ReceivedAt time.Time  `json:"received_at"`
+			}
+			if err := json.Unmarshal(message.Value, &event); err != nil {
+				t.Fatalf("failed to unmarshal message: %v", err)
+			}
+
+			if event.ReceivedAt.IsZero() || time.Since(event.ReceivedAt) > time.Minute {
+				t.Errorf("expected the receive time to be now, got %v", event.ReceivedAt)
+			}
+
+			if tt.offset == nil {
+				if event.ObservedAt != nil {
+					t.Errorf("expected no observation time, got %v", event.ObservedAt)
+				}
+				if !message.Time.Equal(event.ReceivedAt) {
+					t.Errorf("expected the message time to be the receive time %v, got %v", event.ReceivedAt, message.Time)
+				}
+				return
+			}
+
+			if event.ObservedAt == nil {
+				t.Fatal("expected an observation time")
+			}
+			if offset := event.ObservedAt.Sub(event.ReceivedAt); (offset - *tt.offset).Abs() > time.Second {
+				t.Errorf("expected the observation time %v from the receive time, got %v", *tt.offset, offset)
+			}
+			if !message.Time.Equal(*event.ObservedAt) {
+				t.Errorf("expected the message time to be the observation time %v, got %v", event.ObservedAt, message.Time)
+			}
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,423 @@
+package internal
+
+import (
+	"container/list"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// Deduplicator suppresses repeated copies of a measurement. Tags broadcast
+// every measurement several times and several gateways may hear them, so a
+// measurement, identified by the MAC of the tag and its sequence number, is
+// produced only once within the window. Measurements without a sequence
+// number are never suppressed.
+//
+// Sequence numbers wrap around, so they are tracked per tag as a continuous
+// count: after a wraparound a sequence number starts a new measurement, even
+// if the same number was seen within the window before.
+type Deduplicator struct {
+	window    time.Duration
+	capacity  int
+	hold      time.Duration
+	aggregate bool
+
+	mu      sync.Mutex
+	entries map[measurementKey]*list.Element
+	// Entries in the order they were first seen, the oldest at the back
+	order  *list.List
+	tags   map[tagKey]*tagSequence
+	closed bool
+
+	// Held messages being sent to the messaging channel
+	sending sync.WaitGroup
+}
+
+type tagKey struct {
+	mac    string
+	format int
+}
+
+type measurementKey struct {
+	tag      tagKey
+	sequence int64
+}
+
+type measurementEntry struct {
+	key  measurementKey
+	seen time.Time
+	held *heldMessage
+	// pending is set while Kafka has not acknowledged the produced copy of
+	// a measurement sent with synchronous acknowledgements. Copies received
+	// meanwhile wait for the same outcome in waiters.
+	pending bool
+	waiters []func(error)
+}
+
+// tagSequence unwraps the sequence numbers of a tag. unwrapped counts every
+// step of the counter since the tag was first seen, and entries is the number
+// of measurements of the tag in the window.
+type tagSequence struct {
+	latest    int
+	unwrapped int64
+	entries   int
+}
+
+// unwrap returns the continuous count of sequence. Numbers ahead of the
+// latest one by less than half the range of the counter are newer, and the
+// others are late copies of older measurements.
+func (t *tagSequence) unwrap(sequence, modulus int) int64 {
+	ahead := ((sequence-t.latest)%modulus + modulus) % modulus
+	if ahead < modulus/2 {
+		t.latest = sequence
+		t.unwrapped += int64(ahead)
+		return t.unwrapped
+	}
+	return t.unwrapped - int64(modulus-ahead)
+}
+
+// heldMessage is the best copy of a measurement received so far, waiting
+// for the hold time to pass.
+type heldMessage struct {
+	event   events.RuuviKafkaEvent
+	message kafkawrapper.Message
+	rssi    int
+	hasRSSI bool
+	// Receptions of every gateway, when they are aggregated
+	receptions []events.Reception
+	// Done callbacks of the requests whose copy may still be produced
+	done      []func(error)
+	send      func(kafkawrapper.Message)
+	serialize func(*events.RuuviKafkaEvent) ([]byte, error)
+	timer     *time.Timer
+	// delivered, if set, is called with the outcome of the delivery
+	delivered func(error)
+}
+
+// DeduplicatorOption configures a Deduplicator.
+type DeduplicatorOption func(*Deduplicator)
+
+// AggregateReceptions lists the gateways that received the copies of a
+// measurement during the hold time in the produced event, so that tags can
+// be located by the gateways that hear them.
+func AggregateReceptions() DeduplicatorOption {
+	return func(d *Deduplicator) {
+		d.aggregate = true
+	}
+}
+
+// NewDeduplicator returns a deduplicator remembering at most capacity
+// measurements for window. If hold is not zero, the first copy of a
+// measurement is held back for hold and the copy received with the strongest
+// signal is produced; otherwise the first copy is produced immediately. hold
+// must be shorter than window.
+func NewDeduplicator(window time.Duration, capacity int, hold time.Duration, opts ...DeduplicatorOption) (*Deduplicator, error) {
+	d := &Deduplicator{
+		window:   window,
+		capacity: capacity,
+		hold:     hold,
+		entries:  make(map[measurementKey]*list.Element),
+		order:    list.New(),
+		tags:     make(map[tagKey]*tagSequence),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if window <= 0 || capacity < 1 {
+		return nil, errors.New("window and capacity must be positive")
+	}
+	if hold < 0 || hold >= window {
+		return nil, errors.New("hold must be shorter than window")
+	}
+	if d.aggregate && hold == 0 {
+		return nil, errors.New("receptions can only be aggregated with a hold")
+	}
+	return d, nil
+}
+
+// forward sends message, produced from event, to the messaging channel of in
+// unless it is a copy of a measurement seen within the window. Copies are
+// acknowledged as accepted.
+func (d *Deduplicator) forward(in *ingress, event events.RuuviKafkaEvent, message kafkawrapper.Message) *eventResult {
+	data, ok := event.Data.(events.MeasurementData)
+	if !ok {
+		return in.forward(message)
+	}
+
+	mac, hasMAC := events.TagMAC(data)
+	sequence, modulus, hasSequence := events.SequenceNumber(data)
+	if !hasMAC || !hasSequence {
+		return in.forward(message)
+	}
+	rssi, hasRSSI := events.SignalStrength(data)
+
+	reception := events.Reception{SourceUuid: event.SourceUuid, ReceivedAt: event.ReceivedAt}
+	if hasRSSI {
+		reception.RSSI = &rssi
+	}
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return in.forward(message)
+	}
+
+	now := time.Now()
+	release := d.expire(now)
+	defer func() {
+		for _, held := range release {
+			d.send(held)
+		}
+	}()
+
+	tag := tagKey{mac: mac, format: data.Format()}
+	state, ok := d.tags[tag]
+	if !ok {
+		state = &tagSequence{latest: sequence, unwrapped: int64(sequence)}
+		d.tags[tag] = state
+	}
+	key := measurementKey{tag: tag, sequence: state.unwrap(sequence, modulus)}
+
+	if element, ok := d.entries[key]; ok {
+		entry := element.Value.(*measurementEntry)
+		metrics.Duplicates.Inc()
+
+		if held := entry.held; held != nil && d.aggregate {
+			held.addReception(reception)
+		}
+
+		// A held copy is replaced by one received with a stronger signal.
+		// Copies without a signal strength are the weakest.
+		if held := entry.held; held != nil && hasRSSI && (!held.hasRSSI || rssi > held.rssi) {
+			result := in.accept(&message)
+			held.event = event
+			held.message = message
+			held.rssi = rssi
+			held.hasRSSI = true
+			if message.Done != nil {
+				held.done = append(held.done, message.Done)
+			}
+			d.mu.Unlock()
+			return result
+		}
+
+		// The copy is only delivered once the produced copy is, and it
+		// is retried with the produced copy if that fails
+		if entry.pending {
+			result := in.accept(&message)
+			if message.Done != nil {
+				entry.waiters = append(entry.waiters, message.Done)
+			}
+			d.mu.Unlock()
+			return result
+		}
+
+		d.mu.Unlock()
+		return &eventResult{status: http.StatusOK, message: apiresponse.Ok}
+	}
+
+	entry := &measurementEntry{key: key, seen: now}
+	d.entries[key] = d.order.PushFront(entry)
+	state.entries++
+
+	if d.order.Len() > d.capacity {
+		if held := d.remove(d.order.Back().Value.(*measurementEntry)); held != nil {
+			release = append(release, held)
+		}
+	}
+
+	result := in.accept(&message)
+	if message.Done != nil {
+		entry.pending = true
+	}
+
+	if d.hold == 0 {
+		if message.Done != nil {
+			done := message.Done
+			message.Done = func(err error) {
+				done(err)
+				d.delivered(entry, err)
+			}
+		}
+		d.mu.Unlock()
+		in.send(message)
+		return result
+	}
+
+	entry.held = &heldMessage{event: event, message: message, rssi: rssi, hasRSSI: hasRSSI, send: in.send, serialize: in.serialize}
+	if d.aggregate {
+		entry.held.receptions = []events.Reception{reception}
+	}
+	if message.Done != nil {
+		entry.held.done = []func(error){message.Done}
+		entry.held.delivered = func(err error) { d.delivered(entry, err) }
+	}
+	entry.held.timer = time.AfterFunc(d.hold, func() { d.release(entry) })
+	d.mu.Unlock()
+
+	return result
+}
+
+// expire removes the entries older than the window and returns their held
+// messages. It must be called with the lock held.
+func (d *Deduplicator) expire(now time.Time) []*heldMessage {
+	var release []*heldMessage
+	for element := d.order.Back(); element != nil; element = d.order.Back() {
+		entry := element.Value.(*measurementEntry)
+		if now.Sub(entry.seen) <= d.window {
+			break
+		}
+		if held := d.remove(entry); held != nil {
+			release = append(release, held)
+		}
+	}
+	return release
+}
+
+// remove forgets an entry and returns its held message, which the caller must
+// send. It must be called with the lock held.
+func (d *Deduplicator) remove(entry *measurementEntry) *heldMessage {
+	d.order.Remove(d.entries[entry.key])
+	delete(d.entries, entry.key)
+
+	if state := d.tags[entry.key.tag]; state != nil {
+		state.entries--
+		if state.entries == 0 {
+			delete(d.tags, entry.key.tag)
+		}
+	}
+
+	return d.take(entry)
+}
+
+// take removes the held message of an entry, if any, and registers it to be
+// sent. It must be called with the lock held.
+func (d *Deduplicator) take(entry *measurementEntry) *heldMessage {
+	held := entry.held
+	if held == nil {
+		return nil
+	}
+
+	entry.held = nil
+	held.timer.Stop()
+	d.sending.Add(1)
+	return held
+}
+
+// release sends the held message of an entry once its hold time has passed.
+func (d *Deduplicator) release(entry *measurementEntry) {
+	d.mu.Lock()
+	held := d.take(entry)
+	d.mu.Unlock()
+
+	if held != nil {
+		d.send(held)
+	}
+}
+
+// addReception adds the reception of a copy. Gateways hearing several
+// broadcasts of the measurement are listed once, with the strongest signal
+// they received.
+func (h *heldMessage) addReception(reception events.Reception) {
+	for i, r := range h.receptions {
+		if r.SourceUuid != reception.SourceUuid {
+			continue
+		}
+		if reception.RSSI != nil && (r.RSSI == nil || *reception.RSSI > *r.RSSI) {
+			h.receptions[i] = reception
+		}
+		return
+	}
+	h.receptions = append(h.receptions, reception)
+}
+
+// send sends a held message, acknowledging every request that sent a copy of
+// it once it has been delivered.
+func (d *Deduplicator) send(held *heldMessage) {
+	defer d.sending.Done()
+
+	message := held.message
+	if len(held.receptions) > 0 {
+		message.Value = held.aggregatedValue()
+	}
+	if len(held.done) > 0 {
+		message.Done = func(err error) {
+			for _, done := range held.done {
+				done(err)
+			}
+			if held.delivered != nil {
+				held.delivered(err)
+			}
+		}
+	}
+	held.send(message)
+}
+
+// delivered records the outcome of the delivery of the produced copy of an
+// entry and passes it to the copies waiting for it. A measurement that could
+// not be delivered is forgotten, so that the copies retried by the senders
+// are produced.
+func (d *Deduplicator) delivered(entry *measurementEntry, err error) {
+	d.mu.Lock()
+	waiters := entry.waiters
+	entry.waiters = nil
+	entry.pending = false
+
+	if err != nil {
+		if element, ok := d.entries[entry.key]; ok && element.Value == entry {
+			d.remove(entry)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, waiter := range waiters {
+		waiter(err)
+	}
+}
+
+// Close sends the held messages without waiting for their hold time.
+// Measurements received after Close are forwarded without deduplication. It
+// must be called before the messaging channel is closed.
+func (d *Deduplicator) Close() {
+	d.mu.Lock()
+	d.closed = true
+
+	var release []*heldMessage
+	for element := d.order.Front(); element != nil; element = element.Next() {
+		if held := d.take(element.Value.(*measurementEntry)); held != nil {
+			release = append(release, held)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, held := range release {
+		d.send(held)
+	}
+	d.sending.Wait()
+}
+
+// aggregatedValue returns the value of the held message with the receptions
+// of every gateway, marking the gateway whose copy is produced.
+func (h *heldMessage) aggregatedValue() []byte {
+	event := h.event
+	event.Receptions = make([]events.Reception, len(h.receptions))
+	for i, reception := range h.receptions {
+		reception.Strongest = reception.SourceUuid == event.SourceUuid
+		event.Receptions[i] = reception
+	}
+
+	value, err := h.serialize(&event)
+	if err != nil {
+		log.Printf("Failed to add receptions to measurement from %s: %v\n", event.SourceUuid, err)
+		return h.message.Value
+	}
+	return value
+}
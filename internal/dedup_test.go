@@ -0,0 +1,379 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+const rawv1Body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":3,"Temperature":26.3,"Humidity":20.5,"Pressure":102766,"Acceleration":{"X":-1000,"Y":-1726,"Z":714},"Battery":2899,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+func measurementBody(mac string, sequence, rssi int) string {
+	return fmt.Sprintf(`{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":%d,"MAC":"%s","RSSI":%d,"Address":"%s","LocalName":""}}`,
+		sequence, mac, rssi, mac)
+}
+
+// producedSequences returns the sequence numbers of the messages in the
+// channel.
+func producedSequences(t *testing.T, messageChan chan kafkawrapper.Message) []int {
+	t.Helper()
+
+	var sequences []int
+	for len(messageChan) > 0 {
+		var event struct {
+			Data struct {
+				Sequence int
+			}
+		}
+		if err := json.Unmarshal((<-messageChan).Value, &event); err != nil {
+			t.Fatalf("failed to unmarshal message: %v", err)
+		}
+		sequences = append(sequences, event.Data.Sequence)
+	}
+	return sequences
+}
+
+func TestDeduplicator(t *testing.T) {
+	const mac = "E8:D3:AD:C4:6E:18"
+
+	tests := []struct {
+		name     string
+		capacity int
+		bodies   []string
+		produced []int
+	}{
+		{
+			name:     "copies are produced once",
+			bodies:   []string{measurementBody(mac, 1, -70), measurementBody(mac, 1, -60), measurementBody(mac, 2, -70), measurementBody(mac, 1, -70)},
+			produced: []int{1, 2},
+		},
+		{
+			name:     "tags are deduplicated separately",
+			bodies:   []string{measurementBody(mac, 1, -70), measurementBody("AA:BB:CC:DD:EE:FF", 1, -70)},
+			produced: []int{1, 1},
+		},
+		{
+			name: "sequence number after wraparound is new",
+			bodies: []string{
+				measurementBody(mac, 10, -70),
+				measurementBody(mac, 30000, -70),
+				measurementBody(mac, 60000, -70),
+				measurementBody(mac, 10, -70),
+			},
+			produced: []int{10, 30000, 60000, 10},
+		},
+		{
+			name: "late copy across wraparound is a duplicate",
+			bodies: []string{
+				measurementBody(mac, 65534, -70),
+				measurementBody(mac, 0, -70),
+				measurementBody(mac, 65534, -70),
+				measurementBody(mac, 0, -70),
+			},
+			produced: []int{65534, 0},
+		},
+		{
+			name:     "oldest measurements are forgotten over capacity",
+			capacity: 1,
+			bodies:   []string{measurementBody(mac, 1, -70), measurementBody(mac, 2, -70), measurementBody(mac, 1, -70)},
+			produced: []int{1, 2, 1},
+		},
+		{
+			name:     "measurements without a sequence number are not deduplicated",
+			bodies:   []string{rawv1Body, rawv1Body},
+			produced: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity := tt.capacity
+			if capacity == 0 {
+				capacity = 100
+			}
+
+			dedup, err := internal.NewDeduplicator(time.Minute, capacity, 0)
+			if err != nil {
+				t.Fatalf("failed to create deduplicator: %v", err)
+			}
+
+			messageChan := make(chan kafkawrapper.Message, len(tt.bodies))
+			handler := internal.CreateIncomingEventHandler(messageChan, internal.WithDeduplicator(dedup))
+
+			for i, body := range tt.bodies {
+				rr := httptest.NewRecorder()
+				handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+				if rr.Code != http.StatusOK {
+					t.Fatalf("request %d: expected status 200, got %d", i, rr.Code)
+				}
+			}
+
+			if got := producedSequences(t, messageChan); fmt.Sprint(got) != fmt.Sprint(tt.produced) {
+				t.Errorf("expected sequences %v to be produced, got %v", tt.produced, got)
+			}
+		})
+	}
+}
+
+func TestDeduplicatorWindow(t *testing.T) {
+	dedup, err := internal.NewDeduplicator(20*time.Millisecond, 100, 0)
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	messageChan := make(chan kafkawrapper.Message, 2)
+	handler := internal.CreateIncomingEventHandler(messageChan, internal.WithDeduplicator(dedup))
+
+	body := measurementBody("E8:D3:AD:C4:6E:18", 1, -70)
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+	time.Sleep(30 * time.Millisecond)
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+
+	if got := len(messageChan); got != 2 {
+		t.Errorf("expected the measurement to be produced again after the window, got %d messages", got)
+	}
+}
+
+func TestDeduplicatorHoldsBestCopy(t *testing.T) {
+	const mac = "E8:D3:AD:C4:6E:18"
+
+	dedup, err := internal.NewDeduplicator(time.Minute, 100, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	messageChan := make(chan kafkawrapper.Message, 3)
+	handler := internal.CreateIncomingEventHandler(messageChan, internal.WithDeduplicator(dedup))
+
+	for _, rssi := range []int{-80, -60, -70} {
+		handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(measurementBody(mac, 1, rssi))))
+	}
+
+	if got := len(messageChan); got != 0 {
+		t.Fatalf("expected the measurement to be held, got %d messages", got)
+	}
+
+	var message kafkawrapper.Message
+	select {
+	case message = <-messageChan:
+	case <-time.After(time.Second):
+		t.Fatal("expected the held measurement to be produced")
+	}
+
+	var event struct {
+		Data struct {
+			Sequence int
+			RSSI     int
+		}
+	}
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatalf("failed to unmarshal message: %v", err)
+	}
+	if event.Data.Sequence != 1 || event.Data.RSSI != -60 {
+		t.Errorf("expected sequence 1 with RSSI -60, got %+v", event.Data)
+	}
+
+	// Closing releases a held measurement without waiting
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(measurementBody(mac, 2, -90))))
+	dedup.Close()
+	if got := producedSequences(t, messageChan); fmt.Sprint(got) != "[2]" {
+		t.Errorf("expected sequence 2 to be released on close, got %v", got)
+	}
+}
+
+func TestDeduplicatorSynchronousAck(t *testing.T) {
+	dedup, err := internal.NewDeduplicator(time.Minute, 100, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	messageChan := make(chan kafkawrapper.Message, 1)
+	handler := internal.CreateIncomingEventHandler(messageChan,
+		internal.WithDeduplicator(dedup), internal.WithSynchronousAck(time.Second))
+
+	// Acknowledge the produced message
+	go func() {
+		message := <-messageChan
+		message.Done(nil)
+	}()
+
+	// Both copies wait for the delivery of the one that is produced
+	codes := make(chan int, 2)
+	for _, rssi := range []int{-80, -60} {
+		body := measurementBody("E8:D3:AD:C4:6E:18", 1, rssi)
+		go func() {
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+			codes <- rr.Code
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", code)
+		}
+	}
+}
+
+func TestDeduplicatorRetriesFailedDelivery(t *testing.T) {
+	for _, hold := range []time.Duration{0, 20 * time.Millisecond} {
+		t.Run(fmt.Sprintf("hold %v", hold), func(t *testing.T) {
+			dedup, err := internal.NewDeduplicator(time.Minute, 100, hold)
+			if err != nil {
+				t.Fatalf("failed to create deduplicator: %v", err)
+			}
+
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := internal.CreateIncomingEventHandler(messageChan,
+				internal.WithDeduplicator(dedup), internal.WithSynchronousAck(time.Second))
+			body := measurementBody("E8:D3:AD:C4:6E:18", 1, -70)
+
+			post := func() int {
+				rr := httptest.NewRecorder()
+				handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+				return rr.Code
+			}
+
+			// The write of the first copy fails
+			go func() {
+				message := <-messageChan
+				message.Done(errors.New("kafka unavailable"))
+			}()
+			if code := post(); code != http.StatusServiceUnavailable {
+				t.Fatalf("expected status 503, got %d", code)
+			}
+
+			// The retry is produced rather than suppressed as a copy
+			produced := make(chan struct{})
+			go func() {
+				message := <-messageChan
+				message.Done(nil)
+				close(produced)
+			}()
+			if code := post(); code != http.StatusOK {
+				t.Errorf("expected status 200, got %d", code)
+			}
+			select {
+			case <-produced:
+			case <-time.After(time.Second):
+				t.Error("expected the retry to be produced")
+			}
+		})
+	}
+}
+
+func TestDeduplicatorCopyWaitsForDelivery(t *testing.T) {
+	dedup, err := internal.NewDeduplicator(time.Minute, 100, 0)
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	messageChan := make(chan kafkawrapper.Message, 1)
+	handler := internal.CreateIncomingEventHandler(messageChan,
+		internal.WithDeduplicator(dedup), internal.WithSynchronousAck(time.Second))
+
+	// A copy received while the first one is being written gets the
+	// outcome of that write
+	codes := make(chan int, 2)
+	for _, rssi := range []int{-70, -60} {
+		body := measurementBody("E8:D3:AD:C4:6E:18", 1, rssi)
+		go func() {
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+			codes <- rr.Code
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	(<-messageChan).Done(errors.New("kafka unavailable"))
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", code)
+		}
+	}
+}
+
+func TestDeduplicatorAggregatesReceptions(t *testing.T) {
+	if _, err := internal.NewDeduplicator(time.Minute, 100, 0, internal.AggregateReceptions()); err == nil {
+		t.Error("expected an error for aggregation without a hold")
+	}
+
+	dedup, err := internal.NewDeduplicator(time.Minute, 100, 50*time.Millisecond, internal.AggregateReceptions())
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	messageChan := make(chan kafkawrapper.Message, 1)
+	handler := internal.CreateIncomingEventHandler(messageChan, internal.WithDeduplicator(dedup))
+
+	copies := []struct {
+		source string
+		rssi   int
+	}{
+		{source: "gateway-1", rssi: -80},
+		{source: "gateway-2", rssi: -60},
+		{source: "gateway-1", rssi: -70},
+		{source: "gateway-3", rssi: -90},
+	}
+	for _, c := range copies {
+		body := withSource(measurementBody("E8:D3:AD:C4:6E:18", 1, c.rssi), c.source)
+		handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+	}
+
+	var message kafkawrapper.Message
+	select {
+	case message = <-messageChan:
+	case <-time.After(time.Second):
+		t.Fatal("expected the held measurement to be produced")
+	}
+
+	var event struct {
+		SourceUuid string `json:"source_uuid"`
+		Data       struct {
+			RSSI int
+		}
+		Receptions []struct {
+			SourceUuid string    `json:"source_uuid"`
+			RSSI       int       `json:"RSSI"`
+			ReceivedAt time.Time `json:"received_at"`
+			Strongest  bool      `json:"strongest"`
+		}
+	}
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatalf("failed to unmarshal message: %v", err)
+	}
+
+	if event.SourceUuid != "gateway-2" || event.Data.RSSI != -60 {
+		t.Errorf("expected the copy of gateway-2 with RSSI -60, got %s with %d", event.SourceUuid, event.Data.RSSI)
+	}
+
+	expected := []struct {
+		source    string
+		rssi      int
+		strongest bool
+	}{
+		{source: "gateway-1", rssi: -70},
+		{source: "gateway-2", rssi: -60, strongest: true},
+		{source: "gateway-3", rssi: -90},
+	}
+	if len(event.Receptions) != len(expected) {
+		t.Fatalf("expected %d receptions, got %+v", len(expected), event.Receptions)
+	}
+	for i, reception := range event.Receptions {
+		if reception.SourceUuid != expected[i].source || reception.RSSI != expected[i].rssi || reception.Strongest != expected[i].strongest {
+			t.Errorf("reception %d: expected %+v, got %+v", i, expected[i], reception)
+		}
+		if reception.ReceivedAt.IsZero() {
+			t.Errorf("reception %d: expected a reception time", i)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+// HealthCheckTimeout bounds the time a single health check may take, so that
+// a probe is answered before the kubelet gives up on it.
+const HealthCheckTimeout = 2 * time.Second
+
+// HealthCheck checks a single component. It returns nil if the component is
+// healthy, and an error marked with Degraded if the component has a problem
+// that does not make it fail.
+type HealthCheck func(ctx context.Context) error
+
+type degradedError struct {
+	error
+}
+
+func (e degradedError) Unwrap() error {
+	return e.error
+}
+
+// Degraded marks err as a problem that does not fail the health check.
+func Degraded(err error) error {
+	return degradedError{err}
+}
+
+// CreateHealthHandler returns a handler that runs every check and reports the
+// status of each component as JSON. It responds with 503 if any component is
+// failing.
+func CreateHealthHandler(checks map[string]HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), HealthCheckTimeout)
+		defer cancel()
+
+		response := apiresponse.HealthApiResponse{
+			Status:     apiresponse.HealthOk,
+			Components: make(map[string]apiresponse.ComponentHealth, len(checks)),
+		}
+
+		for name, check := range checks {
+			component := componentHealth(check(ctx))
+			response.Components[name] = component
+
+			switch {
+			case component.Status == apiresponse.HealthFailing:
+				response.Status = apiresponse.HealthFailing
+			case component.Status == apiresponse.HealthDegraded && response.Status == apiresponse.HealthOk:
+				response.Status = apiresponse.HealthDegraded
+			}
+		}
+
+		status := http.StatusOK
+		if response.Status == apiresponse.HealthFailing {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func componentHealth(err error) apiresponse.ComponentHealth {
+	var degraded degradedError
+
+	switch {
+	case err == nil:
+		return apiresponse.ComponentHealth{Status: apiresponse.HealthOk}
+	case errors.As(err, &degraded):
+		return apiresponse.ComponentHealth{Status: apiresponse.HealthDegraded, Detail: err.Error()}
+	default:
+		return apiresponse.ComponentHealth{Status: apiresponse.HealthFailing, Detail: err.Error()}
+	}
+}
+
+// ChannelCheck fails when the messaging channel is filled to at least the
+// given fraction of its capacity, i.e. the producer is not keeping up.
+func ChannelCheck(messageChan chan kafkawrapper.Message, threshold float64) HealthCheck {
+	return func(ctx context.Context) error {
+		if cap(messageChan) == 0 {
+			return nil
+		}
+
+		if float64(len(messageChan)) >= threshold*float64(cap(messageChan)) {
+			return fmt.Errorf("channel is saturated: %d of %d messages buffered", len(messageChan), cap(messageChan))
+		}
+		return nil
+	}
+}
+
+// ProducerReadinessCheck fails when the producer cannot deliver messages to
+// Kafka. It is only degraded while messages are spooled instead.
+func ProducerReadinessCheck(producer kafkawrapper.IKafkaProducer) HealthCheck {
+	return func(ctx context.Context) error {
+		err := producer.Ready(ctx)
+		if errors.Is(err, kafkawrapper.ErrSpooling) {
+			return Degraded(err)
+		}
+		return err
+	}
+}
+
+// ProducerLivenessCheck fails when producing messages from the messaging
+// channel is stuck.
+func ProducerLivenessCheck(producer kafkawrapper.IKafkaProducer) HealthCheck {
+	return func(ctx context.Context) error {
+		return producer.Live()
+	}
+}
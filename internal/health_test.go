@@ -0,0 +1,117 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestHealthHandler(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("broken") }
+	degraded := func(ctx context.Context) error { return internal.Degraded(errors.New("slow")) }
+
+	tests := []struct {
+		name             string
+		method           string
+		checks           map[string]internal.HealthCheck
+		expectedStatus   int
+		expectedResponse *apiresponse.HealthApiResponse
+	}{
+		{
+			name:           "all components ok",
+			method:         "GET",
+			checks:         map[string]internal.HealthCheck{"a": ok, "b": ok},
+			expectedStatus: http.StatusOK,
+			expectedResponse: &apiresponse.HealthApiResponse{
+				Status: apiresponse.HealthOk,
+				Components: map[string]apiresponse.ComponentHealth{
+					"a": {Status: apiresponse.HealthOk},
+					"b": {Status: apiresponse.HealthOk},
+				},
+			},
+		},
+		{
+			name:           "degraded component",
+			method:         "GET",
+			checks:         map[string]internal.HealthCheck{"a": ok, "b": degraded},
+			expectedStatus: http.StatusOK,
+			expectedResponse: &apiresponse.HealthApiResponse{
+				Status: apiresponse.HealthDegraded,
+				Components: map[string]apiresponse.ComponentHealth{
+					"a": {Status: apiresponse.HealthOk},
+					"b": {Status: apiresponse.HealthDegraded, Detail: "slow"},
+				},
+			},
+		},
+		{
+			name:           "failing component",
+			method:         "GET",
+			checks:         map[string]internal.HealthCheck{"a": failing, "b": degraded},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedResponse: &apiresponse.HealthApiResponse{
+				Status: apiresponse.HealthFailing,
+				Components: map[string]apiresponse.ComponentHealth{
+					"a": {Status: apiresponse.HealthFailing, Detail: "broken"},
+					"b": {Status: apiresponse.HealthDegraded, Detail: "slow"},
+				},
+			},
+		},
+		{
+			name:           "invalid method",
+			method:         "POST",
+			checks:         map[string]internal.HealthCheck{"a": ok},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/ready", nil)
+			rr := httptest.NewRecorder()
+
+			internal.CreateHealthHandler(tt.checks).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+
+			if tt.expectedResponse == nil {
+				return
+			}
+
+			var response apiresponse.HealthApiResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(&response, tt.expectedResponse) {
+				t.Errorf("handler returned unexpected body: got %+v want %+v", response, tt.expectedResponse)
+			}
+		})
+	}
+}
+
+func TestChannelCheck(t *testing.T) {
+	messageChan := make(chan kafkawrapper.Message, 10)
+	check := internal.ChannelCheck(messageChan, 0.9)
+
+	for i := 0; i < 8; i++ {
+		messageChan <- kafkawrapper.Message{}
+	}
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected channel with 8 of 10 messages to be ok, got %v", err)
+	}
+
+	messageChan <- kafkawrapper.Message{}
+	if err := check(context.Background()); err == nil {
+		t.Error("expected channel with 9 of 10 messages to be saturated")
+	}
+}
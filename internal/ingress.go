@@ -0,0 +1,350 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// IngressOption configures the ingress handlers.
+type IngressOption func(*ingress)
+
+// DefaultMessageKeyField is the event field used as the Kafka message key
+// unless configured otherwise.
+const DefaultMessageKeyField = "MAC"
+
+// ingress holds the configuration shared by the ingress handlers.
+type ingress struct {
+	messageChan chan<- kafkawrapper.Message
+
+	syncAck    bool
+	ackTimeout time.Duration
+
+	keyField string
+
+	skewTolerance time.Duration
+	skewPolicy    ClockSkewPolicy
+
+	limiter  *RateLimiter
+	dedup    *Deduplicator
+	enricher *events.Enricher
+
+	trustedProxies []*net.IPNet
+
+	ranges      *events.RangeValidator
+	rangePolicy RangePolicy
+
+	eventFormat events.EventFormat
+	serializer  kafkawrapper.Serializer
+
+	spoolFull func() bool
+
+	shutdown context.Context
+}
+
+func newIngress(messageChan chan<- kafkawrapper.Message, opts []IngressOption) *ingress {
+	in := &ingress{
+		messageChan:   messageChan,
+		keyField:      DefaultMessageKeyField,
+		skewTolerance: DefaultClockSkewTolerance,
+		skewPolicy:    ClampClockSkew,
+		eventFormat:   events.LegacyFormat,
+	}
+	for _, opt := range opts {
+		opt(in)
+	}
+	return in
+}
+
+// WithSynchronousAck makes the handlers respond only after Kafka has
+// acknowledged every accepted event. Events that are not acknowledged within
+// timeout, or whose write fails, are answered with 503 so that the sender
+// retries them.
+func WithSynchronousAck(timeout time.Duration) IngressOption {
+	return func(in *ingress) {
+		in.syncAck = true
+		in.ackTimeout = timeout
+	}
+}
+
+// WithMessageKeyField sets the event field whose value is used as the Kafka
+// message key, e.g. "MAC" or "source_uuid". Events without the field are
+// keyed by their source UUID. An empty field disables message keys.
+func WithMessageKeyField(field string) IngressOption {
+	return func(in *ingress) {
+		in.keyField = field
+	}
+}
+
+// WithRateLimiter rejects events exceeding the rate limits of limiter with
+// 429 and a Retry-After header.
+func WithRateLimiter(limiter *RateLimiter) IngressOption {
+	return func(in *ingress) {
+		in.limiter = limiter
+	}
+}
+
+// WithTrustedProxies attributes requests from the given proxies, such as an
+// ingress controller, to the client address in their X-Forwarded-For header.
+// The remote IP rate limits would otherwise apply to the proxy as a whole.
+func WithTrustedProxies(proxies []*net.IPNet) IngressOption {
+	return func(in *ingress) {
+		in.trustedProxies = proxies
+	}
+}
+
+// WithDeduplicator acknowledges repeated copies of a measurement without
+// producing them again.
+func WithDeduplicator(dedup *Deduplicator) IngressOption {
+	return func(in *ingress) {
+		in.dedup = dedup
+	}
+}
+
+// WithEnricher adds the quantities derived by enricher to the produced
+// events.
+func WithEnricher(enricher *events.Enricher) IngressOption {
+	return func(in *ingress) {
+		in.enricher = enricher
+	}
+}
+
+// WithEventFormat sets the shape in which events are produced, e.g. the
+// versioned envelope.
+func WithEventFormat(format events.EventFormat) IngressOption {
+	return func(in *ingress) {
+		in.eventFormat = format
+	}
+}
+
+// WithSerializer encodes events with the serializer of the topic, e.g. in
+// protobuf, instead of in JSON.
+func WithSerializer(serializer kafkawrapper.Serializer) IngressOption {
+	return func(in *ingress) {
+		in.serializer = serializer
+	}
+}
+
+// WithSpoolFullCheck answers events with 503 while full reports that the
+// spool of the producer is full, so that the senders retry them instead of
+// the events being dropped.
+func WithSpoolFullCheck(full func() bool) IngressOption {
+	return func(in *ingress) {
+		in.spoolFull = full
+	}
+}
+
+// WithShutdown ends event streams once ctx is done, answering them with the
+// lines read so far, so that long-lived streams do not hold up a graceful
+// shutdown.
+func WithShutdown(ctx context.Context) IngressOption {
+	return func(in *ingress) {
+		in.shutdown = ctx
+	}
+}
+
+// serialize encodes the event into the value of its Kafka record.
+func (in *ingress) serialize(event *events.RuuviKafkaEvent) ([]byte, error) {
+	if in.serializer != nil {
+		return in.serializer.Serialize(event)
+	}
+	return event.Marshal(in.eventFormat)
+}
+
+// eventResult is the outcome of processing a single event. If the event is
+// waiting for a synchronous acknowledgement, ack receives the result of the
+// Kafka write and the status is final only after await. A rate limited event
+// carries the time after which it may be sent again. Rejected events carry
+// an error code, a detail and the fields that failed validation; accepted
+// events may list flagged fields.
+type eventResult struct {
+	status     int
+	message    apiresponse.ApiResponseMessage
+	code       apiresponse.ErrorCode
+	detail     string
+	errors     []apiresponse.FieldError
+	ack        chan error
+	eventType  string
+	retryAfter time.Duration
+}
+
+func rejected(status int, message apiresponse.ApiResponseMessage, code apiresponse.ErrorCode, detail string) *eventResult {
+	return &eventResult{status: status, message: message, code: code, detail: detail}
+}
+
+// response returns the API response describing the result.
+func (r *eventResult) response() apiresponse.ApiResponse {
+	return apiresponse.ApiResponse{Message: r.message, Code: r.code, Detail: r.detail, Errors: r.errors}
+}
+
+// writeError writes the response of a request rejected as a whole.
+func writeError(w http.ResponseWriter, status int, response apiresponse.ApiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkRequest answers requests to another path than path or with another
+// method than POST, and reports whether the request may be handled.
+func checkRequest(w http.ResponseWriter, r *http.Request, path string) bool {
+	if r.URL.Path != path {
+		writeError(w, http.StatusNotFound, apiresponse.ApiResponse{
+			Message: apiresponse.NotFound,
+			Code:    apiresponse.CodeNotFound,
+			Detail:  fmt.Sprintf("no endpoint at %s", r.URL.Path),
+		})
+		return false
+	}
+
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, apiresponse.ApiResponse{
+			Message: apiresponse.InvalidRequest,
+			Code:    apiresponse.CodeMethodNotAllowed,
+			Detail:  fmt.Sprintf("%s is not allowed, use POST", r.Method),
+		})
+		return false
+	}
+
+	return true
+}
+
+// invalidBody counts and answers a request whose body cannot be decoded.
+func invalidBody(w http.ResponseWriter, detail string, fieldErrors []apiresponse.FieldError) {
+	countValidationFailure(apiresponse.CodeInvalidBody)
+	writeError(w, http.StatusBadRequest, apiresponse.ApiResponse{
+		Message: apiresponse.InvalidRequest,
+		Code:    apiresponse.CodeInvalidBody,
+		Detail:  detail,
+		Errors:  fieldErrors,
+	})
+}
+
+// bodyTooLarge counts and answers a request whose body is larger than
+// accepted.
+func bodyTooLarge(w http.ResponseWriter, detail string) {
+	countValidationFailure(apiresponse.CodeBodyTooLarge)
+	writeError(w, http.StatusRequestEntityTooLarge, apiresponse.ApiResponse{
+		Message: apiresponse.RequestTooLarge,
+		Code:    apiresponse.CodeBodyTooLarge,
+		Detail:  detail,
+	})
+}
+
+// decodeError describes an error decoding the JSON value at path, e.g.
+// "data", and the field it concerns if it is known.
+func decodeError(err error, path string) (string, []apiresponse.FieldError) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		if path != "" {
+			path += "."
+		}
+		return err.Error(), []apiresponse.FieldError{{
+			Path:   path + typeErr.Field,
+			Reason: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}}
+	}
+	return err.Error(), nil
+}
+
+// missingFieldErrors returns a field error for each missing field of the
+// JSON value at path.
+func missingFieldErrors(path string, fields []string) []apiresponse.FieldError {
+	if path != "" {
+		path += "."
+	}
+
+	fieldErrors := make([]apiresponse.FieldError, len(fields))
+	for i, field := range fields {
+		fieldErrors[i] = apiresponse.FieldError{Path: path + field, Reason: "missing"}
+	}
+	return fieldErrors
+}
+
+// forward sends a message to the Kafka messaging channel.
+func (in *ingress) forward(message kafkawrapper.Message) *eventResult {
+	result := in.accept(&message)
+	in.send(message)
+	return result
+}
+
+// accept returns the result of an accepted message. With synchronous
+// acknowledgements the result waits for the Done callback of the message.
+func (in *ingress) accept(message *kafkawrapper.Message) *eventResult {
+	result := &eventResult{status: http.StatusOK, message: apiresponse.Ok}
+
+	if in.syncAck {
+		result.ack = make(chan error, 1)
+		message.Done = func(err error) { result.ack <- err }
+	}
+
+	return result
+}
+
+// send sends an accepted message to the Kafka messaging channel.
+func (in *ingress) send(message kafkawrapper.Message) {
+	metrics.ChannelBufferedBytes.Add(float64(len(message.Value)))
+	in.messageChan <- message
+}
+
+// await waits for the acknowledgements of the given results and updates
+// their status. All results share the same deadline.
+func (in *ingress) await(ctx context.Context, results ...*eventResult) {
+	if !in.syncAck {
+		return
+	}
+
+	timeout := time.NewTimer(in.ackTimeout)
+	defer timeout.Stop()
+
+	for _, result := range results {
+		if result.ack == nil {
+			continue
+		}
+
+		select {
+		case err := <-result.ack:
+			if err != nil {
+				result.deliveryFailed("writing the event to Kafka failed")
+			}
+		case <-timeout.C:
+			// Stop waiting for the remaining results too
+			timeout.Reset(0)
+			result.deliveryFailed("Kafka did not acknowledge the event in time")
+		case <-ctx.Done():
+			result.deliveryFailed("the request was cancelled")
+		}
+		result.ack = nil
+	}
+}
+
+// deliveryFailed marks an accepted result as not delivered, so that the
+// sender retries the event.
+func (r *eventResult) deliveryFailed(detail string) {
+	r.status = http.StatusServiceUnavailable
+	r.message = apiresponse.DeliveryFailed
+	r.code = apiresponse.CodeDeliveryFailed
+	r.detail = detail
+}
+
+// responseStatus returns the status of a response summarizing several
+// results. The request is well-formed, so it is answered with 200 unless an
+// event could not be delivered, in which case 503 makes the sender retry the
+// whole request.
+func responseStatus(results ...*eventResult) int {
+	for _, result := range results {
+		if result.status == http.StatusServiceUnavailable {
+			return http.StatusServiceUnavailable
+		}
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+// MaxBatchSize is the maximum number of events accepted in a single batch request.
+const MaxBatchSize = 1000
+
+// MaxBatchBytes is the largest body accepted in a batch request.
+const MaxBatchBytes = 4 << 20
+
+var (
+	errNotBatch      = errors.New("the body is not a JSON array of events")
+	errTooManyEvents = fmt.Errorf("the batch has more than %d events", MaxBatchSize)
+)
+
+// CreateIncomingBatchHandler returns a handler that accepts a JSON array of
+// RuuviEvent objects. Every event is validated independently and the valid
+// ones are forwarded to the Kafka messaging channel. The response contains a
+// result for each item, in request order, so that the sender knows exactly
+// which entries were rejected.
+func CreateIncomingBatchHandler(messageChan chan<- kafkawrapper.Message, opts ...IngressOption) func(http.ResponseWriter, *http.Request) {
+	in := newIngress(messageChan, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Log incoming request
+		log.Printf("Received a batch request: %s %s from %s\n", r.Method, r.URL.Path, r.RemoteAddr)
+
+		ctx := in.withRemoteIP(withTraceID(r), r)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RequestIDHeader, traceIDFromContext(ctx))
+
+		if !checkRequest(w, r, "/events") {
+			return
+		}
+
+		// Try to decode the request body into a list of RuuviEvent objects.
+		// Oversized batches are rejected before they are read completely.
+		batch, err := decodeBatch(http.MaxBytesReader(w, r.Body, MaxBatchBytes))
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			bodyTooLarge(w, fmt.Sprintf("the body is larger than %d bytes", maxBytesErr.Limit))
+			return
+		case errors.Is(err, errTooManyEvents):
+			bodyTooLarge(w, err.Error())
+			return
+		case err != nil:
+			detail, fieldErrors := decodeError(err, "")
+			invalidBody(w, detail, fieldErrors)
+			return
+		}
+		if len(batch) == 0 {
+			invalidBody(w, "the batch is empty", nil)
+			return
+		}
+
+		// Forward every valid event before waiting for any acknowledgements,
+		// so that they can be written to Kafka together
+		results := make([]*eventResult, len(batch))
+		for i, event := range batch {
+			results[i] = in.processEvent(ctx, event)
+		}
+		in.await(ctx, results...)
+		countEvents(results...)
+		setRetryAfter(w, results...)
+
+		response := apiresponse.BatchApiResponse{
+			Message: apiresponse.Ok,
+			Results: make([]apiresponse.BatchItemResult, len(batch)),
+		}
+
+		for i, result := range results {
+			response.Results[i] = apiresponse.BatchItemResult{
+				Index:   i,
+				Status:  result.status,
+				Message: result.message,
+				Code:    result.code,
+				Detail:  result.detail,
+				Errors:  result.errors,
+			}
+
+			if result.status != http.StatusOK {
+				response.Message = apiresponse.PartiallyAccepted
+			}
+		}
+
+		// The batch itself was well-formed, so always respond with 200 and let
+		// the per-item results tell which events were rejected.
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// decodeBatch decodes a JSON array of events one event at a time, and fails
+// with errTooManyEvents as soon as the array has more than MaxBatchSize.
+func decodeBatch(body io.Reader) ([]events.RuuviEvent, error) {
+	decoder := json.NewDecoder(body)
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, errNotBatch
+	}
+
+	var batch []events.RuuviEvent
+	for decoder.More() {
+		if len(batch) == MaxBatchSize {
+			return nil, errTooManyEvents
+		}
+
+		var event events.RuuviEvent
+		if err := decoder.Decode(&event); err != nil {
+			return nil, err
+		}
+		batch = append(batch, event)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
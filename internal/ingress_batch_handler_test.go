@@ -0,0 +1,139 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+const validBatchEvent = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+func TestHandleIncomingBatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedMessages int
+		expectedResults  []apiresponse.BatchItemResult
+	}{
+		{
+			name:             "all valid",
+			method:           "POST",
+			url:              "/events",
+			body:             "[" + validBatchEvent + "," + validBatchEvent + "]",
+			expectedStatus:   http.StatusOK,
+			expectedMessages: 2,
+			expectedResults: []apiresponse.BatchItemResult{
+				{Index: 0, Status: http.StatusOK, Message: apiresponse.Ok},
+				{Index: 1, Status: http.StatusOK, Message: apiresponse.Ok},
+			},
+		},
+		{
+			name:             "partially valid",
+			method:           "POST",
+			url:              "/events",
+			body:             "[" + validBatchEvent + `,{"type":"new_measurement","data":{}},{"type":"unknown","source_uuid":"abc","data":{}}]`,
+			expectedStatus:   http.StatusOK,
+			expectedMessages: 1,
+			expectedResults: []apiresponse.BatchItemResult{
+				{Index: 0, Status: http.StatusOK, Message: apiresponse.Ok},
+				{
+					Index: 1, Status: http.StatusBadRequest, Message: apiresponse.InvalidRequest,
+					Code: apiresponse.CodeMissingSource, Detail: "the event has no source",
+					Errors: []apiresponse.FieldError{{Path: "source_uuid", Reason: "missing"}},
+				},
+				{
+					Index: 2, Status: http.StatusBadRequest, Message: apiresponse.UnknownEvent,
+					Code: apiresponse.CodeUnknownEventType, Detail: `unknown event type "unknown"`,
+					Errors: []apiresponse.FieldError{{Path: "type", Reason: "unknown"}},
+				},
+			},
+		},
+		{
+			name:           "not an array",
+			method:         "POST",
+			url:            "/events",
+			body:           validBatchEvent,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty array",
+			method:         "POST",
+			url:            "/events",
+			body:           "[]",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too many events",
+			method:         "POST",
+			url:            "/events",
+			body:           "[" + strings.Repeat(validBatchEvent+",", internal.MaxBatchSize) + validBatchEvent + "]",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "body too large",
+			method:         "POST",
+			url:            "/events",
+			body:           "[" + validBatchEvent + strings.Repeat(" ", internal.MaxBatchBytes) + "]",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "invalid method",
+			method:         "GET",
+			url:            "/events",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "invalid url",
+			method:         "POST",
+			url:            "/invalid",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 10)
+			handler := http.HandlerFunc(internal.CreateIncomingBatchHandler(messageChan))
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, tt.expectedStatus)
+			}
+
+			if len(messageChan) != tt.expectedMessages {
+				t.Errorf("handler sent wrong number of messages to channel: got %v want %v",
+					len(messageChan), tt.expectedMessages)
+			}
+
+			if tt.expectedResults != nil {
+				var response apiresponse.BatchApiResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(response.Results, tt.expectedResults) {
+					t.Errorf("handler returned wrong results: got %+v want %+v",
+						response.Results, tt.expectedResults)
+				}
+			}
+		})
+	}
+}
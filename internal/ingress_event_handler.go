@@ -1,33 +1,39 @@
 package internal
 
 import (
-	// "encoding/json"
-	// "fmt"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
 	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
 )
 
-func CreateIncomingEventHandler(messageChan chan<- string) func(http.ResponseWriter, *http.Request) {
+func CreateIncomingEventHandler(messageChan chan<- kafkawrapper.Message, opts ...IngressOption) func(http.ResponseWriter, *http.Request) {
+	in := newIngress(messageChan, opts)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Log incoming request
 		log.Printf("Received a request: %s %s from %s\n", r.Method, r.URL.Path, r.RemoteAddr)
 
+		ctx := in.withRemoteIP(withTraceID(r), r)
+
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RequestIDHeader, traceIDFromContext(ctx))
 
-		if r.URL.Path != "/event" {
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.NotFound})
+		if !checkRequest(w, r, "/event") {
 			return
 		}
 
-		if r.Method != "POST" {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
+		// Newline-delimited bodies are processed one event at a time
+		if isEventStream(r) {
+			in.handleEventStream(ctx, w, r)
 			return
 		}
 
@@ -37,66 +43,202 @@ func CreateIncomingEventHandler(messageChan chan<- string) func(http.ResponseWri
 		// Try to decode the request body into the RuuviEvent object
 		err := json.NewDecoder(r.Body).Decode(&event)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
+			detail, fieldErrors := decodeError(err, "")
+			invalidBody(w, detail, fieldErrors)
 			return
 		}
 
-		if event.SourceUuid == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
-			return
+		result := in.processEvent(ctx, event)
+		in.await(ctx, result)
+		countEvents(result)
+
+		// Return a response to the client
+		setRetryAfter(w, result)
+		w.WriteHeader(result.status)
+		json.NewEncoder(w).Encode(result.response())
+	}
+}
+
+// processEvent validates a single decoded RuuviEvent and, if it is valid,
+// forwards it to the Kafka messaging channel. The result holds the HTTP
+// status code and API response message describing the outcome for this
+// event; with synchronous acknowledgements it must be passed to await first.
+func (in *ingress) processEvent(ctx context.Context, event events.RuuviEvent) (result *eventResult) {
+	defer func() {
+		result.eventType = eventTypeLabel(event.Type)
+	}()
+
+	if event.SourceUuid == "" {
+		return invalid(apiresponse.CodeMissingSource, apiresponse.InvalidRequest, "the event has no source",
+			apiresponse.FieldError{Path: "source_uuid", Reason: "missing"})
+	}
+
+	// The API key of the request must be bound to the source
+	if err := checkSource(ctx, event.SourceUuid); err != nil {
+		log.Printf("Rejected event: %v\n", err)
+		countValidationFailure(apiresponse.CodeSourceNotAllowed)
+		return rejected(http.StatusForbidden, apiresponse.SourceNotAllowed, apiresponse.CodeSourceNotAllowed,
+			fmt.Sprintf("the credentials of the request may not send events for %s", event.SourceUuid))
+	}
+
+	// Sources and addresses over their rate limits are rejected before
+	// their data is decoded
+	if result := in.rateLimit(event.SourceUuid, remoteIPFromContext(ctx), ""); result != nil {
+		return result
+	}
+
+	// Events accepted while the spool is full would be dropped
+	if in.spoolFull != nil && in.spoolFull() {
+		return rejected(http.StatusServiceUnavailable, apiresponse.ServiceUnavailable, apiresponse.CodeSpoolFull,
+			"the spool is full, retry later")
+	}
+
+	// The data model depends on the data format of the measurement
+	var data events.MeasurementData
+
+	// Handle different types of events
+	switch event.Type {
+	case events.NewMeasurement:
+		// Try to unmarshal the Data field into the model of its data format
+		var err error
+		data, err = events.UnmarshalMeasurement(event.Data)
+		if errors.Is(err, events.ErrUnsupportedDataFormat) {
+			return invalid(apiresponse.CodeUnsupportedDataFormat, apiresponse.UnsupportedDataFormat, err.Error(),
+				apiresponse.FieldError{Path: "data.DataFormat", Reason: "unsupported"})
+		}
+		if errors.Is(err, events.ErrMissingDataFormat) {
+			return invalid(apiresponse.CodeMalformedData, apiresponse.InvalidRequest, err.Error(),
+				apiresponse.FieldError{Path: "data.DataFormat", Reason: "missing"})
+		}
+		if err != nil {
+			detail, fieldErrors := decodeError(err, "data")
+			return invalid(apiresponse.CodeMalformedData, apiresponse.InvalidRequest, detail, fieldErrors...)
 		}
 
-		// Handle different types of events
-		switch event.Type {
-		case events.NewMeasurement:
-			// Create a new NewMeasurementData object
-			var data events.NewMeasurementData
-
-			// Try to unmarshal the Data field into the NewMeasurementData object
-			err := json.Unmarshal(event.Data, &data)
-			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
-				return
-			}
-
-			// Check if all fields are present
-			if !data.IsValid() {
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
-				return
-			}
-
-			// Handle the NewMeasurementData here
-			// For example, print it to the console
-			fmt.Printf("Received new measurement data: %+v\n", data)
-
-			// Send the data to the Kafka messaging channel
-			kafkaEvent := events.RuuviKafkaEvent{
-				Type:       event.Type,
-				Data:       data,
-				SourceUuid: event.SourceUuid,
-			}
-			kafkaEventJson, err := json.Marshal(kafkaEvent)
-			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.InvalidRequest})
-				return
-			}
-			messageChan <- string(kafkaEventJson)
-
-			// Return a response to the client
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.Ok})
+	case events.RawAdvertisement:
+		var raw events.RawAdvertisementData
 
-			return
+		// Try to unmarshal the Data field into the RawAdvertisementData object
+		err := json.Unmarshal(event.Data, &raw)
+		if err != nil {
+			detail, fieldErrors := decodeError(err, "data")
+			return invalid(apiresponse.CodeMalformedData, apiresponse.InvalidRequest, detail, fieldErrors...)
+		}
+		if !raw.IsValid() {
+			return invalid(apiresponse.CodeMissingFields, apiresponse.InvalidRequest, "required fields are missing",
+				missingFieldErrors("data", raw.MissingFields())...)
+		}
 
-		default:
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(apiresponse.ApiResponse{Message: apiresponse.UnknownEvent})
-			return
+		// Decode the advertisement into measurement data
+		data, err = raw.Decode()
+		if errors.Is(err, events.ErrUnsupportedDataFormat) {
+			return invalid(apiresponse.CodeUnsupportedDataFormat, apiresponse.UnsupportedDataFormat, err.Error(),
+				apiresponse.FieldError{Path: "data.Data", Reason: "unsupported data format"})
+		}
+		if err != nil {
+			log.Printf("Failed to decode raw advertisement from %s: %v\n", event.SourceUuid, err)
+			return invalid(apiresponse.CodeUndecodableAdvertisement, apiresponse.InvalidRequest, err.Error(),
+				apiresponse.FieldError{Path: "data.Data", Reason: err.Error()})
+		}
+
+	default:
+		return invalid(apiresponse.CodeUnknownEventType, apiresponse.UnknownEvent, fmt.Sprintf("unknown event type %q", event.Type),
+			apiresponse.FieldError{Path: "type", Reason: "unknown"})
+	}
+
+	// Readings holding the "not available" value of the data format are not
+	// real readings. They are cleared first, as the decoder of raw
+	// advertisements does, so that both paths are validated alike.
+	events.ClearUnavailable(data)
+
+	// Check if all fields required by the data format are present
+	if !data.IsValid() {
+		return invalid(apiresponse.CodeMissingFields, apiresponse.InvalidRequest,
+			fmt.Sprintf("required fields of data format %d are missing", data.Format()),
+			missingFieldErrors("data", events.MissingFields(data))...)
+	}
+
+	// The readings must be physically plausible
+	var violations []events.RangeViolation
+	if in.ranges != nil {
+		var ok bool
+		if violations, ok = in.checkRanges(event.SourceUuid, data); !ok {
+			return invalid(apiresponse.CodeOutOfRange, apiresponse.OutOfRange, "readings are out of range", fieldErrors(violations)...)
+		}
+	}
+
+	// Gateway clocks may be wrong, so the time the event was observed is
+	// checked against the time it was received
+	receivedAt := time.Now().UTC()
+	observedAt, err := in.checkObservedAt(event.SourceUuid, event.ObservedAt, receivedAt)
+	if err != nil {
+		return invalid(apiresponse.CodeFutureTimestamp, apiresponse.InvalidTimestamp, err.Error(),
+			apiresponse.FieldError{Path: "observed_at", Reason: err.Error()})
+	}
+
+	// Events of tags over their rate limit are rejected before they can
+	// fill the messaging channel
+	if tag, ok := events.TagMAC(data); ok {
+		if result := in.rateLimit("", "", tag); result != nil {
+			return result
 		}
 	}
+
+	// Send the data to the Kafka messaging channel. Raw advertisements are
+	// produced as regular measurements once decoded.
+	kafkaEvent := events.RuuviKafkaEvent{
+		EventID:         events.NewEventID(),
+		Type:            events.NewMeasurement,
+		Data:            data,
+		SourceUuid:      event.SourceUuid,
+		ObservedAt:      observedAt,
+		ReceivedAt:      receivedAt,
+		RangeViolations: violations,
+	}
+	if in.enricher != nil {
+		kafkaEvent.Derived = in.enricher.Derive(data)
+	}
+	value, err := in.serialize(&kafkaEvent)
+	if err != nil {
+		// The schema registry is unavailable, so the sender should retry
+		if in.serializer != nil {
+			log.Printf("Failed to serialize measurement from %s: %v\n", kafkaEvent.SourceUuid, err)
+			return rejected(http.StatusServiceUnavailable, apiresponse.DeliveryFailed, apiresponse.CodeDeliveryFailed, "serializing the event failed")
+		}
+		return rejected(http.StatusBadRequest, apiresponse.InvalidRequest, apiresponse.CodeMalformedData, err.Error())
+	}
+
+	// Attach metadata as headers so that messages can be routed and traced
+	// without parsing their body
+	message := kafkawrapper.Message{
+		Value: value,
+		Headers: []kafkawrapper.Header{
+			{Key: kafkawrapper.HeaderEventType, Value: []byte(kafkaEvent.Type)},
+			{Key: kafkawrapper.HeaderSourceUuid, Value: []byte(kafkaEvent.SourceUuid)},
+			{Key: kafkawrapper.HeaderDataFormat, Value: []byte(strconv.Itoa(data.Format()))},
+			{Key: kafkawrapper.HeaderIngestedAt, Value: []byte(receivedAt.Format(time.RFC3339Nano))},
+			{Key: kafkawrapper.HeaderEventID, Value: []byte(kafkaEvent.EventID)},
+			{Key: kafkawrapper.HeaderSchemaVersion, Value: []byte(in.eventFormat.SchemaVersion())},
+			{Key: kafkawrapper.HeaderTraceID, Value: []byte(traceIDFromContext(ctx))},
+		},
+		// Consumers get the time of the measurement rather than the time
+		// it was appended to the topic
+		Time: kafkaEvent.Time(),
+	}
+
+	// Key the message so that events of the same tag keep their order
+	if in.keyField != "" {
+		message.Key = []byte(kafkaEvent.MessageKey(in.keyField))
+	}
+
+	// The same measurement arrives from every gateway in range of the tag
+	if in.dedup != nil {
+		result = in.dedup.forward(in, kafkaEvent, message)
+	} else {
+		result = in.forward(message)
+	}
+
+	// Flagged readings are reported to the sender as well
+	result.errors = fieldErrors(violations)
+	return result
 }
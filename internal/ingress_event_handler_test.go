@@ -1,14 +1,27 @@
 package internal_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
+var receivedAtPattern = regexp.MustCompile(`,"received_at":"[^"]+"`)
+
 func TestHandleIncomingEvent(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -26,6 +39,38 @@ func TestHandleIncomingEvent(t *testing.T) {
 			expectedStatus:      http.StatusOK,
 			expectedChanMessage: "{\"type\":\"new_measurement\",\"data\":{\"DataFormat\":5,\"Temperature\":22.34,\"Humidity\":42.975,\"Pressure\":97465,\"Acceleration\":{\"X\":-8,\"Y\":-20,\"Z\":1056},\"Battery\":2857,\"TXPower\":4,\"Movement\":75,\"Sequence\":6256,\"MAC\":\"E8:D3:AD:C4:6E:18\",\"RSSI\":-75,\"Address\":\"E8:D3:AD:C4:6E:18\",\"LocalName\":\"\"},\"source_uuid\":\"7d01818b-0332-4adf-99c1-13f833e59c6b\"}",
 		},
+		{
+			name:                "valid raw advertisement",
+			method:              "POST",
+			url:                 "/event",
+			body:                `{"type":"raw_advertisement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"Data":"0201061BFF99040512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F","MAC":"CB:B8:33:4C:88:4F","RSSI":-75}}`,
+			expectedStatus:      http.StatusOK,
+			expectedChanMessage: "{\"type\":\"new_measurement\",\"data\":{\"DataFormat\":5,\"Temperature\":24.3,\"Humidity\":53.49,\"Pressure\":100044,\"Acceleration\":{\"X\":4,\"Y\":-4,\"Z\":1036},\"Battery\":2977,\"TXPower\":4,\"Movement\":66,\"Sequence\":205,\"MAC\":\"CB:B8:33:4C:88:4F\",\"RSSI\":-75,\"Address\":\"CB:B8:33:4C:88:4F\",\"LocalName\":\"\"},\"source_uuid\":\"7d01818b-0332-4adf-99c1-13f833e59c6b\"}",
+		},
+		{
+			name:                "undecodable raw advertisement",
+			method:              "POST",
+			url:                 "/event",
+			body:                `{"type":"raw_advertisement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"Data":"0512FC","MAC":"CB:B8:33:4C:88:4F","RSSI":-75}}`,
+			expectedStatus:      http.StatusBadRequest,
+			expectedChanMessage: "",
+		},
+		{
+			name:                "valid RAWv1 measurement",
+			method:              "POST",
+			url:                 "/event",
+			body:                `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":3,"Temperature":26.3,"Humidity":20.5,"Pressure":102766,"Acceleration":{"X":-1000,"Y":-1726,"Z":714},"Battery":2899,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`,
+			expectedStatus:      http.StatusOK,
+			expectedChanMessage: "{\"type\":\"new_measurement\",\"data\":{\"DataFormat\":3,\"Temperature\":26.3,\"Humidity\":20.5,\"Pressure\":102766,\"Acceleration\":{\"X\":-1000,\"Y\":-1726,\"Z\":714},\"Battery\":2899,\"MAC\":\"E8:D3:AD:C4:6E:18\",\"RSSI\":-75,\"Address\":\"E8:D3:AD:C4:6E:18\",\"LocalName\":\"\"},\"source_uuid\":\"7d01818b-0332-4adf-99c1-13f833e59c6b\"}",
+		},
+		{
+			name:                "unsupported data format",
+			method:              "POST",
+			url:                 "/event",
+			body:                `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":4}}`,
+			expectedStatus:      http.StatusBadRequest,
+			expectedChanMessage: "",
+		},
 		{
 			name:                "invalid method",
 			method:              "GET",
@@ -60,13 +105,18 @@ func TestHandleIncomingEvent(t *testing.T) {
 			}
 
 			rr := httptest.NewRecorder()
-			messageChan := make(chan string, 1)
+			messageChan := make(chan kafkawrapper.Message, 1)
 			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan))
 
 			handler.ServeHTTP(rr, req)
 
 			if tt.expectedChanMessage != "" {
-				receivedMsg := <-messageChan
+				// The receive time differs on every run
+				receivedMsg := string((<-messageChan).Value)
+				if !receivedAtPattern.MatchString(receivedMsg) {
+					t.Errorf("handler did not add the receive time to the message: %v", receivedMsg)
+				}
+				receivedMsg = receivedAtPattern.ReplaceAllString(receivedMsg, "")
 				if receivedMsg != tt.expectedChanMessage {
 					t.Errorf("handler did not send correct message to channel: got %v want %v",
 						receivedMsg, tt.expectedChanMessage)
@@ -80,3 +130,459 @@ func TestHandleIncomingEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleIncomingEventUnavailableReadings(t *testing.T) {
+	// The same measurement with its temperature and acceleration X reported
+	// as not available, sent decoded and as a raw advertisement
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "new measurement",
+			body: `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":-163.84,"Humidity":53.49,"Pressure":100044,"Acceleration":{"X":-32768,"Y":-4,"Z":1036},"Battery":2977,"TXPower":4,"Movement":66,"Sequence":205,"MAC":"CB:B8:33:4C:88:4F","RSSI":-75,"Address":"CB:B8:33:4C:88:4F","LocalName":""}}`,
+		},
+		{
+			name: "raw advertisement",
+			body: `{"type":"raw_advertisement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"Data":"0201061BFF99040580005394C37C8000FFFC040CAC364200CDCBB8334C884F","MAC":"CB:B8:33:4C:88:4F","RSSI":-75}}`,
+		},
+	}
+	expectedChanMessage := "{\"type\":\"new_measurement\",\"data\":{\"DataFormat\":5,\"Temperature\":null,\"Humidity\":53.49,\"Pressure\":100044,\"Acceleration\":{\"X\":null,\"Y\":-4,\"Z\":1036},\"Battery\":2977,\"TXPower\":4,\"Movement\":66,\"Sequence\":205,\"MAC\":\"CB:B8:33:4C:88:4F\",\"RSSI\":-75,\"Address\":\"CB:B8:33:4C:88:4F\",\"LocalName\":\"\"},\"source_uuid\":\"7d01818b-0332-4adf-99c1-13f833e59c6b\"}"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/event", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan))
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s",
+					status, http.StatusOK, rr.Body.String())
+			}
+
+			receivedMsg := receivedAtPattern.ReplaceAllString(string((<-messageChan).Value), "")
+			if receivedMsg != expectedChanMessage {
+				t.Errorf("handler did not send correct message to channel: got %v want %v",
+					receivedMsg, expectedChanMessage)
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventSynchronousAck(t *testing.T) {
+	validBody := `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	tests := []struct {
+		name           string
+		ack            func(kafkawrapper.Message)
+		expectedStatus int
+	}{
+		{
+			name:           "acknowledged",
+			ack:            func(m kafkawrapper.Message) { m.Done(nil) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "write failed",
+			ack:            func(m kafkawrapper.Message) { m.Done(errors.New("kafka unavailable")) },
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "not acknowledged in time",
+			ack:            func(m kafkawrapper.Message) {},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/event", strings.NewReader(validBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan,
+				internal.WithSynchronousAck(100*time.Millisecond)))
+
+			// Act as the Kafka producer
+			go func() {
+				message := <-messageChan
+				if message.Done == nil {
+					t.Error("handler did not request an acknowledgement")
+					return
+				}
+				tt.ack(message)
+			}()
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventSpoolFull(t *testing.T) {
+	req, err := http.NewRequest("POST", "/event", strings.NewReader(validBatchEvent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	messageChan := make(chan kafkawrapper.Message, 1)
+	handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan,
+		internal.WithSpoolFullCheck(func() bool { return true })))
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusServiceUnavailable)
+	}
+	if len(messageChan) != 0 {
+		t.Errorf("handler sent %d messages to a full spool", len(messageChan))
+	}
+
+	var response apiresponse.ApiResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Code != apiresponse.CodeSpoolFull {
+		t.Errorf("handler returned wrong code: got %v want %v", response.Code, apiresponse.CodeSpoolFull)
+	}
+}
+
+func TestHandleIncomingEventMessageKey(t *testing.T) {
+	validBody := `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	tests := []struct {
+		name        string
+		opts        []internal.IngressOption
+		expectedKey []byte
+	}{
+		{
+			name:        "default key field",
+			expectedKey: []byte("E8:D3:AD:C4:6E:18"),
+		},
+		{
+			name:        "source uuid key field",
+			opts:        []internal.IngressOption{internal.WithMessageKeyField("source_uuid")},
+			expectedKey: []byte("7d01818b-0332-4adf-99c1-13f833e59c6b"),
+		},
+		{
+			name:        "keys disabled",
+			opts:        []internal.IngressOption{internal.WithMessageKeyField("")},
+			expectedKey: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/event", strings.NewReader(validBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan, tt.opts...))
+
+			handler.ServeHTTP(rr, req)
+
+			message := <-messageChan
+			if !bytes.Equal(message.Key, tt.expectedKey) {
+				t.Errorf("handler sent wrong message key: got %q want %q", message.Key, tt.expectedKey)
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventHeaders(t *testing.T) {
+	validBody := `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	tests := []struct {
+		name            string
+		requestHeaders  map[string]string
+		expectedTraceID string
+	}{
+		{
+			name:            "request id",
+			requestHeaders:  map[string]string{"X-Request-Id": "request-1"},
+			expectedTraceID: "request-1",
+		},
+		{
+			name:           "request id too long",
+			requestHeaders: map[string]string{"X-Request-Id": strings.Repeat("a", 129)},
+		},
+		{
+			name:           "request id with invalid characters",
+			requestHeaders: map[string]string{"X-Request-Id": "request 1\"}"},
+		},
+		{
+			name: "invalid request id with traceparent",
+			requestHeaders: map[string]string{
+				"X-Request-Id": "<script>",
+				"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+			expectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:            "traceparent",
+			requestHeaders:  map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			expectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name: "generated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/event", strings.NewReader(validBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tt.requestHeaders {
+				req.Header.Set(key, value)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan))
+
+			handler.ServeHTTP(rr, req)
+
+			headers := map[string]string{}
+			for _, header := range (<-messageChan).Headers {
+				headers[header.Key] = string(header.Value)
+			}
+
+			expectedTraceID := tt.expectedTraceID
+			if expectedTraceID == "" {
+				expectedTraceID = rr.Header().Get(internal.RequestIDHeader)
+				if len(expectedTraceID) != 32 {
+					t.Errorf("handler did not generate a trace id: got %q", expectedTraceID)
+				}
+			}
+
+			expectedHeaders := map[string]string{
+				kafkawrapper.HeaderEventType:     "new_measurement",
+				kafkawrapper.HeaderSourceUuid:    "7d01818b-0332-4adf-99c1-13f833e59c6b",
+				kafkawrapper.HeaderDataFormat:    "5",
+				kafkawrapper.HeaderSchemaVersion: "1",
+				kafkawrapper.HeaderTraceID:       expectedTraceID,
+			}
+			for key, expected := range expectedHeaders {
+				if headers[key] != expected {
+					t.Errorf("handler sent wrong %s header: got %q want %q", key, headers[key], expected)
+				}
+			}
+
+			if _, err := time.Parse(time.RFC3339Nano, headers[kafkawrapper.HeaderIngestedAt]); err != nil {
+				t.Errorf("handler sent invalid %s header: %v", kafkawrapper.HeaderIngestedAt, err)
+			}
+			if len(headers[kafkawrapper.HeaderEventID]) != 36 {
+				t.Errorf("handler sent invalid %s header: %q", kafkawrapper.HeaderEventID, headers[kafkawrapper.HeaderEventID])
+			}
+
+			if got := rr.Header().Get(internal.RequestIDHeader); got != expectedTraceID {
+				t.Errorf("handler returned wrong request id: got %q want %q", got, expectedTraceID)
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		eventType string
+		status    string
+		reason    string
+	}{
+		{
+			name:      "accepted",
+			body:      `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`,
+			eventType: "new_measurement",
+			status:    "200",
+		},
+		{
+			name:      "unknown event type",
+			body:      `{"type":"something_else","data":{},"source_uuid":"123"}`,
+			eventType: "unknown",
+			status:    "400",
+			reason:    "unknown_event_type",
+		},
+		{
+			name:      "missing source",
+			body:      `{"type":"new_measurement","data":{}}`,
+			eventType: "new_measurement",
+			status:    "400",
+			reason:    "missing_source",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := metrics.Events.WithLabelValues(tt.eventType, tt.status)
+			eventsBefore := testutil.ToFloat64(events)
+
+			var failuresBefore float64
+			if tt.reason != "" {
+				failuresBefore = testutil.ToFloat64(metrics.ValidationFailures.WithLabelValues(tt.reason))
+			}
+
+			req := httptest.NewRequest("POST", "/event", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			handler := internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, 1))
+			handler(rr, req)
+
+			if got := testutil.ToFloat64(events) - eventsBefore; got != 1 {
+				t.Errorf("expected 1 event with type %s and status %s, got %v", tt.eventType, tt.status, got)
+			}
+
+			if tt.reason != "" {
+				if got := testutil.ToFloat64(metrics.ValidationFailures.WithLabelValues(tt.reason)) - failuresBefore; got != 1 {
+					t.Errorf("expected 1 validation failure for %s, got %v", tt.reason, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		expected apiresponse.ApiResponse
+	}{
+		{
+			name:   "malformed JSON",
+			method: "POST",
+			body:   `{"type":`,
+			expected: apiresponse.ApiResponse{
+				Message: apiresponse.InvalidRequest,
+				Code:    apiresponse.CodeInvalidBody,
+				Detail:  "unexpected EOF",
+			},
+		},
+		{
+			name:   "wrong type",
+			method: "POST",
+			body:   `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":"warm"}}`,
+			expected: apiresponse.ApiResponse{
+				Message: apiresponse.InvalidRequest,
+				Code:    apiresponse.CodeMalformedData,
+				Detail:  "json: cannot unmarshal string into Go struct field NewMeasurementData.Temperature of type float64",
+				Errors:  []apiresponse.FieldError{{Path: "data.Temperature", Reason: "expected float64, got string"}},
+			},
+		},
+		{
+			name:   "missing fields",
+			method: "POST",
+			body:   `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`,
+			expected: apiresponse.ApiResponse{
+				Message: apiresponse.InvalidRequest,
+				Code:    apiresponse.CodeMissingFields,
+				Detail:  "required fields of data format 5 are missing",
+				Errors: []apiresponse.FieldError{
+					{Path: "data.MAC", Reason: "missing"},
+					{Path: "data.RSSI", Reason: "missing"},
+				},
+			},
+		},
+		{
+			name:   "method not allowed",
+			method: "GET",
+			expected: apiresponse.ApiResponse{
+				Message: apiresponse.InvalidRequest,
+				Code:    apiresponse.CodeMethodNotAllowed,
+				Detail:  "GET is not allowed, use POST",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler := internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, 1))
+			handler(rr, httptest.NewRequest(tt.method, "/event", strings.NewReader(tt.body)))
+
+			var response apiresponse.ApiResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if !reflect.DeepEqual(response, tt.expected) {
+				t.Errorf("handler returned wrong response: got %+v want %+v", response, tt.expected)
+			}
+		})
+	}
+}
+
+// serializerFunc adapts a function to kafkawrapper.Serializer.
+type serializerFunc func(*events.RuuviKafkaEvent) ([]byte, error)
+
+func (f serializerFunc) Serialize(event *events.RuuviKafkaEvent) ([]byte, error) {
+	return f(event)
+}
+
+func TestHandleIncomingEventSerializer(t *testing.T) {
+	validBody := `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	tests := []struct {
+		name       string
+		serializer serializerFunc
+		status     int
+		value      string
+	}{
+		{
+			name: "serialized",
+			serializer: func(event *events.RuuviKafkaEvent) ([]byte, error) {
+				return []byte("\x00" + event.SourceUuid), nil
+			},
+			status: http.StatusOK,
+			value:  "\x007d01818b-0332-4adf-99c1-13f833e59c6b",
+		},
+		{
+			name: "registry unavailable",
+			serializer: func(event *events.RuuviKafkaEvent) ([]byte, error) {
+				return nil, errors.New("connection refused")
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := internal.CreateIncomingEventHandler(messageChan, internal.WithSerializer(tt.serializer))
+			handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(validBody)))
+
+			if rr.Code != tt.status {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.status)
+			}
+			if tt.value == "" {
+				if len(messageChan) != 0 {
+					t.Error("expected the event not to be produced")
+				}
+				return
+			}
+			if message := <-messageChan; string(message.Value) != tt.value {
+				t.Errorf("handler sent wrong value: got %q want %q", message.Value, tt.value)
+			}
+		})
+	}
+}
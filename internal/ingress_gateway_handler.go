@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+// CreateGatewayHandler returns a handler that accepts the native HTTP POST
+// payload of the Ruuvi Gateway. Every tag in the payload is decoded from its
+// raw advertisement and forwarded to the Kafka messaging channel as a
+// separate measurement, with the gateway MAC as its source.
+func CreateGatewayHandler(messageChan chan<- kafkawrapper.Message, opts ...IngressOption) func(http.ResponseWriter, *http.Request) {
+	in := newIngress(messageChan, opts)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Log incoming request
+		log.Printf("Received a gateway request: %s %s from %s\n", r.Method, r.URL.Path, r.RemoteAddr)
+
+		ctx := in.withRemoteIP(withTraceID(r), r)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RequestIDHeader, traceIDFromContext(ctx))
+
+		if !checkRequest(w, r, "/gateway") {
+			return
+		}
+
+		// Try to decode the request body into the GatewayPayload object
+		var payload events.GatewayPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			detail, fieldErrors := decodeError(err, "")
+			invalidBody(w, detail, fieldErrors)
+			return
+		}
+		if !payload.IsValid() {
+			invalidBody(w, "the payload is not a Ruuvi Gateway payload", missingFieldErrors("", payload.MissingFields()))
+			return
+		}
+
+		tagEvents, err := payload.Events()
+		if err != nil {
+			invalidBody(w, err.Error(), nil)
+			return
+		}
+
+		results := make([]*eventResult, len(tagEvents))
+		for i, event := range tagEvents {
+			results[i] = in.processEvent(ctx, event)
+		}
+		in.await(ctx, results...)
+		countEvents(results...)
+		setRetryAfter(w, results...)
+
+		response := apiresponse.GatewayApiResponse{
+			Message: apiresponse.Ok,
+			Results: make([]apiresponse.GatewayTagResult, len(results)),
+		}
+		for i, mac := range payload.TagMACs() {
+			result := results[i]
+			response.Results[i] = apiresponse.GatewayTagResult{
+				MAC:     mac,
+				Status:  result.status,
+				Message: result.message,
+				Code:    result.code,
+				Detail:  result.detail,
+				Errors:  result.errors,
+			}
+
+			if result.status == http.StatusOK {
+				response.Accepted++
+			} else {
+				response.Rejected++
+			}
+		}
+
+		if response.Rejected > 0 {
+			response.Message = apiresponse.PartiallyAccepted
+		}
+
+		w.WriteHeader(responseStatus(results...))
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,196 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestHandleGatewayPayload(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		url              string
+		body             string
+		expectedStatus   int
+		expectedResponse *apiresponse.GatewayApiResponse
+	}{
+		{
+			name:   "valid payload",
+			method: "POST",
+			url:    "/gateway",
+			body: `{"data":{"coordinates":"","timestamp":1574082635,"gw_mac":"AA:BB:CC:DD:EE:FF","tags":{
+				"CB:B8:33:4C:88:4F":{"rssi":-65,"timestamp":1574082630,"data":"0201061BFF99040512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"},
+				"C8:25:2D:8E:9C:2C":{"rssi":-51,"timestamp":1574082635,"data":"02010611FF990403291A1ECE1EFC18F94202CA0B53"}}}}`,
+			expectedStatus: http.StatusOK,
+			expectedResponse: &apiresponse.GatewayApiResponse{
+				Message:  apiresponse.Ok,
+				Accepted: 2,
+				Results: []apiresponse.GatewayTagResult{
+					{MAC: "C8:25:2D:8E:9C:2C", Status: http.StatusOK, Message: apiresponse.Ok},
+					{MAC: "CB:B8:33:4C:88:4F", Status: http.StatusOK, Message: apiresponse.Ok},
+				},
+			},
+		},
+		{
+			name:   "Ruuvi Air tags",
+			method: "POST",
+			url:    "/gateway",
+			body: `{"data":{"coordinates":"","timestamp":1574082635,"gw_mac":"AA:BB:CC:DD:EE:FF","tags":{
+				"D1:E2:F3:4C:88:4F":{"rssi":-60,"timestamp":1574082630,"data":"02010617FF990406170C5668C79E007000C90501D9FFCD004C884F"},
+				"CB:B8:33:4C:88:4F":{"rssi":-62,"timestamp":1574082632,"data":"9904E1170C5668C79E0065007004BD11CA00C90A0213E0AC373050DECDEE09FFFFFFFFFFCBB8334C884F"}}}}`,
+			expectedStatus: http.StatusOK,
+			expectedResponse: &apiresponse.GatewayApiResponse{
+				Message:  apiresponse.Ok,
+				Accepted: 2,
+				Results: []apiresponse.GatewayTagResult{
+					{MAC: "CB:B8:33:4C:88:4F", Status: http.StatusOK, Message: apiresponse.Ok},
+					{MAC: "D1:E2:F3:4C:88:4F", Status: http.StatusOK, Message: apiresponse.Ok},
+				},
+			},
+		},
+		{
+			name:   "undecodable tag",
+			method: "POST",
+			url:    "/gateway",
+			body: `{"data":{"coordinates":"","timestamp":1574082635,"gw_mac":"AA:BB:CC:DD:EE:FF","tags":{
+				"CB:B8:33:4C:88:4F":{"rssi":-65,"timestamp":1574082630,"data":"0201061BFF99040512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"},
+				"11:22:33:44:55:66":{"rssi":-80,"timestamp":1574082635,"data":"not hex"}}}}`,
+			expectedStatus: http.StatusOK,
+			expectedResponse: &apiresponse.GatewayApiResponse{
+				Message:  apiresponse.PartiallyAccepted,
+				Accepted: 1,
+				Rejected: 1,
+				Results: []apiresponse.GatewayTagResult{
+					{
+						MAC:     "11:22:33:44:55:66",
+						Status:  http.StatusBadRequest,
+						Message: apiresponse.InvalidRequest,
+						Code:    apiresponse.CodeUndecodableAdvertisement,
+						Detail:  "invalid hex data: encoding/hex: invalid byte: U+006E 'n'",
+						Errors:  []apiresponse.FieldError{{Path: "data.Data", Reason: "invalid hex data: encoding/hex: invalid byte: U+006E 'n'"}},
+					},
+					{MAC: "CB:B8:33:4C:88:4F", Status: http.StatusOK, Message: apiresponse.Ok},
+				},
+			},
+		},
+		{
+			name:           "missing gateway MAC",
+			method:         "POST",
+			url:            "/gateway",
+			body:           `{"data":{"tags":{}}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid method",
+			method:         "GET",
+			url:            "/gateway",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "invalid url",
+			method:         "POST",
+			url:            "/invalid",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 10)
+			handler := http.HandlerFunc(internal.CreateGatewayHandler(messageChan))
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, tt.expectedStatus)
+			}
+
+			if tt.expectedResponse != nil {
+				var response apiresponse.GatewayApiResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(response, *tt.expectedResponse) {
+					t.Errorf("handler returned wrong response: got %+v want %+v",
+						response, *tt.expectedResponse)
+				}
+
+				if len(messageChan) != tt.expectedResponse.Accepted {
+					t.Errorf("handler sent wrong number of messages to channel: got %v want %v",
+						len(messageChan), tt.expectedResponse.Accepted)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleGatewayPayloadDeliveryFailed(t *testing.T) {
+	body := `{"data":{"coordinates":"","timestamp":1574082635,"gw_mac":"AA:BB:CC:DD:EE:FF","tags":{
+		"CB:B8:33:4C:88:4F":{"rssi":-65,"timestamp":1574082630,"data":"0201061BFF99040512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"},
+		"C8:25:2D:8E:9C:2C":{"rssi":-51,"timestamp":1574082635,"data":"02010611FF990403291A1ECE1EFC18F94202CA0B53"}}}}`
+
+	req, err := http.NewRequest("POST", "/gateway", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	messageChan := make(chan kafkawrapper.Message, 10)
+	handler := http.HandlerFunc(internal.CreateGatewayHandler(messageChan,
+		internal.WithSynchronousAck(100*time.Millisecond)))
+
+	// Act as the Kafka producer, failing the write of the second tag
+	go func() {
+		(<-messageChan).Done(nil)
+		(<-messageChan).Done(errors.New("kafka unavailable"))
+	}()
+
+	handler.ServeHTTP(rr, req)
+
+	// The gateway retries the whole payload only if it is not answered with 2xx
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusServiceUnavailable)
+	}
+
+	var response apiresponse.GatewayApiResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	expected := apiresponse.GatewayApiResponse{
+		Message:  apiresponse.PartiallyAccepted,
+		Accepted: 1,
+		Rejected: 1,
+		Results: []apiresponse.GatewayTagResult{
+			{MAC: "C8:25:2D:8E:9C:2C", Status: http.StatusOK, Message: apiresponse.Ok},
+			{
+				MAC:     "CB:B8:33:4C:88:4F",
+				Status:  http.StatusServiceUnavailable,
+				Message: apiresponse.DeliveryFailed,
+				Code:    apiresponse.CodeDeliveryFailed,
+				Detail:  "writing the event to Kafka failed",
+			},
+		},
+	}
+	if !reflect.DeepEqual(response, expected) {
+		t.Errorf("handler returned wrong response: got %+v want %+v", response, expected)
+	}
+}
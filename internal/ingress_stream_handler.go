@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+// NDJSONContentType is the content type that switches the ingress handler
+// into streaming mode.
+const NDJSONContentType = "application/x-ndjson"
+
+// MaxStreamLineBytes is the longest line accepted in an event stream. Longer
+// lines are skipped and counted as malformed.
+const MaxStreamLineBytes = 64 << 10
+
+// isEventStream reports whether the request body is a newline-delimited
+// stream of RuuviEvents.
+func isEventStream(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == NDJSONContentType
+}
+
+// MaxStreamResults is the number of lines that were not accepted listed in
+// the summary of an event stream. All of them are counted.
+const MaxStreamResults = 100
+
+// StreamAckWindow is the number of events of an event stream that may wait
+// for their acknowledgement at a time with synchronous acknowledgements.
+// Reading the stream pauses while the oldest of them is awaited.
+const StreamAckWindow = 1000
+
+// lineResult is the result of the event on a line of an event stream.
+type lineResult struct {
+	line   int
+	result *eventResult
+}
+
+// handleEventStream decodes newline-delimited RuuviEvents from the request
+// body and forwards each one to the Kafka messaging channel as soon as its
+// line has been read, so a single long-lived request can carry an unbounded
+// number of events. With synchronous acknowledgements the events are awaited
+// as the stream goes, at most StreamAckWindow of them at a time. Malformed or
+// invalid lines do not abort the stream; they are counted and reported in the
+// summary response written once the body has been fully consumed.
+func (in *ingress) handleEventStream(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	summary := apiresponse.StreamApiResponse{Message: apiresponse.Ok}
+	reader := bufio.NewReaderSize(r.Body, MaxStreamLineBytes)
+
+	if in.shutdown != nil {
+		// Reading the body fails once the server shuts down. The deadline
+		// must not be set after the handler has returned, when the
+		// connection may be serving the next request.
+		ended := make(chan struct{})
+		stop := context.AfterFunc(in.shutdown, func() {
+			defer close(ended)
+			http.NewResponseController(w).SetReadDeadline(time.Now())
+		})
+		defer func() {
+			if !stop() {
+				<-ended
+			}
+		}()
+	}
+
+	// Lines waiting for a synchronous acknowledgement, at most
+	// StreamAckWindow of them
+	var pending []lineResult
+	// The first lines that were not accepted, up to MaxStreamResults
+	var failed []lineResult
+	// The longest Retry-After of the rate limited lines
+	var retryAfter time.Duration
+	status := http.StatusOK
+
+	// done adds the result of a line to the summary
+	done := func(line int, result *eventResult, malformed bool) {
+		if result.retryAfter > retryAfter {
+			retryAfter = result.retryAfter
+		}
+		if result.status == http.StatusOK {
+			summary.Accepted++
+			return
+		}
+
+		if malformed {
+			summary.Malformed++
+		} else {
+			summary.Rejected++
+		}
+		if status == http.StatusOK {
+			status = responseStatus(result)
+		}
+
+		// Acknowledged lines may precede the lines rejected meanwhile
+		failed = append(failed, lineResult{line: line, result: result})
+		if len(failed) > MaxStreamResults {
+			sort.Slice(failed, func(i, j int) bool { return failed[i].line < failed[j].line })
+			failed = failed[:MaxStreamResults]
+		}
+	}
+	// acknowledged waits for the acknowledgements of the n oldest pending
+	// lines
+	acknowledged := func(n int) {
+		results := make([]*eventResult, n)
+		for i, p := range pending[:n] {
+			results[i] = p.result
+		}
+		in.await(ctx, results...)
+		countEvents(results...)
+		for _, p := range pending[:n] {
+			done(p.line, p.result, false)
+		}
+		pending = pending[n:]
+	}
+
+	for number := 1; ; number++ {
+		line, readErr := reader.ReadSlice('\n')
+		if errors.Is(readErr, bufio.ErrBufferFull) {
+			countValidationFailure(apiresponse.CodeInvalidBody)
+			done(number, rejected(http.StatusBadRequest, apiresponse.InvalidRequest, apiresponse.CodeInvalidBody,
+				fmt.Sprintf("the line is longer than %d bytes", MaxStreamLineBytes)), true)
+			if readErr = skipLine(reader); readErr == nil {
+				continue
+			}
+			line = nil
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var event events.RuuviEvent
+
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			if err := decoder.Decode(&event); err != nil {
+				countValidationFailure(apiresponse.CodeInvalidBody)
+				detail, fieldErrors := decodeError(err, "")
+				result := rejected(http.StatusBadRequest, apiresponse.InvalidRequest, apiresponse.CodeInvalidBody, detail)
+				result.errors = fieldErrors
+				done(number, result, true)
+			} else if decoder.More() {
+				countValidationFailure(apiresponse.CodeInvalidBody)
+				done(number, rejected(http.StatusBadRequest, apiresponse.InvalidRequest, apiresponse.CodeInvalidBody,
+					"the line holds more than one event"), true)
+			} else if result := in.processEvent(ctx, event); result.ack != nil {
+				pending = append(pending, lineResult{line: number, result: result})
+				if len(pending) == StreamAckWindow {
+					acknowledged(1)
+				}
+			} else {
+				countEvents(result)
+				done(number, result, false)
+			}
+		}
+
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				if in.shutdown != nil && in.shutdown.Err() != nil {
+					log.Printf("Event stream from %s was ended by the shutdown\n", r.RemoteAddr)
+				} else {
+					log.Printf("Event stream from %s ended with an error: %v\n", r.RemoteAddr, readErr)
+				}
+				summary.Message = apiresponse.StreamInterrupted
+			}
+			break
+		}
+	}
+
+	acknowledged(len(pending))
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].line < failed[j].line })
+	for _, f := range failed {
+		summary.Results = append(summary.Results, apiresponse.StreamItemResult{
+			Line:    f.line,
+			Status:  f.result.status,
+			Message: f.result.message,
+			Code:    f.result.code,
+			Detail:  f.result.detail,
+			Errors:  f.result.errors,
+		})
+	}
+
+	if summary.Message == apiresponse.Ok && (summary.Malformed > 0 || summary.Rejected > 0) {
+		summary.Message = apiresponse.PartiallyAccepted
+	}
+
+	log.Printf("Event stream from %s finished: %d accepted, %d rejected, %d malformed\n",
+		r.RemoteAddr, summary.Accepted, summary.Rejected, summary.Malformed)
+
+	writeRetryAfter(w, retryAfter)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// skipLine discards the rest of a line that did not fit in the buffer of
+// reader.
+func skipLine(reader *bufio.Reader) error {
+	for {
+		_, err := reader.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestHandleIncomingEventStream(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		expectedSummary apiresponse.StreamApiResponse
+	}{
+		{
+			name: "all valid",
+			body: validBatchEvent + "\n" + validBatchEvent + "\n",
+			expectedSummary: apiresponse.StreamApiResponse{
+				Message:  apiresponse.Ok,
+				Accepted: 2,
+			},
+		},
+		{
+			name: "malformed and invalid lines",
+			body: validBatchEvent + "\n{not json\n\n" + `{"type":"new_measurement","data":{}}` + "\n" + validBatchEvent,
+			expectedSummary: apiresponse.StreamApiResponse{
+				Message:   apiresponse.PartiallyAccepted,
+				Accepted:  2,
+				Rejected:  1,
+				Malformed: 1,
+				Results: []apiresponse.StreamItemResult{
+					{
+						Line:    2,
+						Status:  http.StatusBadRequest,
+						Message: apiresponse.InvalidRequest,
+						Code:    apiresponse.CodeInvalidBody,
+						Detail:  "invalid character 'n' looking for beginning of object key string",
+					},
+					{
+						Line:    4,
+						Status:  http.StatusBadRequest,
+						Message: apiresponse.InvalidRequest,
+						Code:    apiresponse.CodeMissingSource,
+						Detail:  "the event has no source",
+						Errors:  []apiresponse.FieldError{{Path: "source_uuid", Reason: "missing"}},
+					},
+				},
+			},
+		},
+		{
+			name: "line too long",
+			body: validBatchEvent + "\n" + strings.Repeat(" ", internal.MaxStreamLineBytes) + validBatchEvent + "\n" + validBatchEvent,
+			expectedSummary: apiresponse.StreamApiResponse{
+				Message:   apiresponse.PartiallyAccepted,
+				Accepted:  2,
+				Malformed: 1,
+				Results: []apiresponse.StreamItemResult{{
+					Line:    2,
+					Status:  http.StatusBadRequest,
+					Message: apiresponse.InvalidRequest,
+					Code:    apiresponse.CodeInvalidBody,
+					Detail:  "the line is longer than 65536 bytes",
+				}},
+			},
+		},
+		{
+			name: "two objects on one line",
+			body: validBatchEvent + validBatchEvent + "\n",
+			expectedSummary: apiresponse.StreamApiResponse{
+				Message:   apiresponse.PartiallyAccepted,
+				Malformed: 1,
+				Results: []apiresponse.StreamItemResult{{
+					Line:    1,
+					Status:  http.StatusBadRequest,
+					Message: apiresponse.InvalidRequest,
+					Code:    apiresponse.CodeInvalidBody,
+					Detail:  "the line holds more than one event",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/event", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", internal.NDJSONContentType)
+
+			rr := httptest.NewRecorder()
+			messageChan := make(chan kafkawrapper.Message, 10)
+			handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan))
+
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, http.StatusOK)
+			}
+
+			var summary apiresponse.StreamApiResponse
+			if err := json.NewDecoder(rr.Body).Decode(&summary); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(summary, tt.expectedSummary) {
+				t.Errorf("handler returned wrong summary: got %+v want %+v",
+					summary, tt.expectedSummary)
+			}
+
+			if len(messageChan) != tt.expectedSummary.Accepted {
+				t.Errorf("handler sent wrong number of messages to channel: got %v want %v",
+					len(messageChan), tt.expectedSummary.Accepted)
+			}
+		})
+	}
+}
+
+func TestHandleIncomingEventStreamForwardsBeforeEndOfBody(t *testing.T) {
+	body, bodyWriter := io.Pipe()
+
+	req, err := http.NewRequest("POST", "/event", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", internal.NDJSONContentType+"; charset=utf-8")
+
+	rr := httptest.NewRecorder()
+	messageChan := make(chan kafkawrapper.Message, 10)
+	handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rr, req)
+		close(done)
+	}()
+
+	bodyWriter.Write([]byte(validBatchEvent + "\n"))
+
+	select {
+	case <-messageChan:
+	case <-time.After(time.Second):
+		t.Fatal("event was not forwarded while the stream was still open")
+	}
+
+	bodyWriter.Close()
+	<-done
+}
+
+func TestHandleIncomingEventStreamEndedByShutdown(t *testing.T) {
+	shutdown, endStreams := context.WithCancel(context.Background())
+	defer endStreams()
+
+	messageChan := make(chan kafkawrapper.Message, 10)
+	server := httptest.NewServer(http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan,
+		internal.WithShutdown(shutdown))))
+	defer server.Close()
+
+	// The body is never closed by the client
+	body, bodyWriter := io.Pipe()
+	defer bodyWriter.Close()
+
+	go func() {
+		bodyWriter.Write([]byte(validBatchEvent + "\n"))
+		<-messageChan
+		endStreams()
+	}()
+
+	resp, err := http.Post(server.URL+"/event", internal.NDJSONContentType, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var summary apiresponse.StreamApiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	expected := apiresponse.StreamApiResponse{Message: apiresponse.StreamInterrupted, Accepted: 1}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("handler returned wrong summary: got %+v want %+v", summary, expected)
+	}
+}
+
+func TestHandleIncomingEventStreamDeliveryFailed(t *testing.T) {
+	req, err := http.NewRequest("POST", "/event", strings.NewReader(validBatchEvent+"\n"+validBatchEvent+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", internal.NDJSONContentType)
+
+	rr := httptest.NewRecorder()
+	messageChan := make(chan kafkawrapper.Message, 10)
+	handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan,
+		internal.WithSynchronousAck(100*time.Millisecond)))
+
+	// Act as the Kafka producer, failing the write of the second event
+	go func() {
+		(<-messageChan).Done(nil)
+		(<-messageChan).Done(errors.New("kafka unavailable"))
+	}()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusServiceUnavailable)
+	}
+
+	var summary apiresponse.StreamApiResponse
+	if err := json.NewDecoder(rr.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	expected := apiresponse.StreamApiResponse{
+		Message:  apiresponse.PartiallyAccepted,
+		Accepted: 1,
+		Rejected: 1,
+		Results: []apiresponse.StreamItemResult{{
+			Line:    2,
+			Status:  http.StatusServiceUnavailable,
+			Message: apiresponse.DeliveryFailed,
+			Code:    apiresponse.CodeDeliveryFailed,
+			Detail:  "writing the event to Kafka failed",
+		}},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("handler returned wrong summary: got %+v want %+v", summary, expected)
+	}
+}
+
+func TestHandleIncomingEventStreamAwaitsAcknowledgementsInWindow(t *testing.T) {
+	req, err := http.NewRequest("POST", "/event",
+		strings.NewReader(strings.Repeat(validBatchEvent+"\n", internal.StreamAckWindow+1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", internal.NDJSONContentType)
+
+	rr := httptest.NewRecorder()
+	messageChan := make(chan kafkawrapper.Message, internal.StreamAckWindow+1)
+	handler := http.HandlerFunc(internal.CreateIncomingEventHandler(messageChan,
+		internal.WithSynchronousAck(time.Second)))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rr, req)
+		close(done)
+	}()
+
+	// The stream is not read further while the window is full
+	var messages []kafkawrapper.Message
+	for len(messages) < internal.StreamAckWindow {
+		select {
+		case message := <-messageChan:
+			messages = append(messages, message)
+		case <-time.After(time.Second):
+			t.Fatalf("only %d events were forwarded", len(messages))
+		}
+	}
+	select {
+	case <-messageChan:
+		t.Fatal("event was forwarded while the acknowledgement window was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	messages[0].Done(nil)
+	select {
+	case message := <-messageChan:
+		messages = append(messages, message)
+	case <-time.After(time.Second):
+		t.Fatal("event was not forwarded after the oldest event was acknowledged")
+	}
+	for _, message := range messages[1:] {
+		message.Done(nil)
+	}
+	<-done
+
+	var summary apiresponse.StreamApiResponse
+	if err := json.NewDecoder(rr.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Accepted != internal.StreamAckWindow+1 {
+		t.Errorf("handler accepted %d events, want %d", summary.Accepted, internal.StreamAckWindow+1)
+	}
+}
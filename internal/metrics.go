@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// countValidationFailure counts an event or request body rejected with the
+// given error code, which is used as the reason label.
+func countValidationFailure(code apiresponse.ErrorCode) {
+	metrics.ValidationFailures.WithLabelValues(string(code)).Inc()
+}
+
+// invalid counts a validation failure and rejects the event with 400.
+func invalid(code apiresponse.ErrorCode, message apiresponse.ApiResponseMessage, detail string, fieldErrors ...apiresponse.FieldError) *eventResult {
+	countValidationFailure(code)
+	result := rejected(http.StatusBadRequest, message, code, detail)
+	result.errors = fieldErrors
+	return result
+}
+
+// countEvents counts events by their type and final status.
+func countEvents(results ...*eventResult) {
+	for _, result := range results {
+		metrics.Events.WithLabelValues(result.eventType, strconv.Itoa(result.status)).Inc()
+	}
+}
+
+// eventTypeLabel returns the metric label of an event type. Unknown types
+// share a label to keep the number of series bounded.
+func eventTypeLabel(eventType events.RuuviEventTypes) string {
+	switch eventType {
+	case events.NewMeasurement, events.RawAdvertisement:
+		return string(eventType)
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// RangePolicy decides what happens to measurements with readings outside
+// their bounds.
+type RangePolicy string
+
+const (
+	// RejectOutOfRange rejects the event with 400.
+	RejectOutOfRange RangePolicy = "reject"
+	// FlagOutOfRange produces the event with the offending readings listed
+	// in range_violations.
+	FlagOutOfRange RangePolicy = "flag"
+)
+
+// ParseRangePolicy returns the policy with the given name.
+func ParseRangePolicy(s string) (RangePolicy, error) {
+	switch policy := RangePolicy(s); policy {
+	case RejectOutOfRange, FlagOutOfRange:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown range policy %q", s)
+	}
+}
+
+// LoadRangeLimits returns a validator using the default bounds with the
+// overrides in the file at path, which contains an events.RangeLimits object.
+func LoadRangeLimits(path string) (*events.RangeValidator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits events.RangeLimits
+	if err := json.Unmarshal(content, &limits); err != nil {
+		return nil, err
+	}
+	return events.NewRangeValidator(limits)
+}
+
+// WithRangeValidation checks the readings of measurements against the bounds
+// of validator and handles the measurements outside them by policy.
+func WithRangeValidation(validator *events.RangeValidator, policy RangePolicy) IngressOption {
+	return func(in *ingress) {
+		in.ranges = validator
+		in.rangePolicy = policy
+	}
+}
+
+// checkRanges returns the readings of data outside their bounds, and reports
+// false if the event must be rejected.
+func (in *ingress) checkRanges(source string, data events.MeasurementData) ([]events.RangeViolation, bool) {
+	violations := in.ranges.Validate(data)
+	if len(violations) == 0 {
+		return nil, true
+	}
+
+	for _, violation := range violations {
+		metrics.RangeViolations.WithLabelValues(violation.Field, string(in.rangePolicy)).Inc()
+	}
+
+	if in.rangePolicy == RejectOutOfRange {
+		log.Printf("Rejected measurement from %s with %d readings out of range\n", source, len(violations))
+		return violations, false
+	}
+	return violations, true
+}
+
+// fieldErrors describes the readings outside their bounds in a response.
+func fieldErrors(violations []events.RangeViolation) []apiresponse.FieldError {
+	var errors []apiresponse.FieldError
+	for _, violation := range violations {
+		errors = append(errors, apiresponse.FieldError{Path: "data." + violation.Field, Reason: violation.Error()})
+	}
+	return errors
+}
@@ -0,0 +1,94 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestRangeValidation(t *testing.T) {
+	// Humidity of 163% and the "not available" temperature of data format 5
+	const body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":-163.84,"Humidity":163,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+	tests := []struct {
+		name     string
+		policy   internal.RangePolicy
+		status   int
+		message  apiresponse.ApiResponseMessage
+		produced bool
+	}{
+		{
+			name:    "rejected",
+			policy:  internal.RejectOutOfRange,
+			status:  http.StatusBadRequest,
+			message: apiresponse.OutOfRange,
+		},
+		{
+			name:     "flagged",
+			policy:   internal.FlagOutOfRange,
+			status:   http.StatusOK,
+			message:  apiresponse.Ok,
+			produced: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := events.NewRangeValidator(events.RangeLimits{})
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			messageChan := make(chan kafkawrapper.Message, 1)
+			handler := internal.CreateIncomingEventHandler(messageChan, internal.WithRangeValidation(validator, tt.policy))
+
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(body)))
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			var response apiresponse.ApiResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if response.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, response.Message)
+			}
+			if len(response.Errors) != 1 || response.Errors[0].Path != "data.Humidity" {
+				t.Errorf("expected an error for data.Humidity, got %+v", response.Errors)
+			}
+
+			if !tt.produced {
+				if len(messageChan) != 0 {
+					t.Error("expected the event not to be produced")
+				}
+				return
+			}
+
+			var event struct {
+				Data struct {
+					Temperature *float64
+				}
+				RangeViolations []events.RangeViolation `json:"range_violations"`
+			}
+			if err := json.Unmarshal((<-messageChan).Value, &event); err != nil {
+				t.Fatalf("failed to unmarshal message: %v", err)
+			}
+			if event.Data.Temperature != nil {
+				t.Errorf("expected the unavailable temperature to be null, got %v", *event.Data.Temperature)
+			}
+			if len(event.RangeViolations) != 1 || event.RangeViolations[0].Field != "Humidity" || event.RangeViolations[0].Value != 163 {
+				t.Errorf("expected Humidity to be flagged, got %+v", event.RangeViolations)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+)
+
+// RateLimit is the rate of a token bucket in events per second and the
+// number of events it allows in a burst.
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+func (l *RateLimit) validate() error {
+	if l.Rate <= 0 || l.Burst < 1 {
+		return fmt.Errorf("rate must be positive and burst at least 1, got %v and %d", l.Rate, l.Burst)
+	}
+	return nil
+}
+
+// RateLimits configures the rate limits of the ingress endpoints. Events are
+// limited per source, using the limit of the source or the default. Limits
+// per remote IP address and per tag MAC are applied in addition when set.
+type RateLimits struct {
+	Default  *RateLimit           `json:"default"`
+	Sources  map[string]RateLimit `json:"sources,omitempty"`
+	RemoteIP *RateLimit           `json:"remote_ip,omitempty"`
+	Tag      *RateLimit           `json:"tag,omitempty"`
+}
+
+func (l *RateLimits) validate() error {
+	if l.Default != nil {
+		if err := l.Default.validate(); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+	}
+	for source, limit := range l.Sources {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("source %s: %w", source, err)
+		}
+	}
+	if l.RemoteIP != nil {
+		if err := l.RemoteIP.validate(); err != nil {
+			return fmt.Errorf("remote_ip: %w", err)
+		}
+	}
+	if l.Tag != nil {
+		if err := l.Tag.validate(); err != nil {
+			return fmt.Errorf("tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// sourceLimit returns the limit of source, or nil if it is not limited.
+func (l *RateLimits) sourceLimit(source string) *RateLimit {
+	if limit, ok := l.Sources[source]; ok {
+		return &limit
+	}
+	return l.Default
+}
+
+// Types of rate limit keys, used in logs and as labels of the rate limit
+// metric.
+const (
+	limitSource   = "source"
+	limitRemoteIP = "remote_ip"
+	limitTag      = "tag"
+)
+
+// bucketIdleTimeout is how long a full bucket is kept after its last use.
+// A new bucket starts full, so forgetting it does not change the outcome.
+const bucketIdleTimeout = 10 * time.Minute
+
+type bucketKey struct {
+	kind  string
+	value string
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+	limited bool
+}
+
+// refill adds the tokens earned since the last update.
+func (b *bucket) refill(now time.Time, limit RateLimit) {
+	if b.limit != limit {
+		// The limit has been reconfigured
+		b.limit = limit
+		b.tokens = math.Min(b.tokens, float64(limit.Burst))
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+}
+
+// RateLimiter limits the rate of events with token buckets. Its limits are
+// read from a JSON file containing a RateLimits object, which is reloaded
+// when it changes.
+type RateLimiter struct {
+	file reloadableFile
+
+	mu      sync.Mutex
+	limits  RateLimits
+	buckets map[bucketKey]*bucket
+	cleaned time.Time
+}
+
+// NewRateLimiter returns a rate limiter applying limits.
+func NewRateLimiter(limits RateLimits) (*RateLimiter, error) {
+	if err := limits.validate(); err != nil {
+		return nil, err
+	}
+	return &RateLimiter{limits: limits, buckets: make(map[bucketKey]*bucket)}, nil
+}
+
+// LoadRateLimits returns a rate limiter applying the limits in the file at
+// path.
+func LoadRateLimits(path string) (*RateLimiter, error) {
+	limiter := &RateLimiter{buckets: make(map[bucketKey]*bucket)}
+	limiter.file = reloadableFile{path: path, parse: limiter.parse}
+
+	if _, err := limiter.Reload(); err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// Reload reads the rate limit file again and reports whether it had changed.
+// The state of the buckets is kept.
+func (l *RateLimiter) Reload() (bool, error) {
+	return l.file.reload()
+}
+
+// Watch reloads the rate limit file every interval until stop is closed.
+func (l *RateLimiter) Watch(interval time.Duration, stop <-chan struct{}) {
+	l.file.watch(interval, stop)
+}
+
+func (l *RateLimiter) parse(content []byte) error {
+	var limits RateLimits
+	if err := json.Unmarshal(content, &limits); err != nil {
+		return fmt.Errorf("invalid rate limit file %s: %w", l.file.path, err)
+	}
+	if err := limits.validate(); err != nil {
+		return fmt.Errorf("invalid rate limit file %s: %w", l.file.path, err)
+	}
+
+	l.mu.Lock()
+	l.limits = limits
+	l.mu.Unlock()
+	return nil
+}
+
+// allow takes a token for an event of source from the bucket of the source,
+// and of the remote IP address and tag MAC when they are limited and known.
+// The token is taken only if every bucket has one; otherwise allow returns
+// how long to wait until the event would be allowed.
+func (l *RateLimiter) allow(source, remoteIP, tag string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.cleanup(now)
+
+	type limitedBucket struct {
+		key    bucketKey
+		bucket *bucket
+	}
+	var buckets []limitedBucket
+
+	add := func(kind, value string, limit *RateLimit) {
+		if limit == nil || value == "" {
+			return
+		}
+
+		key := bucketKey{kind: kind, value: value}
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(limit.Burst), updated: now, limit: *limit}
+			l.buckets[key] = b
+		}
+		b.refill(now, *limit)
+		buckets = append(buckets, limitedBucket{key: key, bucket: b})
+	}
+
+	add(limitSource, source, l.limits.sourceLimit(source))
+	add(limitRemoteIP, remoteIP, l.limits.RemoteIP)
+	add(limitTag, tag, l.limits.Tag)
+
+	var retryAfter time.Duration
+	for _, b := range buckets {
+		if b.bucket.tokens >= 1 {
+			continue
+		}
+
+		wait := time.Duration((1 - b.bucket.tokens) / b.bucket.limit.Rate * float64(time.Second))
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+
+		metrics.RateLimited.WithLabelValues(b.key.kind).Inc()
+		if !b.bucket.limited {
+			// Log only when a key starts to be limited to avoid flooding
+			log.Printf("Rate limiting %s %s to %v events per second\n", b.key.kind, b.key.value, b.bucket.limit.Rate)
+			b.bucket.limited = true
+		}
+	}
+
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+
+	for _, b := range buckets {
+		b.bucket.tokens--
+		b.bucket.limited = false
+	}
+	return true, 0
+}
+
+// cleanup forgets the buckets that have been idle long enough to be full.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.cleaned) < time.Minute {
+		return
+	}
+	l.cleaned = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > bucketIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+type remoteIPKey struct{}
+
+// withRemoteIP adds the IP address of the client to the context. Requests
+// from trusted proxies are attributed to the address they were forwarded for.
+func (in *ingress) withRemoteIP(ctx context.Context, r *http.Request) context.Context {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if in.trustedProxy(host) {
+		host = in.forwardedFor(r, host)
+	}
+	return context.WithValue(ctx, remoteIPKey{}, host)
+}
+
+// forwardedFor returns the client address from the X-Forwarded-For header.
+// The header is read from the right, as every proxy appends the address it
+// received the request from, and the first address that is not a trusted
+// proxy is the client. Anything left of it may have been set by the client.
+func (in *ingress) forwardedFor(r *http.Request, proxy string) string {
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	client := proxy
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(ip) == nil {
+			break
+		}
+		client = ip
+		if !in.trustedProxy(ip) {
+			break
+		}
+	}
+	return client
+}
+
+func (in *ingress) trustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range in.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses a comma separated list of CIDRs and IP addresses
+// of the proxies that are trusted to set X-Forwarded-For.
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func remoteIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(remoteIPKey{}).(string)
+	return ip
+}
+
+// rateLimit takes a token for an event from the buckets of the given keys,
+// leaving out those that are empty. It returns the rejected result if any of
+// them is over its limit, or nil if the event is allowed.
+func (in *ingress) rateLimit(source, remoteIP, tag string) *eventResult {
+	if in.limiter == nil {
+		return nil
+	}
+
+	ok, retryAfter := in.limiter.allow(source, remoteIP, tag)
+	if ok {
+		return nil
+	}
+
+	result := rejected(http.StatusTooManyRequests, apiresponse.RateLimited, apiresponse.CodeRateLimited,
+		fmt.Sprintf("too many events, retry after %s", retryAfter.Round(time.Second)))
+	result.retryAfter = retryAfter
+	return result
+}
+
+// setRetryAfter sets the Retry-After header to the longest wait among the
+// results, if any of them has been rate limited.
+func setRetryAfter(w http.ResponseWriter, results ...*eventResult) {
+	var retryAfter time.Duration
+	for _, result := range results {
+		if result.retryAfter > retryAfter {
+			retryAfter = result.retryAfter
+		}
+	}
+	writeRetryAfter(w, retryAfter)
+}
+
+// writeRetryAfter sets the Retry-After header to retryAfter, rounded up to
+// whole seconds, unless it is zero.
+func writeRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+}
@@ -0,0 +1,287 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+const rateLimitedBody = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+
+func withSource(body, source string) string {
+	return strings.Replace(body, "7d01818b-0332-4adf-99c1-13f833e59c6b", source, 1)
+}
+
+func withMAC(body, mac string) string {
+	return strings.ReplaceAll(body, "E8:D3:AD:C4:6E:18", mac)
+}
+
+func TestRateLimiter(t *testing.T) {
+	// Tokens are refilled so slowly that they do not affect the tests
+	slow := &internal.RateLimit{Rate: 0.001, Burst: 2}
+
+	type request struct {
+		body         string
+		remoteAddr   string
+		forwardedFor string
+		status       int
+	}
+
+	tests := []struct {
+		name     string
+		limits   internal.RateLimits
+		proxies  string
+		requests []request
+	}{
+		{
+			name:   "default limit per source",
+			limits: internal.RateLimits{Default: slow},
+			requests: []request{
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusTooManyRequests},
+				{body: withSource(rateLimitedBody, "other"), status: http.StatusOK},
+			},
+		},
+		{
+			name: "source limit overrides default",
+			limits: internal.RateLimits{
+				Default: slow,
+				Sources: map[string]internal.RateLimit{"7d01818b-0332-4adf-99c1-13f833e59c6b": {Rate: 0.001, Burst: 1}},
+			},
+			requests: []request{
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusTooManyRequests},
+				{body: withSource(rateLimitedBody, "other"), status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "other"), status: http.StatusOK},
+			},
+		},
+		{
+			name:   "no default",
+			limits: internal.RateLimits{},
+			requests: []request{
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusOK},
+			},
+		},
+		{
+			name:   "remote IP limit",
+			limits: internal.RateLimits{RemoteIP: slow},
+			requests: []request{
+				{body: rateLimitedBody, remoteAddr: "192.0.2.1:1234", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "other"), remoteAddr: "192.0.2.1:1235", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "third"), remoteAddr: "192.0.2.1:1236", status: http.StatusTooManyRequests},
+				{body: rateLimitedBody, remoteAddr: "192.0.2.2:1234", status: http.StatusOK},
+			},
+		},
+		{
+			name:    "remote IP limit behind trusted proxy",
+			limits:  internal.RateLimits{RemoteIP: slow},
+			proxies: "10.0.0.0/8, 192.0.2.9",
+			requests: []request{
+				{body: rateLimitedBody, remoteAddr: "10.1.2.3:1234", forwardedFor: "198.51.100.1", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "other"), remoteAddr: "10.1.2.4:1234", forwardedFor: "198.51.100.1", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "third"), remoteAddr: "10.1.2.3:1235", forwardedFor: "198.51.100.2", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "fourth"), remoteAddr: "192.0.2.9:1234", forwardedFor: "198.51.100.1, 10.3.3.3", status: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:    "spoofed X-Forwarded-For is ignored",
+			limits:  internal.RateLimits{RemoteIP: slow},
+			proxies: "10.0.0.0/8",
+			requests: []request{
+				{body: rateLimitedBody, remoteAddr: "198.51.100.1:1234", forwardedFor: "203.0.113.1", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "other"), remoteAddr: "198.51.100.1:1235", forwardedFor: "203.0.113.2", status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "third"), remoteAddr: "10.1.2.3:1234", forwardedFor: "203.0.113.1, 198.51.100.1", status: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:   "tag limit",
+			limits: internal.RateLimits{Tag: &internal.RateLimit{Rate: 0.001, Burst: 1}},
+			requests: []request{
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: withSource(rateLimitedBody, "other"), status: http.StatusTooManyRequests},
+				{body: withMAC(rateLimitedBody, "AA:BB:CC:DD:EE:FF"), status: http.StatusOK},
+			},
+		},
+		{
+			name:   "malformed events count against the source",
+			limits: internal.RateLimits{Default: &internal.RateLimit{Rate: 0.001, Burst: 1}},
+			requests: []request{
+				{body: strings.Replace(rateLimitedBody, `"Temperature":22.34`, `"Temperature":"warm"`, 1), status: http.StatusBadRequest},
+				{body: strings.Replace(rateLimitedBody, `"Temperature":22.34`, `"Temperature":"warm"`, 1), status: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name:   "rejected event takes no tokens",
+			limits: internal.RateLimits{Default: &internal.RateLimit{Rate: 0.001, Burst: 1}, Tag: slow},
+			requests: []request{
+				{body: rateLimitedBody, status: http.StatusOK},
+				{body: rateLimitedBody, status: http.StatusTooManyRequests},
+				{body: withSource(rateLimitedBody, "other"), status: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := internal.NewRateLimiter(tt.limits)
+			if err != nil {
+				t.Fatalf("failed to create rate limiter: %v", err)
+			}
+
+			proxies, err := internal.ParseTrustedProxies(tt.proxies)
+			if err != nil {
+				t.Fatalf("failed to parse trusted proxies: %v", err)
+			}
+
+			handler := internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, len(tt.requests)), internal.WithRateLimiter(limiter), internal.WithTrustedProxies(proxies))
+
+			for i, request := range tt.requests {
+				req := httptest.NewRequest("POST", "/event", strings.NewReader(request.body))
+				if request.remoteAddr != "" {
+					req.RemoteAddr = request.remoteAddr
+				}
+				if request.forwardedFor != "" {
+					req.Header.Set("X-Forwarded-For", request.forwardedFor)
+				}
+				rr := httptest.NewRecorder()
+				handler(rr, req)
+
+				if rr.Code != request.status {
+					t.Fatalf("request %d: expected status %d, got %d", i, request.status, rr.Code)
+				}
+
+				retryAfter := rr.Header().Get("Retry-After")
+				if request.status != http.StatusTooManyRequests {
+					if retryAfter != "" {
+						t.Errorf("request %d: expected no Retry-After, got %s", i, retryAfter)
+					}
+					continue
+				}
+
+				if seconds, err := strconv.Atoi(retryAfter); err != nil || seconds < 1 {
+					t.Errorf("request %d: expected Retry-After in seconds, got %q", i, retryAfter)
+				}
+
+				var response apiresponse.ApiResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatalf("request %d: failed to decode response: %v", i, err)
+				}
+				if response.Message != apiresponse.RateLimited {
+					t.Errorf("request %d: expected message %q, got %q", i, apiresponse.RateLimited, response.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterBatch(t *testing.T) {
+	limiter, err := internal.NewRateLimiter(internal.RateLimits{Default: &internal.RateLimit{Rate: 0.5, Burst: 1}})
+	if err != nil {
+		t.Fatalf("failed to create rate limiter: %v", err)
+	}
+
+	handler := internal.CreateIncomingBatchHandler(make(chan kafkawrapper.Message, 2), internal.WithRateLimiter(limiter))
+
+	req := httptest.NewRequest("POST", "/events", strings.NewReader("["+rateLimitedBody+","+rateLimitedBody+"]"))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+
+	var response apiresponse.BatchApiResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Results[0].Status != http.StatusOK || response.Results[1].Status != http.StatusTooManyRequests {
+		t.Errorf("expected the second event to be rate limited, got %+v", response.Results)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", proxies: "", want: nil},
+		{name: "networks", proxies: "10.0.0.0/8, fd00::/8", want: []string{"10.0.0.0/8", "fd00::/8"}},
+		{name: "addresses", proxies: "192.0.2.1,2001:db8::1", want: []string{"192.0.2.1/32", "2001:db8::1/128"}},
+		{name: "invalid address", proxies: "10.0.0.0/8,proxy", wantErr: true},
+		{name: "invalid network", proxies: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, err := internal.ParseTrustedProxies(tt.proxies)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			var got []string
+			for _, proxy := range proxies {
+				got = append(got, proxy.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate-limits.json")
+	writeKeyFile(t, path, `{"default": {"rate": 0.001, "burst": 1}}`)
+
+	limiter, err := internal.LoadRateLimits(path)
+	if err != nil {
+		t.Fatalf("failed to load rate limits: %v", err)
+	}
+
+	handler := internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, 3), internal.WithRateLimiter(limiter))
+	send := func() int {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("POST", "/event", strings.NewReader(rateLimitedBody)))
+		return rr.Code
+	}
+
+	if got := send(); got != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", got)
+	}
+	if got := send(); got != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %d", got)
+	}
+
+	// Invalid limits are rejected and the previous ones are kept
+	writeKeyFile(t, path, `{"default": {"rate": 0, "burst": 1}}`)
+	if _, err := limiter.Reload(); err == nil {
+		t.Error("expected an error for a zero rate")
+	}
+
+	// A faster rate refills the existing bucket
+	writeKeyFile(t, path, `{"default": {"rate": 1000, "burst": 1}}`)
+	if changed, err := limiter.Reload(); err != nil || !changed {
+		t.Fatalf("expected the limits to be reloaded, got %v, %v", changed, err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := send(); got != http.StatusOK {
+		t.Errorf("expected status 200 after reload, got %d", got)
+	}
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadableFile is a configuration file that is parsed again whenever its
+// content changes.
+type reloadableFile struct {
+	path string
+	// parse is called with the new content of the file. If it fails, the
+	// previously parsed content stays in use.
+	parse func(content []byte) error
+
+	mu      sync.Mutex
+	content []byte
+	loaded  bool
+}
+
+// reload reads the file again and reports whether it had changed.
+func (f *reloadableFile) reload() (bool, error) {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.loaded && bytes.Equal(content, f.content) {
+		return false, nil
+	}
+
+	if err := f.parse(content); err != nil {
+		return false, err
+	}
+
+	f.content = content
+	f.loaded = true
+	return true, nil
+}
+
+// watch reloads the file every interval until stop is closed.
+func (f *reloadableFile) watch(interval time.Duration, stop <-chan struct{}) {
+	watchReload(f.path, interval, stop, f.reload)
+}
+
+// watchReload calls reload every interval until stop is closed, logging the
+// outcome when something has changed. Kubernetes updates mounted secrets by
+// swapping a symlink, which is picked up as well since files are read again.
+func watchReload(name string, interval time.Duration, stop <-chan struct{}, reload func() (bool, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := reload()
+			if err != nil {
+				log.Printf("Failed to reload %s: %v\n", name, err)
+			} else if changed {
+				log.Printf("Reloaded %s\n", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+)
+
+// Headers of a signed request. The signature is the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the raw request body, computed with
+// the secret of the signing key, and prefixed with "sha256=".
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureKeyIDHeader     = "X-Signature-Key-Id"
+)
+
+// MaxSignedBodyBytes is the largest body accepted in a signed request. The
+// body has to be read completely before its signature can be verified.
+const MaxSignedBodyBytes = 16 << 20
+
+var (
+	errMissingSignature = errors.New("missing signature headers")
+	errUnknownKeyID     = errors.New("unknown signing key")
+	errBadTimestamp     = errors.New("invalid timestamp")
+	errExpired          = errors.New("timestamp outside replay window")
+	errBadSignature     = errors.New("signature does not match")
+	errReplayed         = errors.New("signature has already been used")
+	errBodyTooLarge     = errors.New("body is too large")
+)
+
+// SigningKeyEntry is a signing key in the signing key file.
+type SigningKeyEntry struct {
+	// ID is sent in the X-Signature-Key-Id header, e.g. the source UUID
+	// or the MAC address of the gateway.
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+	// Sources are the sources the key may sign events for, or "*" for any
+	// source. Defaults to the ID of the key.
+	Sources []string `json:"sources,omitempty"`
+}
+
+type signingKey struct {
+	secret   []byte
+	identity *identity
+}
+
+// SigningKeyStore holds the shared secrets of the signing keys read from a
+// JSON file containing a list of SigningKeyEntry objects.
+type SigningKeyStore struct {
+	file reloadableFile
+
+	mu   sync.RWMutex
+	keys map[string]*signingKey
+}
+
+// LoadSigningKeys reads the signing keys from the file at path.
+func LoadSigningKeys(path string) (*SigningKeyStore, error) {
+	store := &SigningKeyStore{}
+	store.file = reloadableFile{path: path, parse: store.parse}
+
+	if _, err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload reads the signing key file again and reports whether it had
+// changed. If the file cannot be read or parsed, the previously loaded keys
+// are kept.
+func (s *SigningKeyStore) Reload() (bool, error) {
+	return s.file.reload()
+}
+
+// Watch reloads the signing key file every interval until stop is closed.
+func (s *SigningKeyStore) Watch(interval time.Duration, stop <-chan struct{}) {
+	s.file.watch(interval, stop)
+}
+
+func (s *SigningKeyStore) parse(content []byte) error {
+	var entries []SigningKeyEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return fmt.Errorf("invalid signing key file %s: %w", s.file.path, err)
+	}
+
+	keys := make(map[string]*signingKey, len(entries))
+	for i, entry := range entries {
+		if entry.ID == "" || entry.Secret == "" {
+			return fmt.Errorf("invalid signing key file %s: key %d must have an id and a secret", s.file.path, i)
+		}
+		if _, ok := keys[entry.ID]; ok {
+			return fmt.Errorf("invalid signing key file %s: key %s is a duplicate", s.file.path, entry.ID)
+		}
+
+		sources := entry.Sources
+		if len(sources) == 0 {
+			sources = []string{entry.ID}
+		}
+		keys[entry.ID] = &signingKey{secret: []byte(entry.Secret), identity: newIdentity(entry.ID, sources)}
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *SigningKeyStore) lookup(id string) (*signingKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// Sign returns the value of the X-Signature header for body signed with
+// secret at the given timestamp.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// replayCache remembers the signatures seen within the replay window, so
+// that a captured request cannot be sent again while its timestamp is still
+// accepted.
+type replayCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	cleaned time.Time
+}
+
+// add records a signature and reports whether it was new.
+func (c *replayCache) add(signature string, expires time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.cleaned) > time.Minute {
+		for s, e := range c.seen {
+			if now.After(e) {
+				delete(c.seen, s)
+			}
+		}
+		c.cleaned = now
+	}
+
+	if _, ok := c.seen[signature]; ok {
+		return false
+	}
+	c.seen[signature] = expires
+	return true
+}
+
+// RequireSignature returns a handler that only passes requests with a valid
+// HMAC signature on to next. The timestamp of the signature must be within
+// replayWindow of the current time, and a signature is accepted only once.
+// The body is read and verified before it reaches next, which receives it
+// unchanged together with the sources the signing key may sign events for.
+func RequireSignature(store *SigningKeyStore, replayWindow time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	replays := &replayCache{seen: make(map[string]time.Time)}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, body, err := verifySignature(store, replays, replayWindow, r)
+		if err != nil {
+			log.Printf("Rejected request with invalid signature: %s %s from %s: %v\n", r.Method, r.URL.Path, r.RemoteAddr, err)
+
+			writeError(w, http.StatusUnauthorized, apiresponse.ApiResponse{
+				Message: apiresponse.InvalidSignature,
+				Code:    apiresponse.CodeInvalidSignature,
+				Detail:  err.Error(),
+			})
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r.WithContext(withIdentity(r.Context(), key.identity)))
+	}
+}
+
+// verifySignature checks the signature headers of r and returns the signing
+// key and the body of the request.
+func verifySignature(store *SigningKeyStore, replays *replayCache, replayWindow time.Duration, r *http.Request) (*signingKey, []byte, error) {
+	signature := r.Header.Get(SignatureHeader)
+	timestampHeader := r.Header.Get(SignatureTimestampHeader)
+	keyID := r.Header.Get(SignatureKeyIDHeader)
+	if signature == "" || timestampHeader == "" || keyID == "" {
+		return nil, nil, errMissingSignature
+	}
+
+	key, ok := store.lookup(keyID)
+	if !ok {
+		return nil, nil, errUnknownKeyID
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return nil, nil, errBadTimestamp
+	}
+
+	signedAt := time.Unix(timestamp, 0)
+	if age := time.Since(signedAt); age > replayWindow || age < -replayWindow {
+		return nil, nil, errExpired
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxSignedBodyBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) > MaxSignedBodyBytes {
+		return nil, nil, errBodyTooLarge
+	}
+
+	expected := Sign(key.secret, timestamp, body)
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return nil, nil, errBadSignature
+	}
+
+	if !replays.add(keyID+" "+expected, signedAt.Add(replayWindow)) {
+		return nil, nil, errReplayed
+	}
+
+	return key, body, nil
+}
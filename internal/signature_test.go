@@ -0,0 +1,175 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+func TestRequireSignature(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing-keys.json")
+	writeKeyFile(t, path, `[
+		{"id": "7d01818b-0332-4adf-99c1-13f833e59c6b", "secret": "secret-1"},
+		{"id": "backfill", "secret": "secret-2", "sources": ["*"]}
+	]`)
+
+	store, err := internal.LoadSigningKeys(path)
+	if err != nil {
+		t.Fatalf("failed to load signing keys: %v", err)
+	}
+
+	const body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+	otherSource := strings.Replace(body, "7d01818b-0332-4adf-99c1-13f833e59c6b", "11111111-2222-3333-4444-555555555555", 1)
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name            string
+		keyID           string
+		secret          string
+		timestamp       int64
+		body            string
+		signedBody      string
+		expectedStatus  int
+		expectedMessage apiresponse.ApiResponseMessage
+	}{
+		{
+			name:            "valid signature",
+			keyID:           "7d01818b-0332-4adf-99c1-13f833e59c6b",
+			secret:          "secret-1",
+			timestamp:       now,
+			body:            body,
+			expectedStatus:  http.StatusOK,
+			expectedMessage: apiresponse.Ok,
+		},
+		{
+			name:            "missing signature",
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.InvalidSignature,
+		},
+		{
+			name:            "unknown key",
+			keyID:           "unknown",
+			secret:          "secret-1",
+			timestamp:       now,
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.InvalidSignature,
+		},
+		{
+			name:            "wrong secret",
+			keyID:           "7d01818b-0332-4adf-99c1-13f833e59c6b",
+			secret:          "secret-2",
+			timestamp:       now + 1,
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.InvalidSignature,
+		},
+		{
+			name:            "tampered body",
+			keyID:           "7d01818b-0332-4adf-99c1-13f833e59c6b",
+			secret:          "secret-1",
+			timestamp:       now + 2,
+			body:            strings.Replace(body, "22.34", "99.99", 1),
+			signedBody:      body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.InvalidSignature,
+		},
+		{
+			name:            "timestamp outside replay window",
+			keyID:           "7d01818b-0332-4adf-99c1-13f833e59c6b",
+			secret:          "secret-1",
+			timestamp:       now - 600,
+			body:            body,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: apiresponse.InvalidSignature,
+		},
+		{
+			name:            "source not bound to key",
+			keyID:           "7d01818b-0332-4adf-99c1-13f833e59c6b",
+			secret:          "secret-1",
+			timestamp:       now,
+			body:            otherSource,
+			expectedStatus:  http.StatusForbidden,
+			expectedMessage: apiresponse.SourceNotAllowed,
+		},
+		{
+			name:            "key allowed for all sources",
+			keyID:           "backfill",
+			secret:          "secret-2",
+			timestamp:       now,
+			body:            otherSource,
+			expectedStatus:  http.StatusOK,
+			expectedMessage: apiresponse.Ok,
+		},
+	}
+
+	handler := internal.RequireSignature(store, 5*time.Minute, internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, len(tests))))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/event", strings.NewReader(tt.body))
+			if tt.keyID != "" {
+				signedBody := tt.signedBody
+				if signedBody == "" {
+					signedBody = tt.body
+				}
+				req.Header.Set(internal.SignatureKeyIDHeader, tt.keyID)
+				req.Header.Set(internal.SignatureTimestampHeader, strconv.FormatInt(tt.timestamp, 10))
+				req.Header.Set(internal.SignatureHeader, internal.Sign([]byte(tt.secret), tt.timestamp, []byte(signedBody)))
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+
+			var response apiresponse.ApiResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Message != tt.expectedMessage {
+				t.Errorf("handler returned wrong message: got %v want %v", response.Message, tt.expectedMessage)
+			}
+		})
+	}
+}
+
+func TestRequireSignatureRejectsReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing-keys.json")
+	writeKeyFile(t, path, `[{"id": "gateway", "secret": "secret-1"}]`)
+
+	store, err := internal.LoadSigningKeys(path)
+	if err != nil {
+		t.Fatalf("failed to load signing keys: %v", err)
+	}
+
+	handler := internal.RequireSignature(store, time.Minute, func(w http.ResponseWriter, r *http.Request) {})
+
+	body := []byte(`{}`)
+	timestamp := time.Now().Unix()
+	signature := internal.Sign([]byte("secret-1"), timestamp, body)
+
+	for i, expectedStatus := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req := httptest.NewRequest("POST", "/event", strings.NewReader(string(body)))
+		req.Header.Set(internal.SignatureKeyIDHeader, "gateway")
+		req.Header.Set(internal.SignatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(internal.SignatureHeader, signature)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		if rr.Code != expectedStatus {
+			t.Errorf("request %d: got status %v want %v", i, rr.Code, expectedStatus)
+		}
+	}
+}
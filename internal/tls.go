@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/apiresponse"
+)
+
+// CertificateReloader serves the TLS certificate and client CAs read from
+// files, and reloads them when the files change, e.g. when cert-manager
+// renews the certificate.
+type CertificateReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	contents  [][]byte
+}
+
+// LoadCertificates reads the certificate and key of the server. If
+// clientCAFile is not empty, clients may authenticate with certificates
+// issued by the CAs in that file.
+func LoadCertificates(certFile, keyFile, clientCAFile string) (*CertificateReloader, error) {
+	c := &CertificateReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again and reports whether they had changed. If the
+// files cannot be read or parsed, the previous certificates stay in use.
+func (c *CertificateReloader) Reload() (bool, error) {
+	files := []string{c.certFile, c.keyFile}
+	if c.clientCAFile != "" {
+		files = append(files, c.clientCAFile)
+	}
+
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		contents[i] = content
+	}
+
+	c.mu.RLock()
+	unchanged := c.cert != nil && equalContents(contents, c.contents)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(contents[0], contents[1])
+	if err != nil {
+		return false, fmt.Errorf("invalid certificate %s: %w", c.certFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if c.clientCAFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(contents[2]) {
+			return false, fmt.Errorf("no certificates in client CA file %s", c.clientCAFile)
+		}
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	c.contents = contents
+	c.mu.Unlock()
+
+	return true, nil
+}
+
+func equalContents(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Watch reloads the certificates every interval until stop is closed.
+func (c *CertificateReloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	watchReload(c.certFile, interval, stop, c.Reload)
+}
+
+// TLSConfig returns the configuration of the TLS listener. New connections
+// always use the latest certificates. Client certificates are verified if
+// they are presented, but not required, so that probes and metrics scrapes
+// without a certificate still work; RequireClientCertificate enforces them
+// on the ingress endpoints.
+func (c *CertificateReloader) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			return c.cert, nil
+		},
+	}
+
+	if c.clientCAFile != "" {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+
+			clientConfig := config.Clone()
+			clientConfig.GetConfigForClient = nil
+			clientConfig.ClientCAs = c.clientCAs
+			return clientConfig, nil
+		}
+	}
+
+	return config
+}
+
+// ClientCertificateEntry binds a client certificate to sources in the client
+// certificate file.
+type ClientCertificateEntry struct {
+	// Name is matched against the subject common name and the subject
+	// alternative names of the certificate.
+	Name string `json:"name"`
+	// Sources are the sources the certificate may send events for, or "*"
+	// for any source.
+	Sources []string `json:"sources"`
+}
+
+// ClientCertificateSources maps client certificates to the sources they may
+// send events for, read from a JSON file containing a list of
+// ClientCertificateEntry objects.
+type ClientCertificateSources struct {
+	file reloadableFile
+
+	mu         sync.RWMutex
+	identities map[string]*identity
+}
+
+// LoadClientCertificateSources reads the client certificate file at path.
+func LoadClientCertificateSources(path string) (*ClientCertificateSources, error) {
+	s := &ClientCertificateSources{}
+	s.file = reloadableFile{path: path, parse: s.parse}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the client certificate file again and reports whether it had
+// changed.
+func (s *ClientCertificateSources) Reload() (bool, error) {
+	return s.file.reload()
+}
+
+// Watch reloads the client certificate file every interval until stop is
+// closed.
+func (s *ClientCertificateSources) Watch(interval time.Duration, stop <-chan struct{}) {
+	s.file.watch(interval, stop)
+}
+
+func (s *ClientCertificateSources) parse(content []byte) error {
+	var entries []ClientCertificateEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return fmt.Errorf("invalid client certificate file %s: %w", s.file.path, err)
+	}
+
+	identities := make(map[string]*identity, len(entries))
+	for i, entry := range entries {
+		if entry.Name == "" || len(entry.Sources) == 0 {
+			return fmt.Errorf("invalid client certificate file %s: entry %d must have a name and sources", s.file.path, i)
+		}
+		identities[entry.Name] = newIdentity(entry.Name, entry.Sources)
+	}
+
+	s.mu.Lock()
+	s.identities = identities
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *ClientCertificateSources) lookup(names []string) (*identity, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, name := range names {
+		if id, ok := s.identities[name]; ok {
+			return id, true
+		}
+	}
+	return nil, false
+}
+
+// certificateNames returns the subject common name and the subject
+// alternative names of cert. URN UUIDs are returned as plain UUIDs, so that
+// device certificates can carry their source UUID as a URI.
+func certificateNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		if strings.EqualFold(uri.Scheme, "urn") && strings.HasPrefix(strings.ToLower(uri.Opaque), "uuid:") {
+			names = append(names, uri.Opaque[len("uuid:"):])
+		} else {
+			names = append(names, uri.String())
+		}
+	}
+	return names
+}
+
+var errNoClientCertificate = errors.New("no verified client certificate")
+
+// clientIdentity returns the identity of the verified client certificate of
+// r. Without a sources file the certificate may send events for the sources
+// named in it.
+func clientIdentity(sources *ClientCertificateSources, r *http.Request) (*identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, errNoClientCertificate
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	names := certificateNames(cert)
+
+	if sources == nil {
+		return newIdentity(cert.Subject.String(), names), nil
+	}
+
+	id, ok := sources.lookup(names)
+	if !ok {
+		return nil, fmt.Errorf("certificate %s is not bound to any source", cert.Subject)
+	}
+	return id, nil
+}
+
+// RequireClientCertificate returns a handler that only passes requests with a
+// verified client certificate on to next. The sources the certificate may
+// send events for are stored in the request context. sources may be nil.
+func RequireClientCertificate(sources *ClientCertificateSources, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := clientIdentity(sources, r)
+		if err != nil {
+			log.Printf("Rejected request without a valid client certificate: %s %s from %s: %v\n", r.Method, r.URL.Path, r.RemoteAddr, err)
+
+			writeError(w, http.StatusUnauthorized, apiresponse.ApiResponse{
+				Message: apiresponse.Unauthorized,
+				Code:    apiresponse.CodeUnauthorized,
+				Detail:  "a verified client certificate bound to a source is required",
+			})
+			return
+		}
+
+		next(w, r.WithContext(withIdentity(r.Context(), id)))
+	}
+}
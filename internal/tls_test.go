@@ -0,0 +1,227 @@
+package internal_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/internal"
+	"github.com/Tuhis/edge-receiver/pkg/kafkawrapper"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// issueCertificate creates a certificate signed by issuer, or a self-signed
+// CA if issuer is nil.
+func issueCertificate(t *testing.T, template *x509.Certificate, issuer *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	return &testCertificate{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCertificate) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestRequireClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "test CA"}}, nil)
+	server := issueCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "edge-receiver"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	writeKeyFile(t, certFile, string(server.pem))
+	writeKeyFile(t, keyFile, string(server.keyPEM(t)))
+	writeKeyFile(t, caFile, string(ca.pem))
+
+	certificates, err := internal.LoadCertificates(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("failed to load certificates: %v", err)
+	}
+
+	sourcesFile := filepath.Join(dir, "sources.json")
+	writeKeyFile(t, sourcesFile, `[{"name": "gateway.example.com", "sources": ["11111111-2222-3333-4444-555555555555"]}]`)
+	sources, err := internal.LoadClientCertificateSources(sourcesFile)
+	if err != nil {
+		t.Fatalf("failed to load client certificate sources: %v", err)
+	}
+
+	device := issueCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "livingroom"},
+		URIs:        []*url.URL{{Scheme: "urn", Opaque: "uuid:7d01818b-0332-4adf-99c1-13f833e59c6b"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	gateway := issueCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "gateway"},
+		DNSNames:    []string{"gateway.example.com"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	otherCA := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other CA"}}, nil)
+	untrusted := issueCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, otherCA)
+
+	const body = `{"type":"new_measurement","source_uuid":"7d01818b-0332-4adf-99c1-13f833e59c6b","data":{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}}`
+	otherSource := strings.Replace(body, "7d01818b-0332-4adf-99c1-13f833e59c6b", "11111111-2222-3333-4444-555555555555", 1)
+
+	tests := []struct {
+		name           string
+		sources        *internal.ClientCertificateSources
+		client         *testCertificate
+		body           string
+		expectedStatus int
+	}{
+		{name: "source in certificate", client: device, body: body, expectedStatus: http.StatusOK},
+		{name: "source not in certificate", client: device, body: otherSource, expectedStatus: http.StatusForbidden},
+		{name: "no certificate", body: body, expectedStatus: http.StatusUnauthorized},
+		// The client does not present a certificate the server does not
+		// accept
+		{name: "untrusted certificate", client: untrusted, body: body, expectedStatus: http.StatusUnauthorized},
+		{name: "certificate bound in sources file", sources: sources, client: gateway, body: otherSource, expectedStatus: http.StatusOK},
+		{name: "certificate not in sources file", sources: sources, client: device, body: body, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := internal.RequireClientCertificate(tt.sources, internal.CreateIncomingEventHandler(make(chan kafkawrapper.Message, 1)))
+
+			srv := httptest.NewUnstartedServer(handler)
+			srv.TLS = certificates.TLSConfig()
+			srv.StartTLS()
+			defer srv.Close()
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.cert)
+			clientConfig := &tls.Config{RootCAs: roots}
+			if tt.client != nil {
+				clientConfig.Certificates = []tls.Certificate{tt.client.tlsCertificate()}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig}}
+
+			resp, err := client.Post(srv.URL+"/event", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", resp.StatusCode, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestCertificateReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "test CA"}}, nil)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	first := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "first"}}, ca)
+	writeKeyFile(t, certFile, string(first.pem))
+	writeKeyFile(t, keyFile, string(first.keyPEM(t)))
+
+	certificates, err := internal.LoadCertificates(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("failed to load certificates: %v", err)
+	}
+
+	servedCommonName := func() string {
+		cert, err := certificates.TLSConfig().GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("GetCertificate() failed: %v", err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("failed to parse served certificate: %v", err)
+		}
+		return leaf.Subject.CommonName
+	}
+
+	if changed, err := certificates.Reload(); err != nil || changed {
+		t.Errorf("Reload() of unchanged files = %v, %v, want false, nil", changed, err)
+	}
+
+	// Rotate the certificate
+	second := issueCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "second"}}, ca)
+	writeKeyFile(t, certFile, string(second.pem))
+	writeKeyFile(t, keyFile, string(second.keyPEM(t)))
+
+	if changed, err := certificates.Reload(); err != nil || !changed {
+		t.Errorf("Reload() of changed files = %v, %v, want true, nil", changed, err)
+	}
+	if got := servedCommonName(); got != "second" {
+		t.Errorf("served certificate %s, want second", got)
+	}
+
+	// A key that does not match keeps the previous certificate
+	if err := os.WriteFile(keyFile, first.keyPEM(t), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if _, err := certificates.Reload(); err == nil {
+		t.Error("Reload() with mismatched key succeeded")
+	}
+	if got := servedCommonName(); got != "second" {
+		t.Errorf("served certificate %s, want second", got)
+	}
+}
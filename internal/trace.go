@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader is the HTTP header carrying the ID of a request. It is
+// echoed in every response and attached to the produced Kafka messages.
+const RequestIDHeader = "X-Request-Id"
+
+// maxTraceIDLength is the length of the longest trace ID accepted from a
+// client.
+const maxTraceIDLength = 128
+
+type traceIDKey struct{}
+
+// withTraceID returns the request context with the trace ID of the request.
+// The ID is taken from the X-Request-Id header or the trace ID of a W3C
+// traceparent header. If neither is present, or they are not valid trace IDs,
+// a random ID is generated.
+func withTraceID(r *http.Request) context.Context {
+	traceID := r.Header.Get(RequestIDHeader)
+	if !validTraceID(traceID) {
+		traceID = ""
+	}
+
+	if traceID == "" {
+		// traceparent: version-traceid-parentid-flags
+		parts := strings.Split(r.Header.Get("traceparent"), "-")
+		if len(parts) == 4 && len(parts[1]) == 32 && validTraceID(parts[1]) {
+			traceID = parts[1]
+		}
+	}
+
+	if traceID == "" {
+		traceID = newTraceID()
+	}
+
+	return context.WithValue(r.Context(), traceIDKey{}, traceID)
+}
+
+// validTraceID reports whether a trace ID supplied by a client can be passed
+// on in headers and logs: it must be at most maxTraceIDLength characters of
+// letters, digits and "-", "_", "." or ":".
+func validTraceID(traceID string) bool {
+	if len(traceID) > maxTraceIDLength {
+		return false
+	}
+	for _, c := range traceID {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// traceIDFromContext returns the trace ID stored by withTraceID.
+func traceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+func newTraceID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -3,12 +3,142 @@ package apiresponse
 type ApiResponseMessage string
 
 const (
-	Ok             ApiResponseMessage = "ok"
-	InvalidRequest ApiResponseMessage = "invalid request"
-	UnknownEvent   ApiResponseMessage = "unknown event"
-	NotFound       ApiResponseMessage = "not found"
+	Ok                    ApiResponseMessage = "ok"
+	InvalidRequest        ApiResponseMessage = "invalid request"
+	UnknownEvent          ApiResponseMessage = "unknown event"
+	UnsupportedDataFormat ApiResponseMessage = "unsupported data format"
+	NotFound              ApiResponseMessage = "not found"
+	PartiallyAccepted     ApiResponseMessage = "partially accepted"
+	StreamInterrupted     ApiResponseMessage = "stream interrupted"
+	DeliveryFailed        ApiResponseMessage = "delivery failed"
+	Unauthorized          ApiResponseMessage = "unauthorized"
+	InvalidSignature      ApiResponseMessage = "invalid signature"
+	SourceNotAllowed      ApiResponseMessage = "source not allowed"
+	RateLimited           ApiResponseMessage = "rate limited"
+	InvalidTimestamp      ApiResponseMessage = "invalid timestamp"
+	OutOfRange            ApiResponseMessage = "out of range"
+	RequestTooLarge       ApiResponseMessage = "request too large"
+	ServiceUnavailable    ApiResponseMessage = "service unavailable"
 )
 
+// ErrorCode identifies why a request or an event was rejected. Unlike the
+// message and the detail, codes are meant to be matched by clients.
+type ErrorCode string
+
+const (
+	CodeNotFound                 ErrorCode = "not_found"
+	CodeMethodNotAllowed         ErrorCode = "method_not_allowed"
+	CodeInvalidBody              ErrorCode = "invalid_body"
+	CodeBodyTooLarge             ErrorCode = "body_too_large"
+	CodeMissingSource            ErrorCode = "missing_source"
+	CodeSourceNotAllowed         ErrorCode = "source_not_allowed"
+	CodeMalformedData            ErrorCode = "malformed_data"
+	CodeUnsupportedDataFormat    ErrorCode = "unsupported_data_format"
+	CodeUndecodableAdvertisement ErrorCode = "undecodable_advertisement"
+	CodeUnknownEventType         ErrorCode = "unknown_event_type"
+	CodeMissingFields            ErrorCode = "missing_fields"
+	CodeFutureTimestamp          ErrorCode = "future_timestamp"
+	CodeOutOfRange               ErrorCode = "out_of_range"
+	CodeRateLimited              ErrorCode = "rate_limited"
+	CodeDeliveryFailed           ErrorCode = "delivery_failed"
+	CodeSpoolFull                ErrorCode = "spool_full"
+	CodeUnauthorized             ErrorCode = "unauthorized"
+	CodeInvalidSignature         ErrorCode = "invalid_signature"
+)
+
+// FieldError is a field of a request that failed validation. Path is the
+// JSON path of the field within the event, e.g. "data.Temperature".
+type FieldError struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ApiResponse is the response of the single event endpoint and of requests
+// that are rejected as a whole. Failures carry an error code, a
+// human-readable detail and the fields that failed validation.
 type ApiResponse struct {
 	Message ApiResponseMessage `json:"message"`
+	Code    ErrorCode          `json:"code,omitempty"`
+	Detail  string             `json:"detail,omitempty"`
+	Errors  []FieldError       `json:"errors,omitempty"`
+}
+
+// BatchItemResult describes the outcome of a single event in a batch request.
+type BatchItemResult struct {
+	Index   int                `json:"index"`
+	Status  int                `json:"status"`
+	Message ApiResponseMessage `json:"message"`
+	Code    ErrorCode          `json:"code,omitempty"`
+	Detail  string             `json:"detail,omitempty"`
+	Errors  []FieldError       `json:"errors,omitempty"`
+}
+
+// BatchApiResponse is returned by the batch ingestion endpoint. Results are in
+// the same order as the events in the request.
+type BatchApiResponse struct {
+	Message ApiResponseMessage `json:"message"`
+	Results []BatchItemResult  `json:"results"`
+}
+
+// StreamItemResult describes the outcome of a line of an event stream that
+// was rejected or malformed. Line numbers start from 1.
+type StreamItemResult struct {
+	Line    int                `json:"line"`
+	Status  int                `json:"status"`
+	Message ApiResponseMessage `json:"message"`
+	Code    ErrorCode          `json:"code,omitempty"`
+	Detail  string             `json:"detail,omitempty"`
+	Errors  []FieldError       `json:"errors,omitempty"`
+}
+
+// StreamApiResponse is the trailing summary returned once a newline-delimited
+// event stream has been fully consumed. Streams are unbounded, so Results
+// lists only the lines that were not accepted, up to a limit.
+type StreamApiResponse struct {
+	Message   ApiResponseMessage `json:"message"`
+	Accepted  int                `json:"accepted"`
+	Rejected  int                `json:"rejected"`
+	Malformed int                `json:"malformed"`
+	Results   []StreamItemResult `json:"results,omitempty"`
+}
+
+// GatewayTagResult describes the outcome of a single tag of a Ruuvi Gateway
+// payload.
+type GatewayTagResult struct {
+	MAC     string             `json:"mac"`
+	Status  int                `json:"status"`
+	Message ApiResponseMessage `json:"message"`
+	Code    ErrorCode          `json:"code,omitempty"`
+	Detail  string             `json:"detail,omitempty"`
+	Errors  []FieldError       `json:"errors,omitempty"`
+}
+
+// GatewayApiResponse summarizes how the tags of a Ruuvi Gateway payload were
+// handled. Results are ordered by the MAC of the tag.
+type GatewayApiResponse struct {
+	Message  ApiResponseMessage `json:"message"`
+	Accepted int                `json:"accepted"`
+	Rejected int                `json:"rejected"`
+	Results  []GatewayTagResult `json:"results"`
+}
+
+type HealthStatus string
+
+const (
+	HealthOk       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded"
+	HealthFailing  HealthStatus = "failing"
+)
+
+// ComponentHealth is the status of a single component in a health check.
+type ComponentHealth struct {
+	Status HealthStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+// HealthApiResponse is returned by the readiness and liveness endpoints. The
+// overall status is failing if any component is failing.
+type HealthApiResponse struct {
+	Status     HealthStatus               `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
 }
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// AvroNamespace is the namespace of the Avro records of the events.
+const AvroNamespace = "com.github.tuhis.edgereceiver"
+
+// AvroSchema returns the Avro schema of the events of a schema version,
+// generated from the Go types that are produced like the JSON Schema. Fields
+// that may be null are unions with null defaulting to null, times are
+// timestamp-micros and the data of a measurement is a union of the data
+// models of the supported data formats.
+func AvroSchema(version string) ([]byte, error) {
+	root, err := rootType(version)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &avroGenerator{names: make(map[reflect.Type]string), used: make(map[string]bool)}
+	schema := g.record(root, root.Name())
+	schema.Namespace = AvroNamespace
+	return json.Marshal(schema)
+}
+
+// MarshalAvro encodes the event in the given format in the Avro binary
+// encoding of AvroSchema. Both shapes in the same record cannot be expressed
+// in Avro.
+func (e *RuuviKafkaEvent) MarshalAvro(format EventFormat) ([]byte, error) {
+	switch format {
+	case LegacyFormat:
+		return appendAvro(nil, reflect.ValueOf(*e))
+	case EnvelopeFormat:
+		return appendAvro(nil, reflect.ValueOf(e.Envelope(time.Now().UTC())))
+	default:
+		return nil, fmt.Errorf("event format %q is not supported in Avro", format)
+	}
+}
+
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type avroGenerator struct {
+	// Names of the records that have been defined. Later uses of the same
+	// type refer to the record by its name.
+	names map[reflect.Type]string
+	used  map[string]bool
+}
+
+// schema returns the schema of a type. name is used for records of anonymous
+// structs.
+func (g *avroGenerator) schema(t reflect.Type, name string) interface{} {
+	switch {
+	case t == timeType:
+		return map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+	case t.Kind() == reflect.Ptr:
+		return []interface{}{"null", g.schema(t.Elem(), name)}
+	case t.Kind() == reflect.Struct:
+		if name, ok := g.names[t]; ok {
+			return name
+		}
+		if t.Name() != "" {
+			name = t.Name()
+		}
+		return g.record(t, name)
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem(), name)}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "map", "values": g.schema(t.Elem(), name)}
+	case t.Kind() == reflect.Interface:
+		var models []interface{}
+		for _, model := range measurementModels {
+			models = append(models, g.schema(model, model.Name()))
+		}
+		return models
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "boolean"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "double"
+	default:
+		return "long"
+	}
+}
+
+// record defines the record of a struct. Records must have unique names, so
+// a name already taken by another type is numbered.
+func (g *avroGenerator) record(t reflect.Type, name string) *avroRecord {
+	for base, i := name, 2; g.used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.names[t] = name
+	g.used[name] = true
+
+	record := &avroRecord{Type: "record", Name: name, Fields: []avroField{}}
+	for _, field := range encodedFields(t) {
+		f := avroField{Name: field.name, Type: g.schema(field.Type, field.Name)}
+		if field.Type.Kind() == reflect.Ptr {
+			f.Default = json.RawMessage("null")
+		}
+		record.Fields = append(record.Fields, f)
+	}
+	return record
+}
+
+// appendAvro appends the Avro binary encoding of v to b.
+func appendAvro(b []byte, v reflect.Value) ([]byte, error) {
+	var err error
+
+	switch {
+	case v.Type() == timeType:
+		return binary.AppendVarint(b, v.Interface().(time.Time).UnixMicro()), nil
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return binary.AppendVarint(b, 0), nil
+		}
+		return appendAvro(binary.AppendVarint(b, 1), v.Elem())
+	case v.Kind() == reflect.Struct:
+		for _, field := range encodedFields(v.Type()) {
+			if b, err = appendAvro(b, v.FieldByIndex(field.Index)); err != nil {
+				return nil, fmt.Errorf("%s: %w", field.name, err)
+			}
+		}
+		return b, nil
+	case v.Kind() == reflect.Slice:
+		if v.Len() > 0 {
+			b = binary.AppendVarint(b, int64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				if b, err = appendAvro(b, v.Index(i)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return binary.AppendVarint(b, 0), nil
+	case v.Kind() == reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		if len(keys) > 0 {
+			b = binary.AppendVarint(b, int64(len(keys)))
+			for _, key := range keys {
+				b = appendAvroString(b, key.String())
+				if b, err = appendAvro(b, v.MapIndex(key)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return binary.AppendVarint(b, 0), nil
+	case v.Kind() == reflect.Interface:
+		return appendAvroMeasurement(b, v)
+	case v.Kind() == reflect.String:
+		return appendAvroString(b, v.String()), nil
+	case v.Kind() == reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), nil
+	case v.CanInt():
+		return binary.AppendVarint(b, v.Int()), nil
+	case v.CanUint():
+		return binary.AppendVarint(b, int64(v.Uint())), nil
+	default:
+		return nil, fmt.Errorf("cannot encode %s in Avro", v.Type())
+	}
+}
+
+// appendAvroMeasurement appends the data of a measurement as the branch of
+// the union of data models matching its type.
+func appendAvroMeasurement(b []byte, v reflect.Value) ([]byte, error) {
+	if v.IsNil() {
+		return nil, errors.New("data is missing")
+	}
+
+	data := reflect.Indirect(v.Elem())
+	if !data.IsValid() {
+		return nil, errors.New("data is missing")
+	}
+	for i, model := range measurementModels {
+		if data.Type() == model {
+			return appendAvro(binary.AppendVarint(b, int64(i)), data)
+		}
+	}
+	return nil, fmt.Errorf("%s is not a supported data model", data.Type())
+}
+
+func appendAvroString(b []byte, s string) []byte {
+	return append(binary.AppendVarint(b, int64(len(s))), s...)
+}
@@ -0,0 +1,75 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestAvroSchema(t *testing.T) {
+	for _, version := range events.SchemaVersions {
+		t.Run("v"+version, func(t *testing.T) {
+			schema, err := events.AvroSchema(version)
+			if err != nil {
+				t.Fatalf("AvroSchema() error = %v", err)
+			}
+
+			var record struct {
+				Type      string
+				Name      string
+				Namespace string
+				Fields    []struct{ Name string }
+			}
+			if err := json.Unmarshal(schema, &record); err != nil {
+				t.Fatalf("failed to unmarshal schema: %v", err)
+			}
+			if record.Type != "record" || record.Namespace != events.AvroNamespace || len(record.Fields) == 0 {
+				t.Errorf("AvroSchema() = %s", schema)
+			}
+			// Records are defined once and referred to by name afterwards
+			if n := bytes.Count(schema, []byte(`"name":"Acceleration","fields"`)); n != 1 {
+				t.Errorf("AvroSchema() defines Acceleration %d times", n)
+			}
+		})
+	}
+
+	if _, err := events.AvroSchema("0"); err == nil {
+		t.Error("AvroSchema() expected an error for an unknown version")
+	}
+}
+
+func TestRuuviKafkaEvent_MarshalAvro(t *testing.T) {
+	format := 3
+	event := events.RuuviKafkaEvent{
+		Type:       events.NewMeasurement,
+		Data:       &events.RAWv1MeasurementData{DataFormat: &format},
+		SourceUuid: "s",
+		ReceivedAt: time.UnixMicro(1),
+	}
+
+	got, err := event.MarshalAvro(events.LegacyFormat)
+	if err != nil {
+		t.Fatalf("RuuviKafkaEvent.MarshalAvro() error = %v", err)
+	}
+
+	want := []byte{30}
+	want = append(want, "new_measurement"...)
+	// First branch of the data union, DataFormat present and 9 null fields
+	want = append(want, 0, 2, 6, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	// source_uuid, null observed_at, received_at, empty receptions, null
+	// derived and empty range_violations
+	want = append(want, 2, 's', 0, 2, 0, 0, 0)
+	if !bytes.Equal(got, want) {
+		t.Errorf("RuuviKafkaEvent.MarshalAvro() = %v, want %v", got, want)
+	}
+
+	if _, err := event.MarshalAvro(events.BothFormats); err == nil {
+		t.Error("RuuviKafkaEvent.MarshalAvro() expected an error for both formats")
+	}
+	if _, err := (&events.RuuviKafkaEvent{}).MarshalAvro(events.LegacyFormat); err == nil {
+		t.Error("RuuviKafkaEvent.MarshalAvro() expected an error for missing data")
+	}
+}
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Derivation is a physical quantity computed from the readings of a
+// measurement.
+type Derivation string
+
+const (
+	// DewPoint is the temperature in °C at which the air would be saturated.
+	DewPoint Derivation = "dew_point"
+	// AbsoluteHumidity is the mass of water vapour in the air in g/m³.
+	AbsoluteHumidity Derivation = "absolute_humidity"
+	// EquilibriumVapourPressure is the saturation vapour pressure of water
+	// at the temperature of the air in Pa.
+	EquilibriumVapourPressure Derivation = "equilibrium_vapour_pressure"
+	// VapourPressureDeficit is the difference between the equilibrium and
+	// the actual vapour pressure in Pa.
+	VapourPressureDeficit Derivation = "vapour_pressure_deficit"
+	// AccelerationMagnitude is the length of the acceleration vector in mG.
+	AccelerationMagnitude Derivation = "acceleration_magnitude"
+	// Tilt is the angle of each axis of the tag to the horizontal plane in
+	// degrees.
+	Tilt Derivation = "tilt"
+	// BatteryPercentage is an estimate of the remaining battery charge from
+	// its voltage.
+	BatteryPercentage Derivation = "battery_percentage"
+	// PressureHPa is the air pressure in hPa.
+	PressureHPa Derivation = "pressure_hpa"
+)
+
+// AllDerivations lists every supported derivation.
+var AllDerivations = []Derivation{
+	DewPoint,
+	AbsoluteHumidity,
+	EquilibriumVapourPressure,
+	VapourPressureDeficit,
+	AccelerationMagnitude,
+	Tilt,
+	BatteryPercentage,
+	PressureHPa,
+}
+
+// Battery voltages in mV mapped to an empty and a full battery. The voltage
+// of a CR2477 cell is fairly flat until it is nearly empty, so the
+// percentage is only a rough estimate.
+const (
+	batteryEmptyVoltage = 2500
+	batteryFullVoltage  = 3000
+)
+
+// Derived holds the quantities derived from a measurement. Quantities that
+// are not enabled, or whose readings the data format lacks, are omitted.
+type Derived struct {
+	DewPoint                  *float64 `json:"dew_point,omitempty"`
+	AbsoluteHumidity          *float64 `json:"absolute_humidity,omitempty"`
+	EquilibriumVapourPressure *float64 `json:"equilibrium_vapour_pressure,omitempty"`
+	VapourPressureDeficit     *float64 `json:"vapour_pressure_deficit,omitempty"`
+	AccelerationMagnitude     *float64 `json:"acceleration_magnitude,omitempty"`
+	TiltX                     *float64 `json:"tilt_x,omitempty"`
+	TiltY                     *float64 `json:"tilt_y,omitempty"`
+	TiltZ                     *float64 `json:"tilt_z,omitempty"`
+	BatteryPercentage         *float64 `json:"battery_percentage,omitempty"`
+	PressureHPa               *float64 `json:"pressure_hpa,omitempty"`
+}
+
+// ParseDerivations parses a comma-separated list of derivations. "all"
+// enables every derivation.
+func ParseDerivations(s string) ([]Derivation, error) {
+	var derivations []Derivation
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return AllDerivations, nil
+		}
+
+		derivation := Derivation(name)
+		if !derivation.valid() {
+			return nil, fmt.Errorf("unknown derivation %q", name)
+		}
+		derivations = append(derivations, derivation)
+	}
+	return derivations, nil
+}
+
+func (d Derivation) valid() bool {
+	for _, derivation := range AllDerivations {
+		if d == derivation {
+			return true
+		}
+	}
+	return false
+}
+
+// Enricher computes the enabled derivations of measurements.
+type Enricher struct {
+	enabled map[Derivation]bool
+}
+
+// NewEnricher returns an enricher computing the given derivations.
+func NewEnricher(derivations ...Derivation) *Enricher {
+	e := &Enricher{enabled: make(map[Derivation]bool)}
+	for _, derivation := range derivations {
+		e.enabled[derivation] = true
+	}
+	return e
+}
+
+// readings are the readings of a measurement that quantities are derived
+// from. Readings the data format does not have are nil.
+type readings struct {
+	temperature *float64
+	humidity    *float64
+	pressure    *int
+	x, y, z     *int
+	battery     *int
+}
+
+func readingsOf(data MeasurementData) readings {
+	switch d := data.(type) {
+	case *NewMeasurementData:
+		r := readings{temperature: d.Temperature, humidity: d.Humidity, pressure: d.Pressure, battery: d.Battery}
+		if d.Acceleration != nil {
+			r.x, r.y, r.z = d.Acceleration.X, d.Acceleration.Y, d.Acceleration.Z
+		}
+		return r
+	case *RAWv1MeasurementData:
+		r := readings{temperature: d.Temperature, humidity: d.Humidity, pressure: d.Pressure, battery: d.Battery}
+		if d.Acceleration != nil {
+			r.x, r.y, r.z = d.Acceleration.X, d.Acceleration.Y, d.Acceleration.Z
+		}
+		return r
+	case *AirQualityMeasurementData:
+		return readings{temperature: d.Temperature, humidity: d.Humidity, pressure: d.Pressure}
+	case *ExtendedAirQualityMeasurementData:
+		return readings{temperature: d.Temperature, humidity: d.Humidity, pressure: d.Pressure}
+	default:
+		return readings{}
+	}
+}
+
+// Derive returns the enabled quantities that can be derived from data, or
+// nil if there are none.
+func (e *Enricher) Derive(data MeasurementData) *Derived {
+	r := readingsOf(data)
+	derived := &Derived{}
+	empty := true
+
+	set := func(derivation Derivation, field **float64, value float64) {
+		if !e.enabled[derivation] || math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		*field = float64Ptr(roundTo(value, 2))
+		empty = false
+	}
+
+	if r.temperature != nil {
+		es := equilibriumVapourPressure(*r.temperature)
+		set(EquilibriumVapourPressure, &derived.EquilibriumVapourPressure, es)
+
+		if r.humidity != nil && *r.humidity > 0 {
+			ea := es * *r.humidity / 100
+			set(VapourPressureDeficit, &derived.VapourPressureDeficit, es-ea)
+			set(AbsoluteHumidity, &derived.AbsoluteHumidity, absoluteHumidity(ea, *r.temperature))
+			set(DewPoint, &derived.DewPoint, dewPoint(*r.temperature, *r.humidity))
+		}
+	}
+
+	if r.x != nil && r.y != nil && r.z != nil {
+		x, y, z := float64(*r.x), float64(*r.y), float64(*r.z)
+		set(AccelerationMagnitude, &derived.AccelerationMagnitude, math.Sqrt(x*x+y*y+z*z))
+
+		if x != 0 || y != 0 || z != 0 {
+			set(Tilt, &derived.TiltX, tiltAngle(x, y, z))
+			set(Tilt, &derived.TiltY, tiltAngle(y, x, z))
+			set(Tilt, &derived.TiltZ, tiltAngle(z, x, y))
+		}
+	}
+
+	if r.battery != nil {
+		set(BatteryPercentage, &derived.BatteryPercentage, batteryPercentage(*r.battery))
+	}
+
+	if r.pressure != nil {
+		set(PressureHPa, &derived.PressureHPa, float64(*r.pressure)/100)
+	}
+
+	if empty {
+		return nil
+	}
+	return derived
+}
+
+// Magnus formula coefficients over water, as used by Ruuvi
+const (
+	magnusA = 17.67
+	magnusB = 243.5
+)
+
+// equilibriumVapourPressure returns the saturation vapour pressure in Pa at
+// temperature t in °C.
+func equilibriumVapourPressure(t float64) float64 {
+	return 611.2 * math.Exp(magnusA*t/(magnusB+t))
+}
+
+// absoluteHumidity returns the water vapour density in g/m³ of air at
+// temperature t in °C with vapour pressure ea in Pa.
+func absoluteHumidity(ea, t float64) float64 {
+	// Specific gas constant of water vapour in J/(kg·K)
+	const rv = 461.5
+	return ea / (rv * (t + 273.15)) * 1000
+}
+
+// dewPoint returns the dew point in °C of air at temperature t in °C with
+// relative humidity rh in %.
+func dewPoint(t, rh float64) float64 {
+	gamma := math.Log(rh/100) + magnusA*t/(magnusB+t)
+	return magnusB * gamma / (magnusA - gamma)
+}
+
+// tiltAngle returns the angle in degrees between axis a and the horizontal
+// plane, given the acceleration along it and the two other axes.
+func tiltAngle(a, b, c float64) float64 {
+	return math.Atan2(a, math.Sqrt(b*b+c*c)) * 180 / math.Pi
+}
+
+// batteryPercentage maps a battery voltage in mV linearly between an empty
+// and a full battery.
+func batteryPercentage(voltage int) float64 {
+	p := float64(voltage-batteryEmptyVoltage) / (batteryFullVoltage - batteryEmptyVoltage) * 100
+	return math.Max(0, math.Min(100, p))
+}
@@ -0,0 +1,80 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestEnricher_Derive(t *testing.T) {
+	rawv2 := measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75)
+
+	tests := []struct {
+		name        string
+		derivations []events.Derivation
+		data        events.MeasurementData
+		want        string
+	}{
+		{
+			name:        "all derivations",
+			derivations: events.AllDerivations,
+			data:        rawv2,
+			want: `{"dew_point":9.16,"absolute_humidity":8.5,"equilibrium_vapour_pressure":2698.18,"vapour_pressure_deficit":1538.64,` +
+				`"acceleration_magnitude":1056.22,"tilt_x":-0.43,"tilt_y":-1.08,"tilt_z":88.83,"battery_percentage":71.4,"pressure_hpa":974.65}`,
+		},
+		{
+			name:        "selected derivations",
+			derivations: []events.Derivation{events.DewPoint, events.PressureHPa},
+			data:        rawv2,
+			want:        `{"dew_point":9.16,"pressure_hpa":974.65}`,
+		},
+		{
+			name:        "format without acceleration or battery",
+			derivations: []events.Derivation{events.AccelerationMagnitude, events.BatteryPercentage, events.PressureHPa},
+			data:        &events.AirQualityMeasurementData{Pressure: intPtr(100000)},
+			want:        `{"pressure_hpa":1000}`,
+		},
+		{
+			name:        "battery percentage is clamped",
+			derivations: []events.Derivation{events.BatteryPercentage},
+			data:        &events.RAWv1MeasurementData{Battery: intPtr(3100)},
+			want:        `{"battery_percentage":100}`,
+		},
+		{
+			name:        "no humidity reading",
+			derivations: []events.Derivation{events.DewPoint},
+			data:        &events.NewMeasurementData{Temperature: float64Ptr(20), Humidity: float64Ptr(0)},
+			want:        `null`,
+		},
+		{
+			name: "nothing enabled",
+			data: rawv2,
+			want: `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(events.NewEnricher(tt.derivations...).Derive(tt.data))
+			if err != nil {
+				t.Fatalf("failed to marshal derived quantities: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Enricher.Derive() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDerivations(t *testing.T) {
+	if got, err := events.ParseDerivations("dew_point, tilt"); err != nil || len(got) != 2 || got[0] != events.DewPoint || got[1] != events.Tilt {
+		t.Errorf("ParseDerivations() = %v, %v", got, err)
+	}
+	if got, err := events.ParseDerivations("all"); err != nil || len(got) != len(events.AllDerivations) {
+		t.Errorf("ParseDerivations(all) = %v, %v", got, err)
+	}
+	if _, err := events.ParseDerivations("dew_point,heat_index"); err == nil {
+		t.Error("ParseDerivations() expected an error for an unknown derivation")
+	}
+}
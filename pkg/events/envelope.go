@@ -0,0 +1,122 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EnvelopeSchemaVersion is the version of the Envelope schema. The flat
+// RuuviKafkaEvent schema is SchemaVersion.
+const EnvelopeSchemaVersion = "2"
+
+// EventFormat is the shape in which events are produced to Kafka.
+type EventFormat string
+
+const (
+	// LegacyFormat produces the flat RuuviKafkaEvent.
+	LegacyFormat EventFormat = "legacy"
+	// EnvelopeFormat produces the versioned Envelope.
+	EnvelopeFormat EventFormat = "envelope"
+	// BothFormats produces the fields of both shapes in the same record, so
+	// that consumers can migrate to the envelope one at a time.
+	BothFormats EventFormat = "both"
+)
+
+// ParseEventFormat returns the event format with the given name.
+func ParseEventFormat(s string) (EventFormat, error) {
+	switch format := EventFormat(s); format {
+	case LegacyFormat, EnvelopeFormat, BothFormats:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown event format %q", s)
+	}
+}
+
+// SchemaVersion returns the schema version of events produced in the format.
+// Events in both shapes report the envelope version, which consumers that
+// check the version migrate to.
+func (f EventFormat) SchemaVersion() string {
+	if f == LegacyFormat {
+		return SchemaVersion
+	}
+	return EnvelopeSchemaVersion
+}
+
+// Envelope is a versioned event. The schema of the payload only changes
+// together with the schema version, so consumers can tell which version of
+// the payload they are reading.
+type Envelope struct {
+	// EventID identifies the event, also when it is produced again.
+	EventID       string          `json:"event_id"`
+	SchemaVersion string          `json:"schema_version"`
+	EventType     RuuviEventTypes `json:"event_type"`
+	// ProducedAt is the time edge-receiver produced the event.
+	ProducedAt time.Time `json:"produced_at"`
+	// Source is the source UUID of the event.
+	Source  string             `json:"source"`
+	Payload MeasurementPayload `json:"payload"`
+}
+
+// MeasurementPayload is the payload of a new_measurement envelope.
+type MeasurementPayload struct {
+	// DataFormat selects the data model of Data.
+	DataFormat      int              `json:"data_format"`
+	Data            interface{}      `json:"data"`
+	ObservedAt      *time.Time       `json:"observed_at,omitempty"`
+	ReceivedAt      time.Time        `json:"received_at"`
+	Receptions      []Reception      `json:"receptions,omitempty"`
+	Derived         *Derived         `json:"derived,omitempty"`
+	RangeViolations []RangeViolation `json:"range_violations,omitempty"`
+}
+
+// NewEventID returns a random version 4 UUID.
+func NewEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0F | 0x40
+	b[8] = b[8]&0x3F | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Envelope returns the event in a versioned envelope produced at producedAt.
+func (e *RuuviKafkaEvent) Envelope(producedAt time.Time) Envelope {
+	payload := MeasurementPayload{
+		Data:            e.Data,
+		ObservedAt:      e.ObservedAt,
+		ReceivedAt:      e.ReceivedAt,
+		Receptions:      e.Receptions,
+		Derived:         e.Derived,
+		RangeViolations: e.RangeViolations,
+	}
+	if data, ok := e.Data.(MeasurementData); ok {
+		payload.DataFormat = data.Format()
+	}
+
+	return Envelope{
+		EventID:       e.EventID,
+		SchemaVersion: EnvelopeSchemaVersion,
+		EventType:     e.Type,
+		ProducedAt:    producedAt,
+		Source:        e.SourceUuid,
+		Payload:       payload,
+	}
+}
+
+// Marshal encodes the event in the given format, produced now.
+func (e *RuuviKafkaEvent) Marshal(format EventFormat) ([]byte, error) {
+	switch format {
+	case EnvelopeFormat:
+		return json.Marshal(e.Envelope(time.Now().UTC()))
+	case BothFormats:
+		return json.Marshal(struct {
+			*RuuviKafkaEvent
+			Envelope
+		}{e, e.Envelope(time.Now().UTC())})
+	default:
+		return json.Marshal(e)
+	}
+}
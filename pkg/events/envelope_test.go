@@ -0,0 +1,138 @@
+package events_test
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestRuuviKafkaEvent_Marshal(t *testing.T) {
+	receivedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := events.RuuviKafkaEvent{
+		EventID:    "0b7a3f4e-5c1d-4f8a-9e2b-6d4c3a2b1f0e",
+		Type:       events.NewMeasurement,
+		Data:       measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		ReceivedAt: receivedAt,
+	}
+
+	tests := []struct {
+		name     string
+		format   events.EventFormat
+		legacy   bool
+		envelope bool
+	}{
+		{name: "legacy", format: events.LegacyFormat, legacy: true},
+		{name: "envelope", format: events.EnvelopeFormat, envelope: true},
+		{name: "both", format: events.BothFormats, legacy: true, envelope: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := event.Marshal(tt.format)
+			if err != nil {
+				t.Fatalf("RuuviKafkaEvent.Marshal() error = %v", err)
+			}
+
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(value, &got); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+
+			for _, field := range []string{"type", "data", "source_uuid", "received_at"} {
+				if _, ok := got[field]; ok != tt.legacy {
+					t.Errorf("legacy field %q present = %v, want %v", field, ok, tt.legacy)
+				}
+			}
+			for _, field := range []string{"event_id", "schema_version", "event_type", "produced_at", "source", "payload"} {
+				if _, ok := got[field]; ok != tt.envelope {
+					t.Errorf("envelope field %q present = %v, want %v", field, ok, tt.envelope)
+				}
+			}
+			if !tt.envelope {
+				return
+			}
+
+			var envelope events.Envelope
+			if err := json.Unmarshal(value, &envelope); err != nil {
+				t.Fatalf("failed to unmarshal envelope: %v", err)
+			}
+			if envelope.EventID != event.EventID || envelope.SchemaVersion != events.EnvelopeSchemaVersion ||
+				envelope.EventType != events.NewMeasurement || envelope.Source != event.SourceUuid {
+				t.Errorf("unexpected envelope %+v", envelope)
+			}
+			if envelope.Payload.DataFormat != 5 || !envelope.Payload.ReceivedAt.Equal(receivedAt) {
+				t.Errorf("unexpected payload %+v", envelope.Payload)
+			}
+			if envelope.ProducedAt.Before(receivedAt) {
+				t.Errorf("produced_at %v before received_at %v", envelope.ProducedAt, receivedAt)
+			}
+		})
+	}
+}
+
+func TestParseEventFormat(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		want          events.EventFormat
+		schemaVersion string
+		wantErr       bool
+	}{
+		{name: "legacy", s: "legacy", want: events.LegacyFormat, schemaVersion: "1"},
+		{name: "envelope", s: "envelope", want: events.EnvelopeFormat, schemaVersion: "2"},
+		{name: "both", s: "both", want: events.BothFormats, schemaVersion: "2"},
+		{name: "unknown", s: "avro", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := events.ParseEventFormat(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEventFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEventFormat() = %q, want %q", got, tt.want)
+			}
+			if !tt.wantErr && got.SchemaVersion() != tt.schemaVersion {
+				t.Errorf("EventFormat.SchemaVersion() = %q, want %q", got.SchemaVersion(), tt.schemaVersion)
+			}
+		})
+	}
+}
+
+func TestNewEventID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	first, second := events.NewEventID(), events.NewEventID()
+	if !uuidV4.MatchString(first) {
+		t.Errorf("NewEventID() = %q, want a version 4 UUID", first)
+	}
+	if first == second {
+		t.Errorf("NewEventID() returned %q twice", first)
+	}
+}
+
+// The schemas in docs/schemas are regenerated with go generate ./pkg/events
+func TestJSONSchema_UpToDate(t *testing.T) {
+	for _, version := range events.SchemaVersions {
+		t.Run("v"+version, func(t *testing.T) {
+			want, err := events.JSONSchema(version)
+			if err != nil {
+				t.Fatalf("JSONSchema() error = %v", err)
+			}
+
+			got, err := os.ReadFile("../../docs/schemas/v" + version + ".schema.json")
+			if err != nil {
+				t.Fatalf("failed to read schema: %v", err)
+			}
+			if string(got) != string(want)+"\n" {
+				t.Error("docs/schemas is out of date, run go generate ./pkg/events")
+			}
+		})
+	}
+}
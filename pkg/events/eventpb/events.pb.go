@@ -0,0 +1,1968 @@
+// Protobuf encoding of the events produced by edge-receiver. The messages
+// mirror the Go types in pkg/events: RuuviKafkaEvent is schema version 1 and
+// Envelope is schema version 2.
+//
+// Readings that may be missing are optional, so that a missing reading can
+// be told apart from zero. Field numbers must never be reused.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: pkg/events/eventpb/events.proto
+
+package eventpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RuuviKafkaEvent is a measurement in the flat legacy format.
+type RuuviKafkaEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type            string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Data            *Measurement           `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	SourceUuid      string                 `protobuf:"bytes,3,opt,name=source_uuid,json=sourceUuid,proto3" json:"source_uuid,omitempty"`
+	ObservedAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	ReceivedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	Receptions      []*Reception           `protobuf:"bytes,6,rep,name=receptions,proto3" json:"receptions,omitempty"`
+	Derived         *Derived               `protobuf:"bytes,7,opt,name=derived,proto3" json:"derived,omitempty"`
+	RangeViolations []*RangeViolation      `protobuf:"bytes,8,rep,name=range_violations,json=rangeViolations,proto3" json:"range_violations,omitempty"`
+}
+
+func (x *RuuviKafkaEvent) Reset() {
+	*x = RuuviKafkaEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RuuviKafkaEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuuviKafkaEvent) ProtoMessage() {}
+
+func (x *RuuviKafkaEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuuviKafkaEvent.ProtoReflect.Descriptor instead.
+func (*RuuviKafkaEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RuuviKafkaEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RuuviKafkaEvent) GetData() *Measurement {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *RuuviKafkaEvent) GetSourceUuid() string {
+	if x != nil {
+		return x.SourceUuid
+	}
+	return ""
+}
+
+func (x *RuuviKafkaEvent) GetObservedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return nil
+}
+
+func (x *RuuviKafkaEvent) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+func (x *RuuviKafkaEvent) GetReceptions() []*Reception {
+	if x != nil {
+		return x.Receptions
+	}
+	return nil
+}
+
+func (x *RuuviKafkaEvent) GetDerived() *Derived {
+	if x != nil {
+		return x.Derived
+	}
+	return nil
+}
+
+func (x *RuuviKafkaEvent) GetRangeViolations() []*RangeViolation {
+	if x != nil {
+		return x.RangeViolations
+	}
+	return nil
+}
+
+// Envelope is a measurement in the versioned envelope.
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	SchemaVersion string                 `protobuf:"bytes,2,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	EventType     string                 `protobuf:"bytes,3,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	ProducedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=produced_at,json=producedAt,proto3" json:"produced_at,omitempty"`
+	Source        string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`
+	Payload       *MeasurementPayload    `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Envelope) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *Envelope) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return ""
+}
+
+func (x *Envelope) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *Envelope) GetProducedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProducedAt
+	}
+	return nil
+}
+
+func (x *Envelope) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Envelope) GetPayload() *MeasurementPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type MeasurementPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DataFormat      int32                  `protobuf:"varint,1,opt,name=data_format,json=dataFormat,proto3" json:"data_format,omitempty"`
+	Data            *Measurement           `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	ObservedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	ReceivedAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	Receptions      []*Reception           `protobuf:"bytes,5,rep,name=receptions,proto3" json:"receptions,omitempty"`
+	Derived         *Derived               `protobuf:"bytes,6,opt,name=derived,proto3" json:"derived,omitempty"`
+	RangeViolations []*RangeViolation      `protobuf:"bytes,7,rep,name=range_violations,json=rangeViolations,proto3" json:"range_violations,omitempty"`
+}
+
+func (x *MeasurementPayload) Reset() {
+	*x = MeasurementPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MeasurementPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MeasurementPayload) ProtoMessage() {}
+
+func (x *MeasurementPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MeasurementPayload.ProtoReflect.Descriptor instead.
+func (*MeasurementPayload) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MeasurementPayload) GetDataFormat() int32 {
+	if x != nil {
+		return x.DataFormat
+	}
+	return 0
+}
+
+func (x *MeasurementPayload) GetData() *Measurement {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *MeasurementPayload) GetObservedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return nil
+}
+
+func (x *MeasurementPayload) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+func (x *MeasurementPayload) GetReceptions() []*Reception {
+	if x != nil {
+		return x.Receptions
+	}
+	return nil
+}
+
+func (x *MeasurementPayload) GetDerived() *Derived {
+	if x != nil {
+		return x.Derived
+	}
+	return nil
+}
+
+func (x *MeasurementPayload) GetRangeViolations() []*RangeViolation {
+	if x != nil {
+		return x.RangeViolations
+	}
+	return nil
+}
+
+// Measurement holds the data model of the data format of the measurement.
+type Measurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Data:
+	//	*Measurement_Rawv1
+	//	*Measurement_Rawv2
+	//	*Measurement_AirQuality
+	//	*Measurement_ExtendedAirQuality
+	Data isMeasurement_Data `protobuf_oneof:"data"`
+}
+
+func (x *Measurement) Reset() {
+	*x = Measurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Measurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Measurement) ProtoMessage() {}
+
+func (x *Measurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Measurement.ProtoReflect.Descriptor instead.
+func (*Measurement) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{3}
+}
+
+func (m *Measurement) GetData() isMeasurement_Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (x *Measurement) GetRawv1() *RAWv1Measurement {
+	if x, ok := x.GetData().(*Measurement_Rawv1); ok {
+		return x.Rawv1
+	}
+	return nil
+}
+
+func (x *Measurement) GetRawv2() *RAWv2Measurement {
+	if x, ok := x.GetData().(*Measurement_Rawv2); ok {
+		return x.Rawv2
+	}
+	return nil
+}
+
+func (x *Measurement) GetAirQuality() *AirQualityMeasurement {
+	if x, ok := x.GetData().(*Measurement_AirQuality); ok {
+		return x.AirQuality
+	}
+	return nil
+}
+
+func (x *Measurement) GetExtendedAirQuality() *ExtendedAirQualityMeasurement {
+	if x, ok := x.GetData().(*Measurement_ExtendedAirQuality); ok {
+		return x.ExtendedAirQuality
+	}
+	return nil
+}
+
+type isMeasurement_Data interface {
+	isMeasurement_Data()
+}
+
+type Measurement_Rawv1 struct {
+	Rawv1 *RAWv1Measurement `protobuf:"bytes,1,opt,name=rawv1,proto3,oneof"`
+}
+
+type Measurement_Rawv2 struct {
+	Rawv2 *RAWv2Measurement `protobuf:"bytes,2,opt,name=rawv2,proto3,oneof"`
+}
+
+type Measurement_AirQuality struct {
+	AirQuality *AirQualityMeasurement `protobuf:"bytes,3,opt,name=air_quality,json=airQuality,proto3,oneof"`
+}
+
+type Measurement_ExtendedAirQuality struct {
+	ExtendedAirQuality *ExtendedAirQualityMeasurement `protobuf:"bytes,4,opt,name=extended_air_quality,json=extendedAirQuality,proto3,oneof"`
+}
+
+func (*Measurement_Rawv1) isMeasurement_Data() {}
+
+func (*Measurement_Rawv2) isMeasurement_Data() {}
+
+func (*Measurement_AirQuality) isMeasurement_Data() {}
+
+func (*Measurement_ExtendedAirQuality) isMeasurement_Data() {}
+
+type Acceleration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	X *int32 `protobuf:"zigzag32,1,opt,name=x,proto3,oneof" json:"x,omitempty"`
+	Y *int32 `protobuf:"zigzag32,2,opt,name=y,proto3,oneof" json:"y,omitempty"`
+	Z *int32 `protobuf:"zigzag32,3,opt,name=z,proto3,oneof" json:"z,omitempty"`
+}
+
+func (x *Acceleration) Reset() {
+	*x = Acceleration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Acceleration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Acceleration) ProtoMessage() {}
+
+func (x *Acceleration) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Acceleration.ProtoReflect.Descriptor instead.
+func (*Acceleration) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Acceleration) GetX() int32 {
+	if x != nil && x.X != nil {
+		return *x.X
+	}
+	return 0
+}
+
+func (x *Acceleration) GetY() int32 {
+	if x != nil && x.Y != nil {
+		return *x.Y
+	}
+	return 0
+}
+
+func (x *Acceleration) GetZ() int32 {
+	if x != nil && x.Z != nil {
+		return *x.Z
+	}
+	return 0
+}
+
+// RAWv1Measurement is a measurement in data format 3.
+type RAWv1Measurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DataFormat   *int32        `protobuf:"varint,1,opt,name=data_format,json=dataFormat,proto3,oneof" json:"data_format,omitempty"`
+	Temperature  *float64      `protobuf:"fixed64,2,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	Humidity     *float64      `protobuf:"fixed64,3,opt,name=humidity,proto3,oneof" json:"humidity,omitempty"`
+	Pressure     *int32        `protobuf:"varint,4,opt,name=pressure,proto3,oneof" json:"pressure,omitempty"`
+	Acceleration *Acceleration `protobuf:"bytes,5,opt,name=acceleration,proto3" json:"acceleration,omitempty"`
+	Battery      *int32        `protobuf:"varint,6,opt,name=battery,proto3,oneof" json:"battery,omitempty"`
+	Mac          *string       `protobuf:"bytes,7,opt,name=mac,proto3,oneof" json:"mac,omitempty"`
+	Rssi         *int32        `protobuf:"zigzag32,8,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	Address      *string       `protobuf:"bytes,9,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	LocalName    *string       `protobuf:"bytes,10,opt,name=local_name,json=localName,proto3,oneof" json:"local_name,omitempty"`
+}
+
+func (x *RAWv1Measurement) Reset() {
+	*x = RAWv1Measurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RAWv1Measurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RAWv1Measurement) ProtoMessage() {}
+
+func (x *RAWv1Measurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RAWv1Measurement.ProtoReflect.Descriptor instead.
+func (*RAWv1Measurement) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RAWv1Measurement) GetDataFormat() int32 {
+	if x != nil && x.DataFormat != nil {
+		return *x.DataFormat
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetHumidity() float64 {
+	if x != nil && x.Humidity != nil {
+		return *x.Humidity
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetPressure() int32 {
+	if x != nil && x.Pressure != nil {
+		return *x.Pressure
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetAcceleration() *Acceleration {
+	if x != nil {
+		return x.Acceleration
+	}
+	return nil
+}
+
+func (x *RAWv1Measurement) GetBattery() int32 {
+	if x != nil && x.Battery != nil {
+		return *x.Battery
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetMac() string {
+	if x != nil && x.Mac != nil {
+		return *x.Mac
+	}
+	return ""
+}
+
+func (x *RAWv1Measurement) GetRssi() int32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *RAWv1Measurement) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *RAWv1Measurement) GetLocalName() string {
+	if x != nil && x.LocalName != nil {
+		return *x.LocalName
+	}
+	return ""
+}
+
+// RAWv2Measurement is a measurement in data format 5.
+type RAWv2Measurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DataFormat   *int32        `protobuf:"varint,1,opt,name=data_format,json=dataFormat,proto3,oneof" json:"data_format,omitempty"`
+	Temperature  *float64      `protobuf:"fixed64,2,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	Humidity     *float64      `protobuf:"fixed64,3,opt,name=humidity,proto3,oneof" json:"humidity,omitempty"`
+	Pressure     *int32        `protobuf:"varint,4,opt,name=pressure,proto3,oneof" json:"pressure,omitempty"`
+	Acceleration *Acceleration `protobuf:"bytes,5,opt,name=acceleration,proto3" json:"acceleration,omitempty"`
+	Battery      *int32        `protobuf:"varint,6,opt,name=battery,proto3,oneof" json:"battery,omitempty"`
+	TxPower      *int32        `protobuf:"zigzag32,7,opt,name=tx_power,json=txPower,proto3,oneof" json:"tx_power,omitempty"`
+	Movement     *int32        `protobuf:"varint,8,opt,name=movement,proto3,oneof" json:"movement,omitempty"`
+	Sequence     *int32        `protobuf:"varint,9,opt,name=sequence,proto3,oneof" json:"sequence,omitempty"`
+	Mac          *string       `protobuf:"bytes,10,opt,name=mac,proto3,oneof" json:"mac,omitempty"`
+	Rssi         *int32        `protobuf:"zigzag32,11,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	Address      *string       `protobuf:"bytes,12,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	LocalName    *string       `protobuf:"bytes,13,opt,name=local_name,json=localName,proto3,oneof" json:"local_name,omitempty"`
+}
+
+func (x *RAWv2Measurement) Reset() {
+	*x = RAWv2Measurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RAWv2Measurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RAWv2Measurement) ProtoMessage() {}
+
+func (x *RAWv2Measurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RAWv2Measurement.ProtoReflect.Descriptor instead.
+func (*RAWv2Measurement) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RAWv2Measurement) GetDataFormat() int32 {
+	if x != nil && x.DataFormat != nil {
+		return *x.DataFormat
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetHumidity() float64 {
+	if x != nil && x.Humidity != nil {
+		return *x.Humidity
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetPressure() int32 {
+	if x != nil && x.Pressure != nil {
+		return *x.Pressure
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetAcceleration() *Acceleration {
+	if x != nil {
+		return x.Acceleration
+	}
+	return nil
+}
+
+func (x *RAWv2Measurement) GetBattery() int32 {
+	if x != nil && x.Battery != nil {
+		return *x.Battery
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetTxPower() int32 {
+	if x != nil && x.TxPower != nil {
+		return *x.TxPower
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetMovement() int32 {
+	if x != nil && x.Movement != nil {
+		return *x.Movement
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetSequence() int32 {
+	if x != nil && x.Sequence != nil {
+		return *x.Sequence
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetMac() string {
+	if x != nil && x.Mac != nil {
+		return *x.Mac
+	}
+	return ""
+}
+
+func (x *RAWv2Measurement) GetRssi() int32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *RAWv2Measurement) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *RAWv2Measurement) GetLocalName() string {
+	if x != nil && x.LocalName != nil {
+		return *x.LocalName
+	}
+	return ""
+}
+
+// AirQualityMeasurement is a measurement in data format 6.
+type AirQualityMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DataFormat  *int32   `protobuf:"varint,1,opt,name=data_format,json=dataFormat,proto3,oneof" json:"data_format,omitempty"`
+	Temperature *float64 `protobuf:"fixed64,2,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	Humidity    *float64 `protobuf:"fixed64,3,opt,name=humidity,proto3,oneof" json:"humidity,omitempty"`
+	Pressure    *int32   `protobuf:"varint,4,opt,name=pressure,proto3,oneof" json:"pressure,omitempty"`
+	Pm2_5       *float64 `protobuf:"fixed64,5,opt,name=pm2_5,json=pm25,proto3,oneof" json:"pm2_5,omitempty"`
+	Co2         *int32   `protobuf:"varint,6,opt,name=co2,proto3,oneof" json:"co2,omitempty"`
+	Voc         *int32   `protobuf:"varint,7,opt,name=voc,proto3,oneof" json:"voc,omitempty"`
+	Nox         *int32   `protobuf:"varint,8,opt,name=nox,proto3,oneof" json:"nox,omitempty"`
+	Luminosity  *float64 `protobuf:"fixed64,9,opt,name=luminosity,proto3,oneof" json:"luminosity,omitempty"`
+	Sequence    *int32   `protobuf:"varint,10,opt,name=sequence,proto3,oneof" json:"sequence,omitempty"`
+	Flags       *int32   `protobuf:"varint,11,opt,name=flags,proto3,oneof" json:"flags,omitempty"`
+	Mac         *string  `protobuf:"bytes,12,opt,name=mac,proto3,oneof" json:"mac,omitempty"`
+	Rssi        *int32   `protobuf:"zigzag32,13,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	Address     *string  `protobuf:"bytes,14,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	LocalName   *string  `protobuf:"bytes,15,opt,name=local_name,json=localName,proto3,oneof" json:"local_name,omitempty"`
+}
+
+func (x *AirQualityMeasurement) Reset() {
+	*x = AirQualityMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AirQualityMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AirQualityMeasurement) ProtoMessage() {}
+
+func (x *AirQualityMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AirQualityMeasurement.ProtoReflect.Descriptor instead.
+func (*AirQualityMeasurement) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AirQualityMeasurement) GetDataFormat() int32 {
+	if x != nil && x.DataFormat != nil {
+		return *x.DataFormat
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetHumidity() float64 {
+	if x != nil && x.Humidity != nil {
+		return *x.Humidity
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetPressure() int32 {
+	if x != nil && x.Pressure != nil {
+		return *x.Pressure
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetPm2_5() float64 {
+	if x != nil && x.Pm2_5 != nil {
+		return *x.Pm2_5
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetCo2() int32 {
+	if x != nil && x.Co2 != nil {
+		return *x.Co2
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetVoc() int32 {
+	if x != nil && x.Voc != nil {
+		return *x.Voc
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetNox() int32 {
+	if x != nil && x.Nox != nil {
+		return *x.Nox
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetLuminosity() float64 {
+	if x != nil && x.Luminosity != nil {
+		return *x.Luminosity
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetSequence() int32 {
+	if x != nil && x.Sequence != nil {
+		return *x.Sequence
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetFlags() int32 {
+	if x != nil && x.Flags != nil {
+		return *x.Flags
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetMac() string {
+	if x != nil && x.Mac != nil {
+		return *x.Mac
+	}
+	return ""
+}
+
+func (x *AirQualityMeasurement) GetRssi() int32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *AirQualityMeasurement) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *AirQualityMeasurement) GetLocalName() string {
+	if x != nil && x.LocalName != nil {
+		return *x.LocalName
+	}
+	return ""
+}
+
+// ExtendedAirQualityMeasurement is a measurement in data format E1.
+type ExtendedAirQualityMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DataFormat   *int32   `protobuf:"varint,1,opt,name=data_format,json=dataFormat,proto3,oneof" json:"data_format,omitempty"`
+	Temperature  *float64 `protobuf:"fixed64,2,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	Humidity     *float64 `protobuf:"fixed64,3,opt,name=humidity,proto3,oneof" json:"humidity,omitempty"`
+	Pressure     *int32   `protobuf:"varint,4,opt,name=pressure,proto3,oneof" json:"pressure,omitempty"`
+	Pm1_0        *float64 `protobuf:"fixed64,5,opt,name=pm1_0,json=pm10,proto3,oneof" json:"pm1_0,omitempty"`
+	Pm2_5        *float64 `protobuf:"fixed64,6,opt,name=pm2_5,json=pm25,proto3,oneof" json:"pm2_5,omitempty"`
+	Pm4_0        *float64 `protobuf:"fixed64,7,opt,name=pm4_0,json=pm40,proto3,oneof" json:"pm4_0,omitempty"`
+	Pm10_0       *float64 `protobuf:"fixed64,8,opt,name=pm10_0,json=pm100,proto3,oneof" json:"pm10_0,omitempty"`
+	Co2          *int32   `protobuf:"varint,9,opt,name=co2,proto3,oneof" json:"co2,omitempty"`
+	Voc          *int32   `protobuf:"varint,10,opt,name=voc,proto3,oneof" json:"voc,omitempty"`
+	Nox          *int32   `protobuf:"varint,11,opt,name=nox,proto3,oneof" json:"nox,omitempty"`
+	Luminosity   *float64 `protobuf:"fixed64,12,opt,name=luminosity,proto3,oneof" json:"luminosity,omitempty"`
+	SoundInstant *float64 `protobuf:"fixed64,13,opt,name=sound_instant,json=soundInstant,proto3,oneof" json:"sound_instant,omitempty"`
+	SoundAverage *float64 `protobuf:"fixed64,14,opt,name=sound_average,json=soundAverage,proto3,oneof" json:"sound_average,omitempty"`
+	SoundPeak    *float64 `protobuf:"fixed64,15,opt,name=sound_peak,json=soundPeak,proto3,oneof" json:"sound_peak,omitempty"`
+	Sequence     *int32   `protobuf:"varint,16,opt,name=sequence,proto3,oneof" json:"sequence,omitempty"`
+	Flags        *int32   `protobuf:"varint,17,opt,name=flags,proto3,oneof" json:"flags,omitempty"`
+	Mac          *string  `protobuf:"bytes,18,opt,name=mac,proto3,oneof" json:"mac,omitempty"`
+	Rssi         *int32   `protobuf:"zigzag32,19,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	Address      *string  `protobuf:"bytes,20,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	LocalName    *string  `protobuf:"bytes,21,opt,name=local_name,json=localName,proto3,oneof" json:"local_name,omitempty"`
+}
+
+func (x *ExtendedAirQualityMeasurement) Reset() {
+	*x = ExtendedAirQualityMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendedAirQualityMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendedAirQualityMeasurement) ProtoMessage() {}
+
+func (x *ExtendedAirQualityMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendedAirQualityMeasurement.ProtoReflect.Descriptor instead.
+func (*ExtendedAirQualityMeasurement) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExtendedAirQualityMeasurement) GetDataFormat() int32 {
+	if x != nil && x.DataFormat != nil {
+		return *x.DataFormat
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetHumidity() float64 {
+	if x != nil && x.Humidity != nil {
+		return *x.Humidity
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetPressure() int32 {
+	if x != nil && x.Pressure != nil {
+		return *x.Pressure
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetPm1_0() float64 {
+	if x != nil && x.Pm1_0 != nil {
+		return *x.Pm1_0
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetPm2_5() float64 {
+	if x != nil && x.Pm2_5 != nil {
+		return *x.Pm2_5
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetPm4_0() float64 {
+	if x != nil && x.Pm4_0 != nil {
+		return *x.Pm4_0
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetPm10_0() float64 {
+	if x != nil && x.Pm10_0 != nil {
+		return *x.Pm10_0
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetCo2() int32 {
+	if x != nil && x.Co2 != nil {
+		return *x.Co2
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetVoc() int32 {
+	if x != nil && x.Voc != nil {
+		return *x.Voc
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetNox() int32 {
+	if x != nil && x.Nox != nil {
+		return *x.Nox
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetLuminosity() float64 {
+	if x != nil && x.Luminosity != nil {
+		return *x.Luminosity
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetSoundInstant() float64 {
+	if x != nil && x.SoundInstant != nil {
+		return *x.SoundInstant
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetSoundAverage() float64 {
+	if x != nil && x.SoundAverage != nil {
+		return *x.SoundAverage
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetSoundPeak() float64 {
+	if x != nil && x.SoundPeak != nil {
+		return *x.SoundPeak
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetSequence() int32 {
+	if x != nil && x.Sequence != nil {
+		return *x.Sequence
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetFlags() int32 {
+	if x != nil && x.Flags != nil {
+		return *x.Flags
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetMac() string {
+	if x != nil && x.Mac != nil {
+		return *x.Mac
+	}
+	return ""
+}
+
+func (x *ExtendedAirQualityMeasurement) GetRssi() int32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *ExtendedAirQualityMeasurement) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *ExtendedAirQualityMeasurement) GetLocalName() string {
+	if x != nil && x.LocalName != nil {
+		return *x.LocalName
+	}
+	return ""
+}
+
+type Reception struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SourceUuid string                 `protobuf:"bytes,1,opt,name=source_uuid,json=sourceUuid,proto3" json:"source_uuid,omitempty"`
+	Rssi       *int32                 `protobuf:"zigzag32,2,opt,name=rssi,proto3,oneof" json:"rssi,omitempty"`
+	ReceivedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	Strongest  bool                   `protobuf:"varint,4,opt,name=strongest,proto3" json:"strongest,omitempty"`
+}
+
+func (x *Reception) Reset() {
+	*x = Reception{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reception) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reception) ProtoMessage() {}
+
+func (x *Reception) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reception.ProtoReflect.Descriptor instead.
+func (*Reception) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Reception) GetSourceUuid() string {
+	if x != nil {
+		return x.SourceUuid
+	}
+	return ""
+}
+
+func (x *Reception) GetRssi() int32 {
+	if x != nil && x.Rssi != nil {
+		return *x.Rssi
+	}
+	return 0
+}
+
+func (x *Reception) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+func (x *Reception) GetStrongest() bool {
+	if x != nil {
+		return x.Strongest
+	}
+	return false
+}
+
+type Derived struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DewPoint                  *float64 `protobuf:"fixed64,1,opt,name=dew_point,json=dewPoint,proto3,oneof" json:"dew_point,omitempty"`
+	AbsoluteHumidity          *float64 `protobuf:"fixed64,2,opt,name=absolute_humidity,json=absoluteHumidity,proto3,oneof" json:"absolute_humidity,omitempty"`
+	EquilibriumVapourPressure *float64 `protobuf:"fixed64,3,opt,name=equilibrium_vapour_pressure,json=equilibriumVapourPressure,proto3,oneof" json:"equilibrium_vapour_pressure,omitempty"`
+	VapourPressureDeficit     *float64 `protobuf:"fixed64,4,opt,name=vapour_pressure_deficit,json=vapourPressureDeficit,proto3,oneof" json:"vapour_pressure_deficit,omitempty"`
+	AccelerationMagnitude     *float64 `protobuf:"fixed64,5,opt,name=acceleration_magnitude,json=accelerationMagnitude,proto3,oneof" json:"acceleration_magnitude,omitempty"`
+	TiltX                     *float64 `protobuf:"fixed64,6,opt,name=tilt_x,json=tiltX,proto3,oneof" json:"tilt_x,omitempty"`
+	TiltY                     *float64 `protobuf:"fixed64,7,opt,name=tilt_y,json=tiltY,proto3,oneof" json:"tilt_y,omitempty"`
+	TiltZ                     *float64 `protobuf:"fixed64,8,opt,name=tilt_z,json=tiltZ,proto3,oneof" json:"tilt_z,omitempty"`
+	BatteryPercentage         *float64 `protobuf:"fixed64,9,opt,name=battery_percentage,json=batteryPercentage,proto3,oneof" json:"battery_percentage,omitempty"`
+	PressureHpa               *float64 `protobuf:"fixed64,10,opt,name=pressure_hpa,json=pressureHpa,proto3,oneof" json:"pressure_hpa,omitempty"`
+}
+
+func (x *Derived) Reset() {
+	*x = Derived{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Derived) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Derived) ProtoMessage() {}
+
+func (x *Derived) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Derived.ProtoReflect.Descriptor instead.
+func (*Derived) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Derived) GetDewPoint() float64 {
+	if x != nil && x.DewPoint != nil {
+		return *x.DewPoint
+	}
+	return 0
+}
+
+func (x *Derived) GetAbsoluteHumidity() float64 {
+	if x != nil && x.AbsoluteHumidity != nil {
+		return *x.AbsoluteHumidity
+	}
+	return 0
+}
+
+func (x *Derived) GetEquilibriumVapourPressure() float64 {
+	if x != nil && x.EquilibriumVapourPressure != nil {
+		return *x.EquilibriumVapourPressure
+	}
+	return 0
+}
+
+func (x *Derived) GetVapourPressureDeficit() float64 {
+	if x != nil && x.VapourPressureDeficit != nil {
+		return *x.VapourPressureDeficit
+	}
+	return 0
+}
+
+func (x *Derived) GetAccelerationMagnitude() float64 {
+	if x != nil && x.AccelerationMagnitude != nil {
+		return *x.AccelerationMagnitude
+	}
+	return 0
+}
+
+func (x *Derived) GetTiltX() float64 {
+	if x != nil && x.TiltX != nil {
+		return *x.TiltX
+	}
+	return 0
+}
+
+func (x *Derived) GetTiltY() float64 {
+	if x != nil && x.TiltY != nil {
+		return *x.TiltY
+	}
+	return 0
+}
+
+func (x *Derived) GetTiltZ() float64 {
+	if x != nil && x.TiltZ != nil {
+		return *x.TiltZ
+	}
+	return 0
+}
+
+func (x *Derived) GetBatteryPercentage() float64 {
+	if x != nil && x.BatteryPercentage != nil {
+		return *x.BatteryPercentage
+	}
+	return 0
+}
+
+func (x *Derived) GetPressureHpa() float64 {
+	if x != nil && x.PressureHpa != nil {
+		return *x.PressureHpa
+	}
+	return 0
+}
+
+type RangeViolation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Field string   `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Value float64  `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Min   *float64 `protobuf:"fixed64,3,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max   *float64 `protobuf:"fixed64,4,opt,name=max,proto3,oneof" json:"max,omitempty"`
+}
+
+func (x *RangeViolation) Reset() {
+	*x = RangeViolation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_events_eventpb_events_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RangeViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeViolation) ProtoMessage() {}
+
+func (x *RangeViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_events_eventpb_events_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeViolation.ProtoReflect.Descriptor instead.
+func (*RangeViolation) Descriptor() ([]byte, []int) {
+	return file_pkg_events_eventpb_events_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RangeViolation) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *RangeViolation) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RangeViolation) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *RangeViolation) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+var File_pkg_events_eventpb_events_proto protoreflect.FileDescriptor
+
+var file_pkg_events_eventpb_events_proto_rawDesc = []byte{
+	0x0a, 0x1f, 0x70, 0x6b, 0x67, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2f, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x70, 0x62, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x13, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xbe, 0x03, 0x0a, 0x0f, 0x52, 0x75, 0x75, 0x76,
+	0x69, 0x4b, 0x61, 0x66, 0x6b, 0x61, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x34, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f,
+	0x75, 0x75, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x55, 0x75, 0x69, 0x64, 0x12, 0x3b, 0x0a, 0x0b, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f,
+	0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x3e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x52, 0x65, 0x63, 0x65, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x36, 0x0a, 0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1c, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72,
+	0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x52,
+	0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x4e, 0x0a, 0x10, 0x72, 0x61, 0x6e, 0x67,
+	0x65, 0x5f, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x08, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x23, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65,
+	0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x56, 0x69,
+	0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x56, 0x69,
+	0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x83, 0x02, 0x0a, 0x08, 0x45, 0x6e, 0x76,
+	0x65, 0x6c, 0x6f, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x12, 0x25, 0x0a, 0x0e, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x3b, 0x0a, 0x0b, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x41, 0x0a, 0x07, 0x70,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x65,
+	0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0xad,
+	0x03, 0x0a, 0x12, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61,
+	0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x34, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x4d, 0x65, 0x61, 0x73, 0x75,
+	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x3b, 0x0a, 0x0b,
+	0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6f,
+	0x62, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x3b, 0x0a, 0x0b, 0x72, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x3e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x65, 0x64, 0x67,
+	0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x2e, 0x52, 0x65, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x36, 0x0a, 0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65,
+	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x44, 0x65,
+	0x72, 0x69, 0x76, 0x65, 0x64, 0x52, 0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x4e,
+	0x0a, 0x10, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x52,
+	0x61, 0x6e, 0x67, 0x65, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x72,
+	0x61, 0x6e, 0x67, 0x65, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xca,
+	0x02, 0x0a, 0x0b, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x3d,
+	0x0a, 0x05, 0x72, 0x61, 0x77, 0x76, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e,
+	0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x73, 0x2e, 0x52, 0x41, 0x57, 0x76, 0x31, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x72, 0x61, 0x77, 0x76, 0x31, 0x12, 0x3d, 0x0a,
+	0x05, 0x72, 0x61, 0x77, 0x76, 0x32, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x65,
+	0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2e, 0x52, 0x41, 0x57, 0x76, 0x32, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x72, 0x61, 0x77, 0x76, 0x32, 0x12, 0x4d, 0x0a, 0x0b,
+	0x61, 0x69, 0x72, 0x5f, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x2a, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72,
+	0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x41, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c, 0x69,
+	0x74, 0x79, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52,
+	0x0a, 0x61, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x66, 0x0a, 0x14, 0x65,
+	0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x61, 0x69, 0x72, 0x5f, 0x71, 0x75, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x65, 0x64, 0x67, 0x65,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x41, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c, 0x69,
+	0x74, 0x79, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52,
+	0x12, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x41, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x42, 0x06, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x59, 0x0a, 0x0c, 0x41,
+	0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x11, 0x0a, 0x01, 0x78,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x11, 0x48, 0x00, 0x52, 0x01, 0x78, 0x88, 0x01, 0x01, 0x12, 0x11,
+	0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x11, 0x48, 0x01, 0x52, 0x01, 0x79, 0x88, 0x01,
+	0x01, 0x12, 0x11, 0x0a, 0x01, 0x7a, 0x18, 0x03, 0x20, 0x01, 0x28, 0x11, 0x48, 0x02, 0x52, 0x01,
+	0x7a, 0x88, 0x01, 0x01, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x78, 0x42, 0x04, 0x0a, 0x02, 0x5f, 0x79,
+	0x42, 0x04, 0x0a, 0x02, 0x5f, 0x7a, 0x22, 0xec, 0x03, 0x0a, 0x10, 0x52, 0x41, 0x57, 0x76, 0x31,
+	0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0b, 0x64,
+	0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x48, 0x00, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88, 0x01,
+	0x01, 0x12, 0x25, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69,
+	0x64, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x08, 0x68, 0x75,
+	0x6d, 0x69, 0x64, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x08, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x45, 0x0a, 0x0c, 0x61, 0x63,
+	0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x21, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x1d, 0x0a, 0x07, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x05, 0x48, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x88, 0x01, 0x01,
+	0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x48, 0x05, 0x52,
+	0x03, 0x6d, 0x61, 0x63, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x11, 0x48, 0x06, 0x52, 0x04, 0x72, 0x73, 0x73, 0x69, 0x88, 0x01, 0x01,
+	0x12, 0x1d, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x07, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x22, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x08, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x4e, 0x61, 0x6d, 0x65,
+	0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79,
+	0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x42, 0x0a, 0x0a,
+	0x08, 0x5f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61,
+	0x63, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xf5, 0x04, 0x0a, 0x10, 0x52, 0x41, 0x57, 0x76, 0x32, 0x4d,
+	0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0b, 0x64, 0x61,
+	0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48,
+	0x00, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88, 0x01, 0x01,
+	0x12, 0x25, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64,
+	0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x08, 0x68, 0x75, 0x6d,
+	0x69, 0x64, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x75, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x08, 0x70, 0x72,
+	0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x45, 0x0a, 0x0c, 0x61, 0x63, 0x63,
+	0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x21, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0c, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1d, 0x0a, 0x07, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x05, 0x48, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x88, 0x01, 0x01, 0x12,
+	0x1e, 0x0a, 0x08, 0x74, 0x78, 0x5f, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x11, 0x48, 0x05, 0x52, 0x07, 0x74, 0x78, 0x50, 0x6f, 0x77, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12,
+	0x1f, 0x0a, 0x08, 0x6d, 0x6f, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x05, 0x48, 0x06, 0x52, 0x08, 0x6d, 0x6f, 0x76, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x88, 0x01, 0x01,
+	0x12, 0x1f, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x05, 0x48, 0x07, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x88, 0x01,
+	0x01, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x48, 0x08,
+	0x52, 0x03, 0x6d, 0x61, 0x63, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x11, 0x48, 0x09, 0x52, 0x04, 0x72, 0x73, 0x73, 0x69, 0x88, 0x01,
+	0x01, 0x12, 0x1d, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x0a, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01,
+	0x12, 0x22, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0d,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x0b, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x4e, 0x61, 0x6d,
+	0x65, 0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74,
+	0x79, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x42, 0x0a,
+	0x0a, 0x08, 0x5f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x74,
+	0x78, 0x5f, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x6d, 0x6f, 0x76, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61, 0x63, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73,
+	0x73, 0x69, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x0d,
+	0x0a, 0x0b, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x87, 0x05,
+	0x0a, 0x15, 0x41, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x4d, 0x65, 0x61, 0x73,
+	0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x5f,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0a,
+	0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a,
+	0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x01, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69,
+	0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x70, 0x6d, 0x32, 0x5f, 0x35, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x01, 0x48, 0x04, 0x52, 0x04, 0x70, 0x6d, 0x32, 0x35, 0x88, 0x01, 0x01,
+	0x12, 0x15, 0x0a, 0x03, 0x63, 0x6f, 0x32, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x48, 0x05, 0x52,
+	0x03, 0x63, 0x6f, 0x32, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x76, 0x6f, 0x63, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x05, 0x48, 0x06, 0x52, 0x03, 0x76, 0x6f, 0x63, 0x88, 0x01, 0x01, 0x12, 0x15,
+	0x0a, 0x03, 0x6e, 0x6f, 0x78, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x48, 0x07, 0x52, 0x03, 0x6e,
+	0x6f, 0x78, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x6f, 0x73,
+	0x69, 0x74, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x48, 0x08, 0x52, 0x0a, 0x6c, 0x75, 0x6d,
+	0x69, 0x6e, 0x6f, 0x73, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x48, 0x09, 0x52, 0x08,
+	0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x88, 0x01, 0x01, 0x12, 0x19, 0x0a, 0x05, 0x66,
+	0x6c, 0x61, 0x67, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x48, 0x0a, 0x52, 0x05, 0x66, 0x6c,
+	0x61, 0x67, 0x73, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x0b, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a,
+	0x04, 0x72, 0x73, 0x73, 0x69, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x11, 0x48, 0x0c, 0x52, 0x04, 0x72,
+	0x73, 0x73, 0x69, 0x88, 0x01, 0x01, 0x12, 0x1d, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x48, 0x0d, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x48, 0x0e, 0x52, 0x09, 0x6c, 0x6f, 0x63,
+	0x61, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x64, 0x61,
+	0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x65,
+	0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x68, 0x75,
+	0x6d, 0x69, 0x64, 0x69, 0x74, 0x79, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x75, 0x72, 0x65, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x70, 0x6d, 0x32, 0x5f, 0x35, 0x42, 0x06, 0x0a,
+	0x04, 0x5f, 0x63, 0x6f, 0x32, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x76, 0x6f, 0x63, 0x42, 0x06, 0x0a,
+	0x04, 0x5f, 0x6e, 0x6f, 0x78, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x6f,
+	0x73, 0x69, 0x74, 0x79, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x42, 0x06, 0x0a, 0x04, 0x5f,
+	0x6d, 0x61, 0x63, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x42, 0x0a, 0x0a, 0x08,
+	0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6c, 0x6f, 0x63,
+	0x61, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xa9, 0x07, 0x0a, 0x1d, 0x45, 0x78, 0x74, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x41, 0x69, 0x72, 0x51, 0x75, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x4d, 0x65,
+	0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0b, 0x64, 0x61, 0x74,
+	0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00,
+	0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88, 0x01, 0x01, 0x12,
+	0x25, 0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69,
+	0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x08, 0x68, 0x75, 0x6d, 0x69,
+	0x64, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x75, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x08, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x75, 0x72, 0x65, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x70, 0x6d, 0x31, 0x5f,
+	0x30, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x48, 0x04, 0x52, 0x04, 0x70, 0x6d, 0x31, 0x30, 0x88,
+	0x01, 0x01, 0x12, 0x18, 0x0a, 0x05, 0x70, 0x6d, 0x32, 0x5f, 0x35, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x05, 0x52, 0x04, 0x70, 0x6d, 0x32, 0x35, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x05,
+	0x70, 0x6d, 0x34, 0x5f, 0x30, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x48, 0x06, 0x52, 0x04, 0x70,
+	0x6d, 0x34, 0x30, 0x88, 0x01, 0x01, 0x12, 0x1a, 0x0a, 0x06, 0x70, 0x6d, 0x31, 0x30, 0x5f, 0x30,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07, 0x52, 0x05, 0x70, 0x6d, 0x31, 0x30, 0x30, 0x88,
+	0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x63, 0x6f, 0x32, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x48,
+	0x08, 0x52, 0x03, 0x63, 0x6f, 0x32, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x76, 0x6f, 0x63,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x48, 0x09, 0x52, 0x03, 0x76, 0x6f, 0x63, 0x88, 0x01, 0x01,
+	0x12, 0x15, 0x0a, 0x03, 0x6e, 0x6f, 0x78, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x48, 0x0a, 0x52,
+	0x03, 0x6e, 0x6f, 0x78, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a, 0x0a, 0x6c, 0x75, 0x6d, 0x69, 0x6e,
+	0x6f, 0x73, 0x69, 0x74, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0b, 0x52, 0x0a, 0x6c,
+	0x75, 0x6d, 0x69, 0x6e, 0x6f, 0x73, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d,
+	0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x0c, 0x52, 0x0c, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f,
+	0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0d, 0x52,
+	0x0c, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x41, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x22, 0x0a, 0x0a, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x70, 0x65, 0x61, 0x6b, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x0e, 0x52, 0x09, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x50, 0x65, 0x61,
+	0x6b, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x05, 0x48, 0x0f, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x65, 0x88, 0x01, 0x01, 0x12, 0x19, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x11,
+	0x20, 0x01, 0x28, 0x05, 0x48, 0x10, 0x52, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x88, 0x01, 0x01,
+	0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x48, 0x11, 0x52,
+	0x03, 0x6d, 0x61, 0x63, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69, 0x18,
+	0x13, 0x20, 0x01, 0x28, 0x11, 0x48, 0x12, 0x52, 0x04, 0x72, 0x73, 0x73, 0x69, 0x88, 0x01, 0x01,
+	0x12, 0x1d, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x14, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x13, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x22, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x15, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x14, 0x52, 0x09, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x4e, 0x61, 0x6d, 0x65,
+	0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x75, 0x72, 0x65, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79,
+	0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x42, 0x08, 0x0a,
+	0x06, 0x5f, 0x70, 0x6d, 0x31, 0x5f, 0x30, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x70, 0x6d, 0x32, 0x5f,
+	0x35, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x70, 0x6d, 0x34, 0x5f, 0x30, 0x42, 0x09, 0x0a, 0x07, 0x5f,
+	0x70, 0x6d, 0x31, 0x30, 0x5f, 0x30, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x63, 0x6f, 0x32, 0x42, 0x06,
+	0x0a, 0x04, 0x5f, 0x76, 0x6f, 0x63, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6e, 0x6f, 0x78, 0x42, 0x0d,
+	0x0a, 0x0b, 0x5f, 0x6c, 0x75, 0x6d, 0x69, 0x6e, 0x6f, 0x73, 0x69, 0x74, 0x79, 0x42, 0x10, 0x0a,
+	0x0e, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x42,
+	0x10, 0x0a, 0x0e, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67,
+	0x65, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x73, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x70, 0x65, 0x61, 0x6b,
+	0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x42, 0x08, 0x0a,
+	0x06, 0x5f, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61, 0x63, 0x42,
+	0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x22, 0xa9, 0x01, 0x0a, 0x09, 0x52, 0x65, 0x63, 0x65, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x75, 0x75, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x75,
+	0x69, 0x64, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x73, 0x73, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x11,
+	0x48, 0x00, 0x52, 0x04, 0x72, 0x73, 0x73, 0x69, 0x88, 0x01, 0x01, 0x12, 0x3b, 0x0a, 0x0b, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x6f,
+	0x6e, 0x67, 0x65, 0x73, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x73, 0x74, 0x72,
+	0x6f, 0x6e, 0x67, 0x65, 0x73, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x22,
+	0x8f, 0x05, 0x0a, 0x07, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x20, 0x0a, 0x09, 0x64,
+	0x65, 0x77, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00,
+	0x52, 0x08, 0x64, 0x65, 0x77, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x30, 0x0a,
+	0x11, 0x61, 0x62, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x65, 0x5f, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69,
+	0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x10, 0x61, 0x62, 0x73, 0x6f,
+	0x6c, 0x75, 0x74, 0x65, 0x48, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12,
+	0x43, 0x0a, 0x1b, 0x65, 0x71, 0x75, 0x69, 0x6c, 0x69, 0x62, 0x72, 0x69, 0x75, 0x6d, 0x5f, 0x76,
+	0x61, 0x70, 0x6f, 0x75, 0x72, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x19, 0x65, 0x71, 0x75, 0x69, 0x6c, 0x69, 0x62, 0x72,
+	0x69, 0x75, 0x6d, 0x56, 0x61, 0x70, 0x6f, 0x75, 0x72, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
+	0x65, 0x88, 0x01, 0x01, 0x12, 0x3b, 0x0a, 0x17, 0x76, 0x61, 0x70, 0x6f, 0x75, 0x72, 0x5f, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x5f, 0x64, 0x65, 0x66, 0x69, 0x63, 0x69, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x01, 0x48, 0x03, 0x52, 0x15, 0x76, 0x61, 0x70, 0x6f, 0x75, 0x72, 0x50,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x44, 0x65, 0x66, 0x69, 0x63, 0x69, 0x74, 0x88, 0x01,
+	0x01, 0x12, 0x3a, 0x0a, 0x16, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x6d, 0x61, 0x67, 0x6e, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x04, 0x52, 0x15, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x4d, 0x61, 0x67, 0x6e, 0x69, 0x74, 0x75, 0x64, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1a, 0x0a,
+	0x06, 0x74, 0x69, 0x6c, 0x74, 0x5f, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x48, 0x05, 0x52,
+	0x05, 0x74, 0x69, 0x6c, 0x74, 0x58, 0x88, 0x01, 0x01, 0x12, 0x1a, 0x0a, 0x06, 0x74, 0x69, 0x6c,
+	0x74, 0x5f, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x48, 0x06, 0x52, 0x05, 0x74, 0x69, 0x6c,
+	0x74, 0x59, 0x88, 0x01, 0x01, 0x12, 0x1a, 0x0a, 0x06, 0x74, 0x69, 0x6c, 0x74, 0x5f, 0x7a, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07, 0x52, 0x05, 0x74, 0x69, 0x6c, 0x74, 0x5a, 0x88, 0x01,
+	0x01, 0x12, 0x32, 0x0a, 0x12, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f, 0x70, 0x65, 0x72,
+	0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x48, 0x08, 0x52,
+	0x11, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61,
+	0x67, 0x65, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a, 0x0c, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
+	0x65, 0x5f, 0x68, 0x70, 0x61, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x09, 0x52, 0x0b, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x48, 0x70, 0x61, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a,
+	0x0a, 0x5f, 0x64, 0x65, 0x77, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x42, 0x14, 0x0a, 0x12, 0x5f,
+	0x61, 0x62, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x65, 0x5f, 0x68, 0x75, 0x6d, 0x69, 0x64, 0x69, 0x74,
+	0x79, 0x42, 0x1e, 0x0a, 0x1c, 0x5f, 0x65, 0x71, 0x75, 0x69, 0x6c, 0x69, 0x62, 0x72, 0x69, 0x75,
+	0x6d, 0x5f, 0x76, 0x61, 0x70, 0x6f, 0x75, 0x72, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
+	0x65, 0x42, 0x1a, 0x0a, 0x18, 0x5f, 0x76, 0x61, 0x70, 0x6f, 0x75, 0x72, 0x5f, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x75, 0x72, 0x65, 0x5f, 0x64, 0x65, 0x66, 0x69, 0x63, 0x69, 0x74, 0x42, 0x19, 0x0a,
+	0x17, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d,
+	0x61, 0x67, 0x6e, 0x69, 0x74, 0x75, 0x64, 0x65, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x74, 0x69, 0x6c,
+	0x74, 0x5f, 0x78, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x74, 0x69, 0x6c, 0x74, 0x5f, 0x79, 0x42, 0x09,
+	0x0a, 0x07, 0x5f, 0x74, 0x69, 0x6c, 0x74, 0x5f, 0x7a, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x62, 0x61,
+	0x74, 0x74, 0x65, 0x72, 0x79, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65,
+	0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x5f, 0x68, 0x70,
+	0x61, 0x22, 0x7a, 0x0a, 0x0e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12,
+	0x15, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x03,
+	0x6d, 0x69, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x78, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x03, 0x6d, 0x61, 0x78, 0x88, 0x01, 0x01, 0x42, 0x06, 0x0a,
+	0x04, 0x5f, 0x6d, 0x69, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61, 0x78, 0x42, 0x5b, 0x0a,
+	0x24, 0x63, 0x6f, 0x6d, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x74, 0x75, 0x68, 0x69,
+	0x73, 0x2e, 0x65, 0x64, 0x67, 0x65, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2e, 0x65,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x50, 0x01, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x54, 0x75, 0x68, 0x69, 0x73, 0x2f, 0x65, 0x64, 0x67, 0x65, 0x2d, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_pkg_events_eventpb_events_proto_rawDescOnce sync.Once
+	file_pkg_events_eventpb_events_proto_rawDescData = file_pkg_events_eventpb_events_proto_rawDesc
+)
+
+func file_pkg_events_eventpb_events_proto_rawDescGZIP() []byte {
+	file_pkg_events_eventpb_events_proto_rawDescOnce.Do(func() {
+		file_pkg_events_eventpb_events_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_events_eventpb_events_proto_rawDescData)
+	})
+	return file_pkg_events_eventpb_events_proto_rawDescData
+}
+
+var file_pkg_events_eventpb_events_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_pkg_events_eventpb_events_proto_goTypes = []interface{}{
+	(*RuuviKafkaEvent)(nil),               // 0: edgereceiver.events.RuuviKafkaEvent
+	(*Envelope)(nil),                      // 1: edgereceiver.events.Envelope
+	(*MeasurementPayload)(nil),            // 2: edgereceiver.events.MeasurementPayload
+	(*Measurement)(nil),                   // 3: edgereceiver.events.Measurement
+	(*Acceleration)(nil),                  // 4: edgereceiver.events.Acceleration
+	(*RAWv1Measurement)(nil),              // 5: edgereceiver.events.RAWv1Measurement
+	(*RAWv2Measurement)(nil),              // 6: edgereceiver.events.RAWv2Measurement
+	(*AirQualityMeasurement)(nil),         // 7: edgereceiver.events.AirQualityMeasurement
+	(*ExtendedAirQualityMeasurement)(nil), // 8: edgereceiver.events.ExtendedAirQualityMeasurement
+	(*Reception)(nil),                     // 9: edgereceiver.events.Reception
+	(*Derived)(nil),                       // 10: edgereceiver.events.Derived
+	(*RangeViolation)(nil),                // 11: edgereceiver.events.RangeViolation
+	(*timestamppb.Timestamp)(nil),         // 12: google.protobuf.Timestamp
+}
+var file_pkg_events_eventpb_events_proto_depIdxs = []int32{
+	3,  // 0: edgereceiver.events.RuuviKafkaEvent.data:type_name -> edgereceiver.events.Measurement
+	12, // 1: edgereceiver.events.RuuviKafkaEvent.observed_at:type_name -> google.protobuf.Timestamp
+	12, // 2: edgereceiver.events.RuuviKafkaEvent.received_at:type_name -> google.protobuf.Timestamp
+	9,  // 3: edgereceiver.events.RuuviKafkaEvent.receptions:type_name -> edgereceiver.events.Reception
+	10, // 4: edgereceiver.events.RuuviKafkaEvent.derived:type_name -> edgereceiver.events.Derived
+	11, // 5: edgereceiver.events.RuuviKafkaEvent.range_violations:type_name -> edgereceiver.events.RangeViolation
+	12, // 6: edgereceiver.events.Envelope.produced_at:type_name -> google.protobuf.Timestamp
+	2,  // 7: edgereceiver.events.Envelope.payload:type_name -> edgereceiver.events.MeasurementPayload
+	3,  // 8: edgereceiver.events.MeasurementPayload.data:type_name -> edgereceiver.events.Measurement
+	12, // 9: edgereceiver.events.MeasurementPayload.observed_at:type_name -> google.protobuf.Timestamp
+	12, // 10: edgereceiver.events.MeasurementPayload.received_at:type_name -> google.protobuf.Timestamp
+	9,  // 11: edgereceiver.events.MeasurementPayload.receptions:type_name -> edgereceiver.events.Reception
+	10, // 12: edgereceiver.events.MeasurementPayload.derived:type_name -> edgereceiver.events.Derived
+	11, // 13: edgereceiver.events.MeasurementPayload.range_violations:type_name -> edgereceiver.events.RangeViolation
+	5,  // 14: edgereceiver.events.Measurement.rawv1:type_name -> edgereceiver.events.RAWv1Measurement
+	6,  // 15: edgereceiver.events.Measurement.rawv2:type_name -> edgereceiver.events.RAWv2Measurement
+	7,  // 16: edgereceiver.events.Measurement.air_quality:type_name -> edgereceiver.events.AirQualityMeasurement
+	8,  // 17: edgereceiver.events.Measurement.extended_air_quality:type_name -> edgereceiver.events.ExtendedAirQualityMeasurement
+	4,  // 18: edgereceiver.events.RAWv1Measurement.acceleration:type_name -> edgereceiver.events.Acceleration
+	4,  // 19: edgereceiver.events.RAWv2Measurement.acceleration:type_name -> edgereceiver.events.Acceleration
+	12, // 20: edgereceiver.events.Reception.received_at:type_name -> google.protobuf.Timestamp
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_pkg_events_eventpb_events_proto_init() }
+func file_pkg_events_eventpb_events_proto_init() {
+	if File_pkg_events_eventpb_events_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_events_eventpb_events_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RuuviKafkaEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MeasurementPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Measurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Acceleration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RAWv1Measurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RAWv2Measurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AirQualityMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendedAirQualityMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reception); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Derived); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_events_eventpb_events_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RangeViolation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pkg_events_eventpb_events_proto_msgTypes[3].OneofWrappers = []interface{}{
+		(*Measurement_Rawv1)(nil),
+		(*Measurement_Rawv2)(nil),
+		(*Measurement_AirQuality)(nil),
+		(*Measurement_ExtendedAirQuality)(nil),
+	}
+	file_pkg_events_eventpb_events_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[6].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[8].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[9].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[10].OneofWrappers = []interface{}{}
+	file_pkg_events_eventpb_events_proto_msgTypes[11].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_events_eventpb_events_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pkg_events_eventpb_events_proto_goTypes,
+		DependencyIndexes: file_pkg_events_eventpb_events_proto_depIdxs,
+		MessageInfos:      file_pkg_events_eventpb_events_proto_msgTypes,
+	}.Build()
+	File_pkg_events_eventpb_events_proto = out.File
+	file_pkg_events_eventpb_events_proto_rawDesc = nil
+	file_pkg_events_eventpb_events_proto_goTypes = nil
+	file_pkg_events_eventpb_events_proto_depIdxs = nil
+}
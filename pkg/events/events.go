@@ -1,23 +1,70 @@
 package events
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
+
+// SchemaVersion is the version of the RuuviKafkaEvent schema. It is attached
+// to every message produced in the legacy format.
+const SchemaVersion = "1"
 
 type RuuviEventTypes string
 
 const (
-	NewMeasurement RuuviEventTypes = "new_measurement"
+	NewMeasurement   RuuviEventTypes = "new_measurement"
+	RawAdvertisement RuuviEventTypes = "raw_advertisement"
 )
 
 type RuuviEvent struct {
 	Type       RuuviEventTypes `json:"type"`
 	Data       json.RawMessage `json:"data"`
 	SourceUuid string          `json:"source_uuid"`
+	// ObservedAt is the time the gateway received the measurement, if it
+	// knows it, e.g. for buffered measurements uploaded later.
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
 }
 
 type RuuviKafkaEvent struct {
+	// EventID identifies the event in its envelope. The flat schema does not
+	// carry it.
+	EventID    string          `json:"-"`
 	Type       RuuviEventTypes `json:"type"`
 	Data       interface{}     `json:"data"`
 	SourceUuid string          `json:"source_uuid"`
+	// ObservedAt is the time the gateway received the measurement, if it
+	// was supplied.
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+	// ReceivedAt is the time edge-receiver received the measurement.
+	ReceivedAt time.Time `json:"received_at"`
+	// Receptions lists the gateways that received the measurement when
+	// receptions are aggregated.
+	Receptions []Reception `json:"receptions,omitempty"`
+	// Derived holds the quantities computed from the measurement when
+	// enrichment is enabled.
+	Derived *Derived `json:"derived,omitempty"`
+	// RangeViolations lists the readings outside their bounds when such
+	// measurements are flagged rather than rejected.
+	RangeViolations []RangeViolation `json:"range_violations,omitempty"`
+}
+
+// Time returns the time the measurement was made: the time it was observed
+// by the gateway, or the time it was received if that is not known.
+func (e *RuuviKafkaEvent) Time() time.Time {
+	if e.ObservedAt != nil {
+		return *e.ObservedAt
+	}
+	return e.ReceivedAt
+}
+
+// Reception is a copy of a measurement received by a gateway. The gateway
+// that received the measurement with the strongest signal is marked, and its
+// copy is the one in the event.
+type Reception struct {
+	SourceUuid string    `json:"source_uuid"`
+	RSSI       *int      `json:"RSSI"`
+	ReceivedAt time.Time `json:"received_at"`
+	Strongest  bool      `json:"strongest,omitempty"`
 }
 
 type NewMeasurementData struct {
@@ -60,8 +107,10 @@ type NewMeasurementDataNoPtr struct {
 	LocalName string `json:"LocalName"`
 }
 
-// IsValid checks if all fields in the NewMeasurementData struct are not nil.
-// It returns true if all fields are not nil, and false otherwise.
+// IsValid checks if the required fields in the NewMeasurementData struct are
+// not nil. It returns true if they are all set, and false otherwise. Readings
+// that data format 5 can report as not available are not required, see
+// MissingFields.
 //
 // This method is useful for validating that a NewMeasurementData object
 // has all required fields before using it. For example, it can be used
@@ -69,23 +118,8 @@ type NewMeasurementDataNoPtr struct {
 // in the JSON data.
 //
 // Note that this method does not check if the fields have valid values,
-// it only checks if they are not nil. Additional validation may be needed
-// depending on the use case.
+// it only checks if they are not nil. Use a RangeValidator to check the
+// values.
 func (n *NewMeasurementData) IsValid() bool {
-	return n.DataFormat != nil &&
-		n.Temperature != nil &&
-		n.Humidity != nil &&
-		n.Pressure != nil &&
-		n.Acceleration != nil &&
-		n.Acceleration.X != nil &&
-		n.Acceleration.Y != nil &&
-		n.Acceleration.Z != nil &&
-		n.Battery != nil &&
-		n.TXPower != nil &&
-		n.Movement != nil &&
-		n.Sequence != nil &&
-		n.MAC != nil &&
-		n.RSSI != nil &&
-		n.Address != nil &&
-		n.LocalName != nil
+	return len(MissingFields(n)) == 0
 }
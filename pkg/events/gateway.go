@@ -0,0 +1,143 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GatewayPayload is the body of the HTTP POST sent by the official Ruuvi
+// Gateway to a custom HTTP server.
+// See https://docs.ruuvi.com/gw-data-formats/http-time-stamped-data-from-bluetooth-sensors
+type GatewayPayload struct {
+	Data *GatewayData `json:"data"`
+}
+
+type GatewayData struct {
+	Coordinates string                `json:"coordinates"`
+	Timestamp   UnixTimestamp         `json:"timestamp"`
+	GatewayMAC  string                `json:"gw_mac"`
+	Tags        map[string]GatewayTag `json:"tags"`
+}
+
+// GatewayTag is the latest advertisement the gateway has received from a
+// single tag.
+type GatewayTag struct {
+	RSSI      *int          `json:"rssi"`
+	Timestamp UnixTimestamp `json:"timestamp"`
+	Data      *string       `json:"data"`
+}
+
+// UnixTimestamp is a timestamp in seconds since the Unix epoch. Depending on
+// the firmware version the gateway sends it either as a number or as a
+// string, so both are accepted.
+type UnixTimestamp int64
+
+func (u *UnixTimestamp) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s == "" {
+			*u = 0
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		*u = UnixTimestamp(i)
+		return nil
+	}
+
+	var i int64
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	*u = UnixTimestamp(i)
+	return nil
+}
+
+// Time returns the timestamp as a time, or nil if it is not set.
+func (u UnixTimestamp) Time() *time.Time {
+	if u <= 0 {
+		return nil
+	}
+	t := time.Unix(int64(u), 0).UTC()
+	return &t
+}
+
+// IsValid checks that the payload identifies the gateway and contains a tag
+// map. The individual tags are validated when their advertisements are
+// decoded.
+func (g *GatewayPayload) IsValid() bool {
+	return g.Data != nil &&
+		g.Data.GatewayMAC != "" &&
+		g.Data.Tags != nil
+}
+
+// MissingFields returns the JSON paths of the fields that IsValid requires
+// and the payload is missing.
+func (g *GatewayPayload) MissingFields() []string {
+	if g.Data == nil {
+		return []string{"data"}
+	}
+
+	var missing []string
+	if g.Data.GatewayMAC == "" {
+		missing = append(missing, "data.gw_mac")
+	}
+	if g.Data.Tags == nil {
+		missing = append(missing, "data.tags")
+	}
+	return missing
+}
+
+// TagMACs returns the MACs of the tags in the payload in order.
+func (g *GatewayPayload) TagMACs() []string {
+	macs := make([]string, 0, len(g.Data.Tags))
+	for mac := range g.Data.Tags {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// Events explodes the tag map into one raw_advertisement RuuviEvent per tag,
+// ordered by tag MAC. The gateway MAC is used as the source of every event,
+// and the time the gateway last heard the tag, or else the time of the
+// payload, as the time the event was observed.
+func (g *GatewayPayload) Events() ([]RuuviEvent, error) {
+	if !g.IsValid() {
+		return nil, errors.New("invalid gateway payload")
+	}
+
+	macs := g.TagMACs()
+	result := make([]RuuviEvent, 0, len(macs))
+	for _, mac := range macs {
+		tag := g.Data.Tags[mac]
+
+		data, err := json.Marshal(RawAdvertisementData{
+			Data: tag.Data,
+			MAC:  stringPtr(mac),
+			RSSI: tag.RSSI,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		observedAt := tag.Timestamp.Time()
+		if observedAt == nil {
+			observedAt = g.Data.Timestamp.Time()
+		}
+
+		result = append(result, RuuviEvent{
+			Type:       RawAdvertisement,
+			Data:       data,
+			SourceUuid: g.Data.GatewayMAC,
+			ObservedAt: observedAt,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestGatewayPayload_Events(t *testing.T) {
+	body := `{"data":{"coordinates":"","timestamp":"1574082635","gw_mac":"AA:BB:CC:DD:EE:FF","tags":{
+		"CB:B8:33:4C:88:4F":{"rssi":-65,"timestamp":1574082630,"data":"0201061BFF99040512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"},
+		"C8:25:2D:8E:9C:2C":{"rssi":-51,"timestamp":"1574082635","data":"02010611FF990403291A1ECE1EFC18F94202CA0B53"}}}}`
+
+	var payload events.GatewayPayload
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if !payload.IsValid() {
+		t.Fatal("GatewayPayload.IsValid() = false, want true")
+	}
+
+	if payload.Data.Timestamp != 1574082635 {
+		t.Errorf("Timestamp = %v, want %v", payload.Data.Timestamp, 1574082635)
+	}
+
+	got, err := payload.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantMACs := []string{"C8:25:2D:8E:9C:2C", "CB:B8:33:4C:88:4F"}
+	wantObservedAt := []int64{1574082635, 1574082630}
+	if len(got) != len(wantMACs) {
+		t.Fatalf("GatewayPayload.Events() returned %d events, want %d", len(got), len(wantMACs))
+	}
+
+	for i, event := range got {
+		if event.Type != events.RawAdvertisement {
+			t.Errorf("event %d type = %v, want %v", i, event.Type, events.RawAdvertisement)
+		}
+		if event.SourceUuid != "AA:BB:CC:DD:EE:FF" {
+			t.Errorf("event %d source = %v, want gateway MAC", i, event.SourceUuid)
+		}
+		if event.ObservedAt == nil || event.ObservedAt.Unix() != wantObservedAt[i] {
+			t.Errorf("event %d observed at = %v, want %v", i, event.ObservedAt, wantObservedAt[i])
+		}
+
+		var raw events.RawAdvertisementData
+		if err := json.Unmarshal(event.Data, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if !raw.IsValid() || *raw.MAC != wantMACs[i] {
+			t.Errorf("event %d data = %+v, want MAC %v", i, raw, wantMACs[i])
+		}
+		if _, err := raw.Decode(); err != nil {
+			t.Errorf("event %d could not be decoded: %v", i, err)
+		}
+	}
+}
+
+func TestGatewayPayload_IsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "Missing data", body: `{}`, want: false},
+		{name: "Missing gateway MAC", body: `{"data":{"tags":{}}}`, want: false},
+		{name: "Missing tags", body: `{"data":{"gw_mac":"AA:BB:CC:DD:EE:FF"}}`, want: false},
+		{name: "No tags", body: `{"data":{"gw_mac":"AA:BB:CC:DD:EE:FF","tags":{}}}`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload events.GatewayPayload
+			if err := json.Unmarshal([]byte(tt.body), &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := payload.IsValid(); got != tt.want {
+				t.Errorf("GatewayPayload.IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package events
+
+import "math"
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// intValue returns the value of p, or zero if it is nil.
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// roundTo rounds f to the given number of decimals. It is used to get rid of
+// floating point noise introduced when scaling raw integer readings.
+func roundTo(f float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(f*scale) / scale
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MessageKey returns the Kafka message key of the event. The key is the value
+// of the given field of the event data, matched by its JSON name, or of the
+// top-level field with that JSON name (e.g. "source_uuid"). If the field is
+// missing or null, the source UUID is used instead.
+func (e *RuuviKafkaEvent) MessageKey(field string) string {
+	if value, ok := fieldValue(e.Data, field); ok {
+		return value
+	}
+
+	if value, ok := fieldValue(*e, field); ok {
+		return value
+	}
+
+	return e.SourceUuid
+}
+
+// TagMAC returns the MAC address of the tag that made the measurement, if
+// it is known.
+func TagMAC(data MeasurementData) (string, bool) {
+	return fieldValue(data, "MAC")
+}
+
+// fieldValue returns the value of the struct field with the given JSON name
+// formatted as a string. It reports false if there is no such field, the
+// field is nil or it is not a scalar.
+func fieldValue(v interface{}, field string) (string, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+		if name != field {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				return "", false
+			}
+			fv = fv.Elem()
+		}
+
+		switch fv.Kind() {
+		case reflect.String:
+			if fv.String() == "" {
+				return "", false
+			}
+			return fv.String(), true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+			return fmt.Sprint(fv.Interface()), true
+		default:
+			return "", false
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,42 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestRuuviKafkaEvent_MessageKey(t *testing.T) {
+	event := events.RuuviKafkaEvent{
+		Type:       events.NewMeasurement,
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		Data: &events.NewMeasurementData{
+			DataFormat: intPtr(5),
+			MAC:        stringPtr("E8:D3:AD:C4:6E:18"),
+			LocalName:  stringPtr(""),
+		},
+	}
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "MAC", want: "E8:D3:AD:C4:6E:18"},
+		{field: "DataFormat", want: "5"},
+		{field: "type", want: "new_measurement"},
+		{field: "source_uuid", want: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+		// Null, empty and unknown fields fall back to the source UUID
+		{field: "Address", want: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+		{field: "LocalName", want: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+		{field: "Unknown", want: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+		{field: "Acceleration", want: "7d01818b-0332-4adf-99c1-13f833e59c6b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := event.MessageKey(tt.field); got != tt.want {
+				t.Errorf("RuuviKafkaEvent.MessageKey(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
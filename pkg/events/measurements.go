@@ -0,0 +1,236 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Ruuvi data formats supported by the receiver.
+// See https://docs.ruuvi.com/communication/bluetooth-advertisements
+const (
+	DataFormatRAWv1 = 3
+	DataFormatRAWv2 = 5
+	DataFormat6     = 6
+	DataFormatE1    = 0xE1
+)
+
+var ErrMissingDataFormat = errors.New("data format is missing")
+
+// MeasurementData is implemented by the data models of every supported Ruuvi
+// data format. The concrete type determines the schema that is produced to
+// Kafka.
+type MeasurementData interface {
+	// Format returns the Ruuvi data format of the measurement.
+	Format() int
+	// IsValid checks that all fields required by the data format are present.
+	IsValid() bool
+}
+
+// RAWv1MeasurementData is a measurement in data format 3 (RAWv1). Older tags
+// broadcasting this format do not report TX power, movement or a sequence
+// number.
+type RAWv1MeasurementData struct {
+	DataFormat   *int     `json:"DataFormat"`
+	Temperature  *float64 `json:"Temperature"`
+	Humidity     *float64 `json:"Humidity"`
+	Pressure     *int     `json:"Pressure"`
+	Acceleration *struct {
+		X *int `json:"X"`
+		Y *int `json:"Y"`
+		Z *int `json:"Z"`
+	} `json:"Acceleration"`
+	Battery   *int    `json:"Battery"`
+	MAC       *string `json:"MAC"`
+	RSSI      *int    `json:"RSSI"`
+	Address   *string `json:"Address"`
+	LocalName *string `json:"LocalName"`
+}
+
+// AirQualityMeasurementData is a measurement in data format 6, broadcast by
+// Ruuvi Air devices in Bluetooth 4 compatible mode.
+type AirQualityMeasurementData struct {
+	DataFormat  *int     `json:"DataFormat"`
+	Temperature *float64 `json:"Temperature"`
+	Humidity    *float64 `json:"Humidity"`
+	Pressure    *int     `json:"Pressure"`
+	PM2_5       *float64 `json:"PM2_5"`
+	CO2         *int     `json:"CO2"`
+	VOC         *int     `json:"VOC"`
+	NOx         *int     `json:"NOx"`
+	Luminosity  *float64 `json:"Luminosity"`
+	Sequence    *int     `json:"Sequence"`
+	Flags       *int     `json:"Flags"`
+	MAC         *string  `json:"MAC"`
+	RSSI        *int     `json:"RSSI"`
+	Address     *string  `json:"Address"`
+	LocalName   *string  `json:"LocalName"`
+}
+
+// ExtendedAirQualityMeasurementData is a measurement in the extended data
+// format E1, broadcast by Ruuvi Air devices using Bluetooth 5 extended
+// advertisements.
+type ExtendedAirQualityMeasurementData struct {
+	DataFormat   *int     `json:"DataFormat"`
+	Temperature  *float64 `json:"Temperature"`
+	Humidity     *float64 `json:"Humidity"`
+	Pressure     *int     `json:"Pressure"`
+	PM1_0        *float64 `json:"PM1_0"`
+	PM2_5        *float64 `json:"PM2_5"`
+	PM4_0        *float64 `json:"PM4_0"`
+	PM10_0       *float64 `json:"PM10_0"`
+	CO2          *int     `json:"CO2"`
+	VOC          *int     `json:"VOC"`
+	NOx          *int     `json:"NOx"`
+	Luminosity   *float64 `json:"Luminosity"`
+	SoundInstant *float64 `json:"SoundInstant"`
+	SoundAverage *float64 `json:"SoundAverage"`
+	SoundPeak    *float64 `json:"SoundPeak"`
+	Sequence     *int     `json:"Sequence"`
+	Flags        *int     `json:"Flags"`
+	MAC          *string  `json:"MAC"`
+	RSSI         *int     `json:"RSSI"`
+	Address      *string  `json:"Address"`
+	LocalName    *string  `json:"LocalName"`
+}
+
+// NewMeasurementDataForFormat returns an empty data model for the given Ruuvi
+// data format, ready to be unmarshalled into.
+func NewMeasurementDataForFormat(format int) (MeasurementData, error) {
+	switch format {
+	case DataFormatRAWv1:
+		return &RAWv1MeasurementData{}, nil
+	case DataFormatRAWv2:
+		return &NewMeasurementData{}, nil
+	case DataFormat6:
+		return &AirQualityMeasurementData{}, nil
+	case DataFormatE1:
+		return &ExtendedAirQualityMeasurementData{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedDataFormat, format)
+	}
+}
+
+// UnmarshalMeasurement unmarshals the data of a new_measurement event into the
+// data model matching its DataFormat field. The result still has to be
+// checked with IsValid before use.
+func UnmarshalMeasurement(raw json.RawMessage) (MeasurementData, error) {
+	var header struct {
+		DataFormat *int `json:"DataFormat"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+
+	if header.DataFormat == nil {
+		return nil, ErrMissingDataFormat
+	}
+
+	data, err := NewMeasurementDataForFormat(*header.DataFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (n *NewMeasurementData) Format() int {
+	return DataFormatRAWv2
+}
+
+func (r *RAWv1MeasurementData) Format() int {
+	return DataFormatRAWv1
+}
+
+// IsValid checks if all fields in the RAWv1MeasurementData struct are not nil.
+func (r *RAWv1MeasurementData) IsValid() bool {
+	return len(MissingFields(r)) == 0
+}
+
+func (a *AirQualityMeasurementData) Format() int {
+	return DataFormat6
+}
+
+// IsValid checks that the readings that are always available and the tag
+// identity are present. The other readings are optional, see MissingFields.
+func (a *AirQualityMeasurementData) IsValid() bool {
+	return len(MissingFields(a)) == 0
+}
+
+func (e *ExtendedAirQualityMeasurementData) Format() int {
+	return DataFormatE1
+}
+
+// IsValid checks that the readings that are always available and the tag
+// identity are present. The other readings are optional, see MissingFields.
+func (e *ExtendedAirQualityMeasurementData) IsValid() bool {
+	return len(MissingFields(e)) == 0
+}
+
+// optionalFields are the fields that IsValid does not require, per data
+// format, in addition to the readings that the data format can report as not
+// available.
+var optionalFields = map[int]map[string]bool{
+	DataFormat6:  {"VOC": true, "NOx": true, "Luminosity": true, "Flags": true},
+	DataFormatE1: {"VOC": true, "NOx": true, "Luminosity": true, "SoundInstant": true, "SoundAverage": true, "SoundPeak": true, "Flags": true},
+}
+
+// MissingFields returns the JSON names of the fields required by the data
+// format that are missing from data, with nested fields joined by dots, e.g.
+// "Acceleration.X". It is empty if and only if data is valid.
+//
+// Readings that the data format can report as not available are not
+// required, so that a measurement is valid whether such a reading was left
+// out, sent as null or cleared by ClearUnavailable. A nested struct is not
+// required if none of its fields are.
+func MissingFields(data MeasurementData) []string {
+	format := data.Format()
+	optional := func(name string) bool {
+		_, unavailable := unavailableValues[format][name]
+		return unavailable || optionalFields[format][name]
+	}
+	return nilFields(reflect.ValueOf(data).Elem(), "", optional)
+}
+
+// nilFields returns the names of the nil pointer fields of the struct v and
+// of the structs it points to, except the optional ones.
+func nilFields(v reflect.Value, prefix string, optional func(name string) bool) []string {
+	var names []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		name := prefix + jsonName(t.Field(i))
+		if field.Kind() != reflect.Ptr || optional(name) {
+			continue
+		}
+
+		elem := field.Type().Elem()
+		switch {
+		case field.IsNil() && elem.Kind() == reflect.Struct:
+			if !allOptional(elem, name+".", optional) {
+				names = append(names, name)
+			}
+		case field.IsNil():
+			names = append(names, name)
+		case elem.Kind() == reflect.Struct:
+			names = append(names, nilFields(field.Elem(), name+".", optional)...)
+		}
+	}
+	return names
+}
+
+// allOptional reports whether every pointer field of the struct type t is
+// optional.
+func allOptional(t reflect.Type, prefix string, optional func(name string) bool) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Ptr && !optional(prefix+jsonName(t.Field(i))) {
+			return false
+		}
+	}
+	return true
+}
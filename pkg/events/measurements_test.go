@@ -0,0 +1,112 @@
+package events_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestUnmarshalMeasurement(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantType  events.MeasurementData
+		wantValid bool
+		wantErr   error
+	}{
+		{
+			name:      "RAWv1 without format 5 fields",
+			data:      `{"DataFormat":3,"Temperature":26.3,"Humidity":20.5,"Pressure":102766,"Acceleration":{"X":-1000,"Y":-1726,"Z":714},"Battery":2899,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}`,
+			wantType:  &events.RAWv1MeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "RAWv2",
+			data:      `{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}`,
+			wantType:  &events.NewMeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "RAWv2 without movement and acceleration readings",
+			data:      `{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":null,"Battery":2857,"TXPower":4,"Sequence":6256,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}`,
+			wantType:  &events.NewMeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "RAWv2 missing MAC",
+			data:      `{"DataFormat":5,"Temperature":22.34,"Humidity":42.975,"Pressure":97465,"Acceleration":{"X":-8,"Y":-20,"Z":1056},"Battery":2857,"TXPower":4,"Movement":75,"Sequence":6256,"RSSI":-75,"Address":"E8:D3:AD:C4:6E:18","LocalName":""}`,
+			wantType:  &events.NewMeasurementData{},
+			wantValid: false,
+		},
+		{
+			name:      "Format 6 without VOC and NOx",
+			data:      `{"DataFormat":6,"Temperature":21.5,"Humidity":40.1,"Pressure":100100,"PM2_5":3.4,"CO2":612,"Luminosity":120.5,"Sequence":12,"Flags":0,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-60,"Address":"E8:D3:AD:C4:6E:18","LocalName":"Ruuvi Air"}`,
+			wantType:  &events.AirQualityMeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "E1",
+			data:      `{"DataFormat":225,"Temperature":21.5,"Humidity":40.1,"Pressure":100100,"PM1_0":1.2,"PM2_5":3.4,"PM4_0":4.1,"PM10_0":5.0,"CO2":612,"VOC":101,"NOx":1,"Luminosity":120.5,"SoundInstant":40.2,"SoundAverage":38.4,"SoundPeak":55.0,"Sequence":123456,"Flags":0,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-60,"Address":"E8:D3:AD:C4:6E:18","LocalName":"Ruuvi Air"}`,
+			wantType:  &events.ExtendedAirQualityMeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "E1 without CO2 reading",
+			data:      `{"DataFormat":225,"Temperature":21.5,"Humidity":40.1,"Pressure":100100,"PM1_0":1.2,"PM2_5":3.4,"PM4_0":4.1,"PM10_0":5.0,"Sequence":123456,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-60,"Address":"E8:D3:AD:C4:6E:18","LocalName":"Ruuvi Air"}`,
+			wantType:  &events.ExtendedAirQualityMeasurementData{},
+			wantValid: true,
+		},
+		{
+			name:      "Format 6 missing sequence",
+			data:      `{"DataFormat":6,"Temperature":21.5,"Humidity":40.1,"Pressure":100100,"PM2_5":3.4,"CO2":612,"MAC":"E8:D3:AD:C4:6E:18","RSSI":-60,"Address":"E8:D3:AD:C4:6E:18","LocalName":"Ruuvi Air"}`,
+			wantType:  &events.AirQualityMeasurementData{},
+			wantValid: false,
+		},
+		{
+			name:      "E1 missing RSSI",
+			data:      `{"DataFormat":225,"Temperature":21.5,"Humidity":40.1,"Pressure":100100,"PM1_0":1.2,"PM2_5":3.4,"PM4_0":4.1,"PM10_0":5.0,"CO2":612,"Sequence":123456,"MAC":"E8:D3:AD:C4:6E:18","Address":"E8:D3:AD:C4:6E:18","LocalName":"Ruuvi Air"}`,
+			wantType:  &events.ExtendedAirQualityMeasurementData{},
+			wantValid: false,
+		},
+		{
+			name:    "Unsupported data format",
+			data:    `{"DataFormat":4}`,
+			wantErr: events.ErrUnsupportedDataFormat,
+		},
+		{
+			name:    "Missing data format",
+			data:    `{"Temperature":21.5}`,
+			wantErr: events.ErrMissingDataFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := events.UnmarshalMeasurement([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UnmarshalMeasurement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.wantType) {
+				t.Errorf("UnmarshalMeasurement() type = %T, want %T", got, tt.wantType)
+			}
+
+			if got.Format() != tt.wantType.Format() {
+				t.Errorf("UnmarshalMeasurement() format = %v, want %v", got.Format(), tt.wantType.Format())
+			}
+
+			if valid := got.IsValid(); valid != tt.wantValid {
+				t.Errorf("IsValid() = %v, want %v", valid, tt.wantValid)
+			}
+
+			if missing := events.MissingFields(got); (len(missing) == 0) != tt.wantValid {
+				t.Errorf("MissingFields() = %v, want valid %v", missing, tt.wantValid)
+			}
+		})
+	}
+}
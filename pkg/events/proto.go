@@ -0,0 +1,224 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events/eventpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MarshalProto encodes the event in the given format in protobuf, as the
+// messages in eventpb/events.proto. Both shapes in the same record cannot be
+// expressed in protobuf.
+func (e *RuuviKafkaEvent) MarshalProto(format EventFormat) ([]byte, error) {
+	var message proto.Message
+	var err error
+
+	switch format {
+	case LegacyFormat:
+		message, err = e.Proto()
+	case EnvelopeFormat:
+		envelope := e.Envelope(time.Now().UTC())
+		message, err = envelope.Proto()
+	default:
+		return nil, fmt.Errorf("event format %q is not supported in protobuf", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+// Proto returns the event as a protobuf message.
+func (e *RuuviKafkaEvent) Proto() (*eventpb.RuuviKafkaEvent, error) {
+	data, err := measurementProto(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventpb.RuuviKafkaEvent{
+		Type:            string(e.Type),
+		Data:            data,
+		SourceUuid:      e.SourceUuid,
+		ObservedAt:      timestampProto(e.ObservedAt),
+		ReceivedAt:      timestamppb.New(e.ReceivedAt),
+		Receptions:      receptionsProto(e.Receptions),
+		Derived:         derivedProto(e.Derived),
+		RangeViolations: rangeViolationsProto(e.RangeViolations),
+	}, nil
+}
+
+// Proto returns the envelope as a protobuf message.
+func (e *Envelope) Proto() (*eventpb.Envelope, error) {
+	data, err := measurementProto(e.Payload.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventpb.Envelope{
+		EventId:       e.EventID,
+		SchemaVersion: e.SchemaVersion,
+		EventType:     string(e.EventType),
+		ProducedAt:    timestamppb.New(e.ProducedAt),
+		Source:        e.Source,
+		Payload: &eventpb.MeasurementPayload{
+			DataFormat:      int32(e.Payload.DataFormat),
+			Data:            data,
+			ObservedAt:      timestampProto(e.Payload.ObservedAt),
+			ReceivedAt:      timestamppb.New(e.Payload.ReceivedAt),
+			Receptions:      receptionsProto(e.Payload.Receptions),
+			Derived:         derivedProto(e.Payload.Derived),
+			RangeViolations: rangeViolationsProto(e.Payload.RangeViolations),
+		},
+	}, nil
+}
+
+func measurementProto(data interface{}) (*eventpb.Measurement, error) {
+	switch d := data.(type) {
+	case *RAWv1MeasurementData:
+		return &eventpb.Measurement{Data: &eventpb.Measurement_Rawv1{Rawv1: &eventpb.RAWv1Measurement{
+			DataFormat:   int32Proto(d.DataFormat),
+			Temperature:  d.Temperature,
+			Humidity:     d.Humidity,
+			Pressure:     int32Proto(d.Pressure),
+			Acceleration: accelerationProto(d.Acceleration),
+			Battery:      int32Proto(d.Battery),
+			Mac:          d.MAC,
+			Rssi:         int32Proto(d.RSSI),
+			Address:      d.Address,
+			LocalName:    d.LocalName,
+		}}}, nil
+	case *NewMeasurementData:
+		return &eventpb.Measurement{Data: &eventpb.Measurement_Rawv2{Rawv2: &eventpb.RAWv2Measurement{
+			DataFormat:   int32Proto(d.DataFormat),
+			Temperature:  d.Temperature,
+			Humidity:     d.Humidity,
+			Pressure:     int32Proto(d.Pressure),
+			Acceleration: accelerationProto(d.Acceleration),
+			Battery:      int32Proto(d.Battery),
+			TxPower:      int32Proto(d.TXPower),
+			Movement:     int32Proto(d.Movement),
+			Sequence:     int32Proto(d.Sequence),
+			Mac:          d.MAC,
+			Rssi:         int32Proto(d.RSSI),
+			Address:      d.Address,
+			LocalName:    d.LocalName,
+		}}}, nil
+	case *AirQualityMeasurementData:
+		return &eventpb.Measurement{Data: &eventpb.Measurement_AirQuality{AirQuality: &eventpb.AirQualityMeasurement{
+			DataFormat:  int32Proto(d.DataFormat),
+			Temperature: d.Temperature,
+			Humidity:    d.Humidity,
+			Pressure:    int32Proto(d.Pressure),
+			Pm2_5:       d.PM2_5,
+			Co2:         int32Proto(d.CO2),
+			Voc:         int32Proto(d.VOC),
+			Nox:         int32Proto(d.NOx),
+			Luminosity:  d.Luminosity,
+			Sequence:    int32Proto(d.Sequence),
+			Flags:       int32Proto(d.Flags),
+			Mac:         d.MAC,
+			Rssi:        int32Proto(d.RSSI),
+			Address:     d.Address,
+			LocalName:   d.LocalName,
+		}}}, nil
+	case *ExtendedAirQualityMeasurementData:
+		return &eventpb.Measurement{Data: &eventpb.Measurement_ExtendedAirQuality{ExtendedAirQuality: &eventpb.ExtendedAirQualityMeasurement{
+			DataFormat:   int32Proto(d.DataFormat),
+			Temperature:  d.Temperature,
+			Humidity:     d.Humidity,
+			Pressure:     int32Proto(d.Pressure),
+			Pm1_0:        d.PM1_0,
+			Pm2_5:        d.PM2_5,
+			Pm4_0:        d.PM4_0,
+			Pm10_0:       d.PM10_0,
+			Co2:          int32Proto(d.CO2),
+			Voc:          int32Proto(d.VOC),
+			Nox:          int32Proto(d.NOx),
+			Luminosity:   d.Luminosity,
+			SoundInstant: d.SoundInstant,
+			SoundAverage: d.SoundAverage,
+			SoundPeak:    d.SoundPeak,
+			Sequence:     int32Proto(d.Sequence),
+			Flags:        int32Proto(d.Flags),
+			Mac:          d.MAC,
+			Rssi:         int32Proto(d.RSSI),
+			Address:      d.Address,
+			LocalName:    d.LocalName,
+		}}}, nil
+	default:
+		return nil, fmt.Errorf("%T is not a supported data model", data)
+	}
+}
+
+func accelerationProto(a *struct {
+	X *int `json:"X"`
+	Y *int `json:"Y"`
+	Z *int `json:"Z"`
+}) *eventpb.Acceleration {
+	if a == nil {
+		return nil
+	}
+	return &eventpb.Acceleration{X: int32Proto(a.X), Y: int32Proto(a.Y), Z: int32Proto(a.Z)}
+}
+
+func receptionsProto(receptions []Reception) []*eventpb.Reception {
+	var messages []*eventpb.Reception
+	for _, reception := range receptions {
+		messages = append(messages, &eventpb.Reception{
+			SourceUuid: reception.SourceUuid,
+			Rssi:       int32Proto(reception.RSSI),
+			ReceivedAt: timestamppb.New(reception.ReceivedAt),
+			Strongest:  reception.Strongest,
+		})
+	}
+	return messages
+}
+
+func derivedProto(d *Derived) *eventpb.Derived {
+	if d == nil {
+		return nil
+	}
+	return &eventpb.Derived{
+		DewPoint:                  d.DewPoint,
+		AbsoluteHumidity:          d.AbsoluteHumidity,
+		EquilibriumVapourPressure: d.EquilibriumVapourPressure,
+		VapourPressureDeficit:     d.VapourPressureDeficit,
+		AccelerationMagnitude:     d.AccelerationMagnitude,
+		TiltX:                     d.TiltX,
+		TiltY:                     d.TiltY,
+		TiltZ:                     d.TiltZ,
+		BatteryPercentage:         d.BatteryPercentage,
+		PressureHpa:               d.PressureHPa,
+	}
+}
+
+func rangeViolationsProto(violations []RangeViolation) []*eventpb.RangeViolation {
+	var messages []*eventpb.RangeViolation
+	for _, violation := range violations {
+		messages = append(messages, &eventpb.RangeViolation{
+			Field: violation.Field,
+			Value: violation.Value,
+			Min:   violation.Min,
+			Max:   violation.Max,
+		})
+	}
+	return messages
+}
+
+func int32Proto(i *int) *int32 {
+	if i == nil {
+		return nil
+	}
+	v := int32(*i)
+	return &v
+}
+
+func timestampProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
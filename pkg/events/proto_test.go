@@ -0,0 +1,161 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/events/eventpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRuuviKafkaEvent_MarshalProto(t *testing.T) {
+	observedAt := time.Date(2024, 5, 1, 11, 59, 58, 0, time.UTC)
+	event := events.RuuviKafkaEvent{
+		EventID:    "0b7a3f4e-5c1d-4f8a-9e2b-6d4c3a2b1f0e",
+		Type:       events.NewMeasurement,
+		Data:       measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		ObservedAt: &observedAt,
+		ReceivedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Derived:    &events.Derived{DewPoint: float64Ptr(9.16)},
+		RangeViolations: []events.RangeViolation{
+			{Field: "Humidity", Value: 163, Max: float64Ptr(100)},
+		},
+	}
+
+	t.Run("legacy", func(t *testing.T) {
+		value, err := event.MarshalProto(events.LegacyFormat)
+		if err != nil {
+			t.Fatalf("RuuviKafkaEvent.MarshalProto() error = %v", err)
+		}
+
+		var got eventpb.RuuviKafkaEvent
+		if err := proto.Unmarshal(value, &got); err != nil {
+			t.Fatalf("failed to unmarshal event: %v", err)
+		}
+		data := got.GetData().GetRawv2()
+		if got.GetType() != "new_measurement" || got.GetSourceUuid() != event.SourceUuid || !got.GetObservedAt().AsTime().Equal(observedAt) {
+			t.Errorf("unexpected event %v", &got)
+		}
+		if data.GetTemperature() != 22.34 || data.GetAcceleration().GetX() != -8 || data.GetRssi() != -75 || data.GetMac() != "E8:D3:AD:C4:6E:18" {
+			t.Errorf("unexpected data %v", data)
+		}
+		if got.GetDerived().GetDewPoint() != 9.16 || got.GetDerived().AbsoluteHumidity != nil {
+			t.Errorf("unexpected derived %v", got.GetDerived())
+		}
+		if len(got.GetRangeViolations()) != 1 || got.GetRangeViolations()[0].Min != nil || got.GetRangeViolations()[0].GetMax() != 100 {
+			t.Errorf("unexpected range violations %v", got.GetRangeViolations())
+		}
+	})
+
+	t.Run("envelope", func(t *testing.T) {
+		value, err := event.MarshalProto(events.EnvelopeFormat)
+		if err != nil {
+			t.Fatalf("RuuviKafkaEvent.MarshalProto() error = %v", err)
+		}
+
+		var got eventpb.Envelope
+		if err := proto.Unmarshal(value, &got); err != nil {
+			t.Fatalf("failed to unmarshal envelope: %v", err)
+		}
+		if got.GetEventId() != event.EventID || got.GetSchemaVersion() != events.EnvelopeSchemaVersion || got.GetPayload().GetDataFormat() != 5 {
+			t.Errorf("unexpected envelope %v", &got)
+		}
+		if got.GetPayload().GetData().GetRawv2().GetSequence() != 6256 {
+			t.Errorf("unexpected data %v", got.GetPayload().GetData())
+		}
+	})
+
+	t.Run("smaller than JSON", func(t *testing.T) {
+		protobuf, _ := event.MarshalProto(events.LegacyFormat)
+		json, _ := event.Marshal(events.LegacyFormat)
+		if len(protobuf)*2 > len(json) {
+			t.Errorf("protobuf is %d bytes, JSON %d bytes", len(protobuf), len(json))
+		}
+	})
+
+	if _, err := event.MarshalProto(events.BothFormats); err == nil {
+		t.Error("RuuviKafkaEvent.MarshalProto() expected an error for both formats")
+	}
+}
+
+func TestRuuviKafkaEvent_MarshalProtoDataFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  events.MeasurementData
+		check func(*eventpb.Measurement) bool
+	}{
+		{
+			name:  "RAWv1",
+			data:  &events.RAWv1MeasurementData{DataFormat: intPtr(3), Battery: intPtr(2899)},
+			check: func(m *eventpb.Measurement) bool { return m.GetRawv1().GetBattery() == 2899 },
+		},
+		{
+			name:  "RAWv2",
+			data:  &events.NewMeasurementData{DataFormat: intPtr(5), TXPower: intPtr(-4)},
+			check: func(m *eventpb.Measurement) bool { return m.GetRawv2().GetTxPower() == -4 },
+		},
+		{
+			name: "6",
+			data: &events.AirQualityMeasurementData{DataFormat: intPtr(6), CO2: intPtr(450)},
+			check: func(m *eventpb.Measurement) bool {
+				return m.GetAirQuality().GetCo2() == 450 && m.GetAirQuality().Voc == nil
+			},
+		},
+		{
+			name:  "E1",
+			data:  &events.ExtendedAirQualityMeasurementData{DataFormat: intPtr(0xE1), SoundPeak: float64Ptr(61.5)},
+			check: func(m *eventpb.Measurement) bool { return m.GetExtendedAirQuality().GetSoundPeak() == 61.5 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := events.RuuviKafkaEvent{Type: events.NewMeasurement, Data: tt.data}
+			value, err := event.MarshalProto(events.LegacyFormat)
+			if err != nil {
+				t.Fatalf("RuuviKafkaEvent.MarshalProto() error = %v", err)
+			}
+
+			var got eventpb.RuuviKafkaEvent
+			if err := proto.Unmarshal(value, &got); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			if !tt.check(got.GetData()) {
+				t.Errorf("unexpected data %v", got.GetData())
+			}
+		})
+	}
+}
+
+func BenchmarkRuuviKafkaEvent_Marshal(b *testing.B) {
+	event := events.RuuviKafkaEvent{
+		Type:       events.NewMeasurement,
+		Data:       measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		ReceivedAt: time.Now(),
+	}
+
+	encodings := []struct {
+		name    string
+		marshal func() ([]byte, error)
+	}{
+		{name: "json", marshal: func() ([]byte, error) { return event.Marshal(events.LegacyFormat) }},
+		{name: "protobuf", marshal: func() ([]byte, error) { return event.MarshalProto(events.LegacyFormat) }},
+	}
+
+	for _, encoding := range encodings {
+		b.Run(encoding.name, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				value, err := encoding.marshal()
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(value)
+			}
+			b.ReportMetric(float64(size), "bytes/event")
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package events
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bounds is the range of valid values of a reading. A nil bound is not
+// checked.
+type Bounds struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// RangeLimits overrides the default bounds of readings. Fields applies to
+// every data format that has the field, and Formats to a single data format,
+// keyed by its number, e.g. "5" or "0xE1". Fields are named by their JSON
+// name, and acceleration axes as "Acceleration.X". A bound that is not given
+// keeps its default.
+type RangeLimits struct {
+	Fields  map[string]Bounds            `json:"fields,omitempty"`
+	Formats map[string]map[string]Bounds `json:"formats,omitempty"`
+}
+
+// RangeViolation is a reading outside its bounds.
+type RangeViolation struct {
+	Field string   `json:"field"`
+	Value float64  `json:"value"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+func (v RangeViolation) Error() string {
+	if v.Min != nil && v.Value < *v.Min {
+		return fmt.Sprintf("%v is below the minimum of %v", v.Value, *v.Min)
+	}
+	return fmt.Sprintf("%v is above the maximum of %v", v.Value, *v.Max)
+}
+
+func bounds(min, max float64) Bounds {
+	return Bounds{Min: &min, Max: &max}
+}
+
+// defaultBounds are the physically plausible values of the readings. They
+// apply to every data format that has the field.
+var defaultBounds = map[string]Bounds{
+	"Temperature":    bounds(-40, 125),
+	"Humidity":       bounds(0, 100),
+	"Pressure":       bounds(50000, 110000),
+	"Acceleration.X": bounds(-16000, 16000),
+	"Acceleration.Y": bounds(-16000, 16000),
+	"Acceleration.Z": bounds(-16000, 16000),
+	"Battery":        bounds(1600, 3646),
+	"TXPower":        bounds(-40, 20),
+	"Movement":       bounds(0, 254),
+	"PM1_0":          bounds(0, 1000),
+	"PM2_5":          bounds(0, 1000),
+	"PM4_0":          bounds(0, 1000),
+	"PM10_0":         bounds(0, 1000),
+	"CO2":            bounds(0, 40000),
+	"VOC":            bounds(1, 500),
+	"NOx":            bounds(1, 500),
+}
+
+// unavailableValues are the values that the data formats use to mark a
+// reading as not available, as decoded by the Ruuvi libraries.
+var unavailableValues = map[int]map[string]float64{
+	DataFormatRAWv2: {
+		"Temperature":    -163.84,
+		"Humidity":       163.8375,
+		"Pressure":       115535,
+		"Acceleration.X": -32768,
+		"Acceleration.Y": -32768,
+		"Acceleration.Z": -32768,
+		"Battery":        3647,
+		"TXPower":        22,
+		"Movement":       255,
+		"Sequence":       65535,
+	},
+	DataFormat6: {
+		"Temperature": -163.84,
+		"Humidity":    163.8375,
+		"Pressure":    115535,
+		"PM2_5":       6553.5,
+		"CO2":         65535,
+		"VOC":         511,
+		"NOx":         511,
+	},
+	DataFormatE1: {
+		"Temperature":  -163.84,
+		"Humidity":     163.8375,
+		"Pressure":     115535,
+		"PM1_0":        6553.5,
+		"PM2_5":        6553.5,
+		"PM4_0":        6553.5,
+		"PM10_0":       6553.5,
+		"CO2":          65535,
+		"VOC":          511,
+		"NOx":          511,
+		"Luminosity":   167772.15,
+		"SoundInstant": 120.2,
+		"SoundAverage": 120.2,
+		"SoundPeak":    120.2,
+		"Sequence":     16777215,
+	},
+}
+
+// supportedFormats are the data formats with a data model.
+var supportedFormats = []int{DataFormatRAWv1, DataFormatRAWv2, DataFormat6, DataFormatE1}
+
+// RangeValidator checks that the readings of measurements are within their
+// bounds.
+type RangeValidator struct {
+	bounds map[int]map[string]Bounds
+}
+
+// NewRangeValidator returns a validator using the default bounds with the
+// overrides of limits.
+func NewRangeValidator(limits RangeLimits) (*RangeValidator, error) {
+	v := &RangeValidator{bounds: make(map[int]map[string]Bounds)}
+
+	for _, format := range supportedFormats {
+		data, _ := NewMeasurementDataForFormat(format)
+		fields := make(map[string]Bounds)
+		for _, name := range numericFieldNames(reflect.TypeOf(data).Elem(), "") {
+			if b, ok := defaultBounds[name]; ok {
+				fields[name] = b
+			}
+		}
+		v.bounds[format] = fields
+	}
+
+	for name, b := range limits.Fields {
+		found := false
+		for _, format := range supportedFormats {
+			if v.override(format, name, b) {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+	}
+
+	for key, fields := range limits.Formats {
+		format, err := strconv.ParseInt(key, 0, 0)
+		if err != nil || v.bounds[int(format)] == nil {
+			return nil, fmt.Errorf("unknown data format %s", key)
+		}
+		for name, b := range fields {
+			if !v.override(int(format), name, b) {
+				return nil, fmt.Errorf("data format %s has no field %s", key, name)
+			}
+		}
+	}
+
+	for format, fields := range v.bounds {
+		for name, b := range fields {
+			if b.Min != nil && b.Max != nil && *b.Min > *b.Max {
+				return nil, fmt.Errorf("data format %d: minimum of %s is above its maximum", format, name)
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// override replaces the given bounds of a field of format. It reports false
+// if the format has no such field.
+func (v *RangeValidator) override(format int, name string, b Bounds) bool {
+	data, _ := NewMeasurementDataForFormat(format)
+	if !hasNumericField(reflect.TypeOf(data).Elem(), name) {
+		return false
+	}
+
+	current := v.bounds[format][name]
+	if b.Min != nil {
+		current.Min = b.Min
+	}
+	if b.Max != nil {
+		current.Max = b.Max
+	}
+	v.bounds[format][name] = current
+	return true
+}
+
+// Validate returns the readings of data outside their bounds, ordered by
+// field name. Readings that are not available are not checked.
+func (v *RangeValidator) Validate(data MeasurementData) []RangeViolation {
+	bounds := v.bounds[data.Format()]
+
+	var violations []RangeViolation
+	walkNumericFields(reflect.ValueOf(data).Elem(), "", func(name string, field reflect.Value) {
+		b, ok := bounds[name]
+		if !ok {
+			return
+		}
+
+		value := numericValue(field)
+		if (b.Min != nil && value < *b.Min) || (b.Max != nil && value > *b.Max) {
+			violations = append(violations, RangeViolation{Field: name, Value: value, Min: b.Min, Max: b.Max})
+		}
+	})
+
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Field < violations[j].Field })
+	return violations
+}
+
+// ClearUnavailable sets the readings of data that hold the "not available"
+// value of the data format to nil, so that they are not mistaken for real
+// readings. It returns the names of the cleared fields.
+func ClearUnavailable(data MeasurementData) []string {
+	unavailable := unavailableValues[data.Format()]
+	if unavailable == nil {
+		return nil
+	}
+
+	var cleared []string
+	walkNumericFields(reflect.ValueOf(data).Elem(), "", func(name string, field reflect.Value) {
+		if value, ok := unavailable[name]; ok && math.Abs(numericValue(field)-value) < 1e-6 {
+			field.Set(reflect.Zero(field.Type()))
+			cleared = append(cleared, name)
+		}
+	})
+	return cleared
+}
+
+// walkNumericFields calls fn with every set numeric field of the struct v,
+// including the fields of nested structs, named by their JSON names joined
+// with dots.
+func walkNumericFields(v reflect.Value, prefix string, fn func(name string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.Ptr || field.IsNil() {
+			continue
+		}
+
+		name := prefix + jsonName(t.Field(i))
+		switch field.Elem().Kind() {
+		case reflect.Int, reflect.Float64:
+			fn(name, field)
+		case reflect.Struct:
+			walkNumericFields(field.Elem(), name+".", fn)
+		}
+	}
+}
+
+// numericFieldNames returns the names of the numeric fields of the struct
+// type t, as walkNumericFields names them.
+func numericFieldNames(t reflect.Type, prefix string) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft.Kind() != reflect.Ptr {
+			continue
+		}
+
+		name := prefix + jsonName(t.Field(i))
+		switch ft.Elem().Kind() {
+		case reflect.Int, reflect.Float64:
+			names = append(names, name)
+		case reflect.Struct:
+			names = append(names, numericFieldNames(ft.Elem(), name+".")...)
+		}
+	}
+	return names
+}
+
+func hasNumericField(t reflect.Type, name string) bool {
+	for _, n := range numericFieldNames(t, "") {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+func numericValue(field reflect.Value) float64 {
+	if field.Elem().Kind() == reflect.Int {
+		return float64(field.Elem().Int())
+	}
+	return field.Elem().Float()
+}
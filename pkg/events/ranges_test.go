@@ -0,0 +1,119 @@
+package events_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestRangeValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits events.RangeLimits
+		data   events.MeasurementData
+		want   []string
+	}{
+		{
+			name: "valid measurement",
+			data: measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+		},
+		{
+			name: "corrupted readings",
+			data: measurement(5, 22.34, 163, 0, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+			want: []string{"Humidity", "Pressure"},
+		},
+		{
+			name: "readings that are not available are not checked",
+			data: &events.NewMeasurementData{Temperature: nil, Humidity: float64Ptr(101)},
+			want: []string{"Humidity"},
+		},
+		{
+			name:   "field override",
+			limits: events.RangeLimits{Fields: map[string]events.Bounds{"Temperature": {Max: float64Ptr(20)}}},
+			data:   &events.AirQualityMeasurementData{Temperature: float64Ptr(22.34), CO2: intPtr(450)},
+			want:   []string{"Temperature"},
+		},
+		{
+			name:   "format override",
+			limits: events.RangeLimits{Formats: map[string]map[string]events.Bounds{"0xE1": {"CO2": {Max: float64Ptr(400)}}}},
+			data:   &events.ExtendedAirQualityMeasurementData{CO2: intPtr(450)},
+			want:   []string{"CO2"},
+		},
+		{
+			name:   "format override applies to its format only",
+			limits: events.RangeLimits{Formats: map[string]map[string]events.Bounds{"0xE1": {"CO2": {Max: float64Ptr(400)}}}},
+			data:   &events.AirQualityMeasurementData{CO2: intPtr(450)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := events.NewRangeValidator(tt.limits)
+			if err != nil {
+				t.Fatalf("NewRangeValidator() error = %v", err)
+			}
+
+			var fields []string
+			for _, violation := range validator.Validate(tt.data) {
+				fields = append(fields, violation.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.want) {
+				t.Errorf("RangeValidator.Validate() fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRangeValidator_InvalidLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits events.RangeLimits
+	}{
+		{
+			name:   "unknown field",
+			limits: events.RangeLimits{Fields: map[string]events.Bounds{"Altitude": {Max: float64Ptr(1)}}},
+		},
+		{
+			name:   "unknown data format",
+			limits: events.RangeLimits{Formats: map[string]map[string]events.Bounds{"4": {"Temperature": {Max: float64Ptr(1)}}}},
+		},
+		{
+			name:   "field of another data format",
+			limits: events.RangeLimits{Formats: map[string]map[string]events.Bounds{"3": {"CO2": {Max: float64Ptr(1)}}}},
+		},
+		{
+			name:   "minimum above maximum",
+			limits: events.RangeLimits{Fields: map[string]events.Bounds{"Humidity": {Min: float64Ptr(101)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := events.NewRangeValidator(tt.limits); err == nil {
+				t.Error("NewRangeValidator() expected an error")
+			}
+		})
+	}
+}
+
+func TestClearUnavailable(t *testing.T) {
+	data := measurement(5, -163.84, 163.8375, 115535, -8, -20, 1056, 3647, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75)
+
+	cleared := events.ClearUnavailable(data)
+	if fmt.Sprint(cleared) != "[Temperature Humidity Pressure Battery]" {
+		t.Errorf("ClearUnavailable() = %v", cleared)
+	}
+	if data.Temperature != nil || data.Humidity != nil || data.Pressure != nil || data.Battery != nil {
+		t.Errorf("ClearUnavailable() left unavailable readings %+v", data)
+	}
+	if data.TXPower == nil || *data.TXPower != 4 {
+		t.Errorf("ClearUnavailable() cleared an available reading")
+	}
+
+	// RAWv1 has no "not available" values
+	rawv1 := &events.RAWv1MeasurementData{Temperature: float64Ptr(-163.84)}
+	if cleared := events.ClearUnavailable(rawv1); cleared != nil || rawv1.Temperature == nil {
+		t.Errorf("ClearUnavailable() cleared %v of RAWv1", cleared)
+	}
+}
@@ -0,0 +1,417 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// RuuviManufacturerID is the Bluetooth SIG company identifier of Ruuvi Innovations.
+const RuuviManufacturerID = 0x0499
+
+const (
+	// adTypeManufacturerData is the BLE advertisement data type of the
+	// manufacturer specific data structure.
+	adTypeManufacturerData = 0xFF
+
+	rawV1PayloadLength      = 14
+	rawV2PayloadLength      = 24
+	airQualityPayloadLength = 20
+	extendedPayloadLength   = 40
+
+	// Bits of the flags byte of formats 6 and E1 holding the least
+	// significant bit of the 9 bit VOC and NOx indexes
+	flagVOCBit = 1 << 6
+	flagNOxBit = 1 << 7
+	// Bits of the flags byte of format E1 holding the least significant bit
+	// of the 9 bit sound levels
+	flagSoundInstantBit = 1 << 3
+	flagSoundAverageBit = 1 << 4
+	flagSoundPeakBit    = 1 << 5
+)
+
+var (
+	ErrNotRuuviAdvertisement = errors.New("advertisement does not contain Ruuvi manufacturer data")
+	ErrUnsupportedDataFormat = errors.New("unsupported data format")
+	ErrPayloadTooShort       = errors.New("payload too short for data format")
+)
+
+// RawAdvertisementData is the data of a raw_advertisement event. Data is the
+// hex encoded BLE advertisement as received by the gateway. It may be the full
+// advertisement (e.g. "0201061BFF9904..."), the manufacturer specific data
+// including the company identifier ("9904...") or just the Ruuvi payload
+// starting with the data format byte.
+type RawAdvertisementData struct {
+	Data *string `json:"Data"`
+	MAC  *string `json:"MAC"`
+	RSSI *int    `json:"RSSI"`
+}
+
+// IsValid checks if all fields in the RawAdvertisementData struct are not nil.
+// It does not check that Data can actually be decoded, use Decode for that.
+func (r *RawAdvertisementData) IsValid() bool {
+	return r.Data != nil &&
+		r.MAC != nil &&
+		r.RSSI != nil
+}
+
+// MissingFields returns the JSON names of the missing fields.
+func (r *RawAdvertisementData) MissingFields() []string {
+	return nilFields(reflect.ValueOf(r).Elem(), "", nil)
+}
+
+// Decode decodes the raw advertisement into the data model of its data
+// format.
+//
+// Fields that the tag reports as not available are left nil, so the result
+// should be checked with IsValid before use. The MAC address from the payload
+// is preferred; the MAC of the event is used when the payload does not carry
+// one.
+func (r *RawAdvertisementData) Decode() (MeasurementData, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(*r.Data, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data: %w", err)
+	}
+
+	payload, err := ruuviPayload(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := DecodeRuuviPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := strings.ToUpper(*r.MAC)
+
+	switch d := data.(type) {
+	case *RAWv1MeasurementData:
+		d.MAC = stringPtr(mac)
+		d.Address = stringPtr(mac)
+		d.LocalName = stringPtr("")
+		d.RSSI = intPtr(*r.RSSI)
+	case *NewMeasurementData:
+		if d.MAC == nil {
+			d.MAC = stringPtr(mac)
+		}
+		d.Address = stringPtr(*d.MAC)
+		d.LocalName = stringPtr("")
+		d.RSSI = intPtr(*r.RSSI)
+	case *AirQualityMeasurementData:
+		// The payload only carries the lowest three bytes of the MAC
+		d.MAC = stringPtr(mac)
+		d.Address = stringPtr(mac)
+		d.LocalName = stringPtr("")
+		d.RSSI = intPtr(*r.RSSI)
+	case *ExtendedAirQualityMeasurementData:
+		if d.MAC == nil {
+			d.MAC = stringPtr(mac)
+		}
+		d.Address = stringPtr(*d.MAC)
+		d.LocalName = stringPtr("")
+		d.RSSI = intPtr(*r.RSSI)
+	}
+
+	return data, nil
+}
+
+// DecodeRuuviPayload decodes a Ruuvi manufacturer specific payload, starting
+// with the data format byte, into the data model of its data format. RSSI,
+// Address and LocalName are not part of the payload and are left nil.
+//
+// Data formats 3 (RAWv1), 5 (RAWv2), 6 and E1 can be decoded.
+func DecodeRuuviPayload(payload []byte) (MeasurementData, error) {
+	if len(payload) == 0 {
+		return nil, ErrPayloadTooShort
+	}
+
+	switch payload[0] {
+	case DataFormatRAWv1:
+		return decodeRawV1(payload)
+	case DataFormatRAWv2:
+		return decodeRawV2(payload)
+	case DataFormat6:
+		return decodeAirQuality(payload)
+	case DataFormatE1:
+		return decodeExtendedAirQuality(payload)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedDataFormat, payload[0])
+	}
+}
+
+// ruuviPayload locates the Ruuvi payload in a raw advertisement and returns it
+// without the company identifier.
+func ruuviPayload(raw []byte) ([]byte, error) {
+	// Full advertisement consisting of AD structures
+	if payload, ok := manufacturerDataFromAdvertisement(raw); ok {
+		return payload, nil
+	}
+
+	// Manufacturer specific data starting with the company identifier
+	if len(raw) >= 2 && binary.LittleEndian.Uint16(raw) == RuuviManufacturerID {
+		return raw[2:], nil
+	}
+
+	// Bare payload starting with the data format byte
+	if len(raw) > 0 {
+		return raw, nil
+	}
+
+	return nil, ErrNotRuuviAdvertisement
+}
+
+// manufacturerDataFromAdvertisement walks the length-type-value AD structures
+// of a BLE advertisement and returns the Ruuvi manufacturer data. It reports
+// false if raw is not a well-formed advertisement with Ruuvi data.
+func manufacturerDataFromAdvertisement(raw []byte) ([]byte, bool) {
+	var payload []byte
+
+	for i := 0; i < len(raw); {
+		length := int(raw[i])
+		if length == 0 || i+1+length > len(raw) {
+			return nil, false
+		}
+
+		structure := raw[i+1 : i+1+length]
+		if structure[0] == adTypeManufacturerData && len(structure) >= 3 &&
+			binary.LittleEndian.Uint16(structure[1:]) == RuuviManufacturerID {
+			payload = structure[3:]
+		}
+
+		i += 1 + length
+	}
+
+	return payload, payload != nil
+}
+
+// decodeRawV1 decodes data format 3 (RAWv1).
+// See https://docs.ruuvi.com/communication/bluetooth-advertisements/data-format-3-rawv1
+func decodeRawV1(p []byte) (*RAWv1MeasurementData, error) {
+	if len(p) < rawV1PayloadLength {
+		return nil, fmt.Errorf("%w: format 3 needs %d bytes, got %d", ErrPayloadTooShort, rawV1PayloadLength, len(p))
+	}
+
+	// Temperature is a sign bit and a 7 bit integer part followed by the
+	// fraction in hundredths of a degree
+	temperature := float64(p[2]&0x7F) + float64(p[3])/100
+	if p[2]&0x80 != 0 {
+		temperature = -temperature
+	}
+
+	return &RAWv1MeasurementData{
+		DataFormat:  intPtr(DataFormatRAWv1),
+		Temperature: float64Ptr(roundTo(temperature, 2)),
+		Humidity:    float64Ptr(float64(p[1]) * 0.5),
+		Pressure:    intPtr(int(binary.BigEndian.Uint16(p[4:])) + 50000),
+		Acceleration: &struct {
+			X *int `json:"X"`
+			Y *int `json:"Y"`
+			Z *int `json:"Z"`
+		}{
+			X: intPtr(int(int16(binary.BigEndian.Uint16(p[6:])))),
+			Y: intPtr(int(int16(binary.BigEndian.Uint16(p[8:])))),
+			Z: intPtr(int(int16(binary.BigEndian.Uint16(p[10:])))),
+		},
+		Battery: intPtr(int(binary.BigEndian.Uint16(p[12:]))),
+	}, nil
+}
+
+// decodeRawV2 decodes data format 5 (RAWv2).
+// See https://docs.ruuvi.com/communication/bluetooth-advertisements/data-format-5-rawv2
+func decodeRawV2(p []byte) (*NewMeasurementData, error) {
+	if len(p) < rawV2PayloadLength {
+		return nil, fmt.Errorf("%w: format 5 needs %d bytes, got %d", ErrPayloadTooShort, rawV2PayloadLength, len(p))
+	}
+
+	data := &NewMeasurementData{DataFormat: intPtr(DataFormatRAWv2)}
+	data.Temperature, data.Humidity, data.Pressure = decodeEnvironment(p[1:7])
+
+	// Every axis may be reported as not available on its own
+	data.Acceleration = &struct {
+		X *int `json:"X"`
+		Y *int `json:"Y"`
+		Z *int `json:"Z"`
+	}{}
+	if x := int16(binary.BigEndian.Uint16(p[7:])); x != -0x8000 {
+		data.Acceleration.X = intPtr(int(x))
+	}
+	if y := int16(binary.BigEndian.Uint16(p[9:])); y != -0x8000 {
+		data.Acceleration.Y = intPtr(int(y))
+	}
+	if z := int16(binary.BigEndian.Uint16(p[11:])); z != -0x8000 {
+		data.Acceleration.Z = intPtr(int(z))
+	}
+
+	power := binary.BigEndian.Uint16(p[13:])
+	if battery := power >> 5; battery != 0x7FF {
+		data.Battery = intPtr(int(battery) + 1600)
+	}
+	if txPower := power & 0x1F; txPower != 0x1F {
+		data.TXPower = intPtr(int(txPower)*2 - 40)
+	}
+
+	if movement := p[15]; movement != 0xFF {
+		data.Movement = intPtr(int(movement))
+	}
+	if sequence := binary.BigEndian.Uint16(p[16:]); sequence != 0xFFFF {
+		data.Sequence = intPtr(int(sequence))
+	}
+
+	if mac := p[18:24]; !isInvalidMAC(mac) {
+		data.MAC = stringPtr(formatMAC(mac))
+	}
+
+	return data, nil
+}
+
+// decodeAirQuality decodes data format 6.
+// See https://docs.ruuvi.com/communication/bluetooth-advertisements/data-format-6
+func decodeAirQuality(p []byte) (*AirQualityMeasurementData, error) {
+	if len(p) < airQualityPayloadLength {
+		return nil, fmt.Errorf("%w: format 6 needs %d bytes, got %d", ErrPayloadTooShort, airQualityPayloadLength, len(p))
+	}
+
+	data := &AirQualityMeasurementData{DataFormat: intPtr(DataFormat6)}
+	flags := p[16]
+
+	data.Temperature, data.Humidity, data.Pressure = decodeEnvironment(p[1:7])
+	data.PM2_5 = decodeParticulateMatter(p[7:])
+	if co2 := binary.BigEndian.Uint16(p[9:]); co2 != 0xFFFF {
+		data.CO2 = intPtr(int(co2))
+	}
+	data.VOC = decodeIndex(p[11], flags&flagVOCBit != 0)
+	data.NOx = decodeIndex(p[12], flags&flagNOxBit != 0)
+
+	// Luminosity is encoded logarithmically from 0 to 65535 lux in 254
+	// steps
+	if code := p[13]; code != 0xFF {
+		luminosity := math.Exp(float64(code)*math.Log(65536)/254) - 1
+		data.Luminosity = float64Ptr(roundTo(luminosity, 2))
+	}
+
+	// Byte 14 is reserved, and the 8 bit sequence number has no value
+	// marking it as not available
+	data.Sequence = intPtr(int(p[15]))
+	data.Flags = intPtr(int(flags &^ (flagVOCBit | flagNOxBit)))
+
+	return data, nil
+}
+
+// decodeExtendedAirQuality decodes the extended data format E1.
+// See https://docs.ruuvi.com/communication/bluetooth-advertisements/data-format-e1
+func decodeExtendedAirQuality(p []byte) (*ExtendedAirQualityMeasurementData, error) {
+	if len(p) < extendedPayloadLength {
+		return nil, fmt.Errorf("%w: format E1 needs %d bytes, got %d", ErrPayloadTooShort, extendedPayloadLength, len(p))
+	}
+
+	data := &ExtendedAirQualityMeasurementData{DataFormat: intPtr(DataFormatE1)}
+	flags := p[28]
+
+	data.Temperature, data.Humidity, data.Pressure = decodeEnvironment(p[1:7])
+	data.PM1_0 = decodeParticulateMatter(p[7:])
+	data.PM2_5 = decodeParticulateMatter(p[9:])
+	data.PM4_0 = decodeParticulateMatter(p[11:])
+	data.PM10_0 = decodeParticulateMatter(p[13:])
+	if co2 := binary.BigEndian.Uint16(p[15:]); co2 != 0xFFFF {
+		data.CO2 = intPtr(int(co2))
+	}
+	data.VOC = decodeIndex(p[17], flags&flagVOCBit != 0)
+	data.NOx = decodeIndex(p[18], flags&flagNOxBit != 0)
+
+	if luminosity := uint24(p[19:]); luminosity != 0xFFFFFF {
+		data.Luminosity = float64Ptr(roundTo(float64(luminosity)*0.01, 2))
+	}
+
+	data.SoundInstant = decodeSoundLevel(p[22], flags&flagSoundInstantBit != 0)
+	data.SoundAverage = decodeSoundLevel(p[23], flags&flagSoundAverageBit != 0)
+	data.SoundPeak = decodeSoundLevel(p[24], flags&flagSoundPeakBit != 0)
+
+	if sequence := uint24(p[25:]); sequence != 0xFFFFFF {
+		data.Sequence = intPtr(int(sequence))
+	}
+	data.Flags = intPtr(int(flags &^ (flagVOCBit | flagNOxBit | flagSoundInstantBit | flagSoundAverageBit | flagSoundPeakBit)))
+
+	// Bytes 29 to 33 are reserved
+	if mac := p[34:40]; !isInvalidMAC(mac) {
+		data.MAC = stringPtr(formatMAC(mac))
+	}
+
+	return data, nil
+}
+
+// decodeEnvironment decodes the temperature, humidity and pressure that
+// formats 5, 6 and E1 encode alike.
+func decodeEnvironment(p []byte) (temperature, humidity *float64, pressure *int) {
+	if t := int16(binary.BigEndian.Uint16(p)); t != -0x8000 {
+		temperature = float64Ptr(roundTo(float64(t)*0.005, 3))
+	}
+	if h := binary.BigEndian.Uint16(p[2:]); h != 0xFFFF {
+		humidity = float64Ptr(roundTo(float64(h)*0.0025, 4))
+	}
+	if pr := binary.BigEndian.Uint16(p[4:]); pr != 0xFFFF {
+		pressure = intPtr(int(pr) + 50000)
+	}
+	return temperature, humidity, pressure
+}
+
+// decodeParticulateMatter decodes a mass concentration in 0.1 µg/m³.
+func decodeParticulateMatter(p []byte) *float64 {
+	if pm := binary.BigEndian.Uint16(p); pm != 0xFFFF {
+		return float64Ptr(roundTo(float64(pm)*0.1, 1))
+	}
+	return nil
+}
+
+// decodeIndex decodes a 9 bit VOC or NOx index from its 8 most significant
+// bits and its least significant bit, which is carried in the flags.
+func decodeIndex(msb byte, lsb bool) *int {
+	index := int(msb) << 1
+	if lsb {
+		index |= 1
+	}
+	if index == 0x1FF {
+		return nil
+	}
+	return intPtr(index)
+}
+
+// decodeSoundLevel decodes a 9 bit sound level in 0.2 dBA steps from 18
+// dBA, from its 8 most significant bits and its least significant bit, which
+// is carried in the flags.
+func decodeSoundLevel(msb byte, lsb bool) *float64 {
+	level := int(msb) << 1
+	if lsb {
+		level |= 1
+	}
+	if level == 0x1FF {
+		return nil
+	}
+	return float64Ptr(roundTo(float64(level)*0.2+18, 1))
+}
+
+// uint24 decodes a big-endian 24 bit unsigned integer.
+func uint24(p []byte) uint32 {
+	return uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+}
+
+func isInvalidMAC(mac []byte) bool {
+	for _, b := range mac {
+		if b != 0xFF {
+			return false
+		}
+	}
+	return true
+}
+
+func formatMAC(mac []byte) string {
+	parts := make([]string, len(mac))
+	for i, b := range mac {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,286 @@
+package events_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+// Test vectors from the Ruuvi data format 3, 5, 6 and E1 specifications. The
+// reserved bytes are set to 0xFF, and the E1 vectors also carry sound levels.
+const (
+	rawV1Valid    = "03291A1ECE1EFC18F94202CA0B53"
+	rawV1Negative = "0300FF6300008001800180010000"
+
+	rawV2Valid   = "0512FC5394C37C0004FFFC040CAC364200CDCBB8334C884F"
+	rawV2Maximum = "057FFFFFFEFFFE7FFF7FFF7FFFFFDEFEFFFECBB8334C884F"
+	rawV2Minimum = "058001000000008001800180010000000000CBB8334C884F"
+	rawV2Invalid = "058000FFFFFFFF800080008000FFFFFFFFFFFFFFFFFFFFFF"
+
+	airQualityValid   = "06170C5668C79E007000C90501D9FFCD004C884F"
+	airQualityMaximum = "067FFF9C40FFFE27109C40FAFAFEFFFF00FFFFFF"
+	airQualityInvalid = "068000FFFFFFFFFFFFFFFFFFFFFFFF00C7FFFFFF"
+
+	extendedValid   = "E1170C5668C79E0065007004BD11CA00C90A0213E0AC373050DECDEE09FFFFFFFFFFCBB8334C884F"
+	extendedInvalid = "E18000FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF8FFFFFFFFFFFFFFFFFFFFFF"
+)
+
+func TestRawAdvertisementData_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    events.MeasurementData
+		wantErr error
+	}{
+		{
+			name: "Valid payload",
+			data: rawV2Valid,
+			want: measurement(5, 24.3, 53.49, 100044, 4, -4, 1036, 2977, 4, 66, 205, "CB:B8:33:4C:88:4F", -75),
+		},
+		{
+			name: "Valid full advertisement",
+			data: "0201061BFF9904" + rawV2Valid,
+			want: measurement(5, 24.3, 53.49, 100044, 4, -4, 1036, 2977, 4, 66, 205, "CB:B8:33:4C:88:4F", -75),
+		},
+		{
+			name: "Valid manufacturer data with company identifier",
+			data: "9904" + rawV2Valid,
+			want: measurement(5, 24.3, 53.49, 100044, 4, -4, 1036, 2977, 4, 66, 205, "CB:B8:33:4C:88:4F", -75),
+		},
+		{
+			name: "Maximum values",
+			data: rawV2Maximum,
+			want: measurement(5, 163.835, 163.835, 115534, 32767, 32767, 32767, 3646, 20, 254, 65534, "CB:B8:33:4C:88:4F", -75),
+		},
+		{
+			name: "Minimum values",
+			data: rawV2Minimum,
+			want: measurement(5, -163.835, 0, 50000, -32767, -32767, -32767, 1600, -40, 0, 0, "CB:B8:33:4C:88:4F", -75),
+		},
+		{
+			name: "Invalid values",
+			data: rawV2Invalid,
+			want: &events.NewMeasurementData{
+				DataFormat: intPtr(5),
+				Acceleration: structPtr(struct {
+					X *int `json:"X"`
+					Y *int `json:"Y"`
+					Z *int `json:"Z"`
+				}{}),
+				MAC:       stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:      intPtr(-75),
+				Address:   stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName: stringPtr(""),
+			},
+		},
+		{
+			name: "Valid RAWv1 payload",
+			data: "02010611FF9904" + rawV1Valid,
+			want: &events.RAWv1MeasurementData{
+				DataFormat:  intPtr(3),
+				Temperature: float64Ptr(26.3),
+				Humidity:    float64Ptr(20.5),
+				Pressure:    intPtr(102766),
+				Acceleration: structPtr(struct {
+					X *int `json:"X"`
+					Y *int `json:"Y"`
+					Z *int `json:"Z"`
+				}{X: intPtr(-1000), Y: intPtr(-1726), Z: intPtr(714)}),
+				Battery:   intPtr(2899),
+				MAC:       stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:      intPtr(-75),
+				Address:   stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName: stringPtr(""),
+			},
+		},
+		{
+			name: "Negative RAWv1 temperature",
+			data: rawV1Negative,
+			want: &events.RAWv1MeasurementData{
+				DataFormat:  intPtr(3),
+				Temperature: float64Ptr(-127.99),
+				Humidity:    float64Ptr(0),
+				Pressure:    intPtr(50000),
+				Acceleration: structPtr(struct {
+					X *int `json:"X"`
+					Y *int `json:"Y"`
+					Z *int `json:"Z"`
+				}{X: intPtr(-32767), Y: intPtr(-32767), Z: intPtr(-32767)}),
+				Battery:   intPtr(0),
+				MAC:       stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:      intPtr(-75),
+				Address:   stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName: stringPtr(""),
+			},
+		},
+		{
+			name: "Valid format 6 payload",
+			data: "02010617FF9904" + airQualityValid,
+			want: &events.AirQualityMeasurementData{
+				DataFormat:  intPtr(6),
+				Temperature: float64Ptr(29.5),
+				Humidity:    float64Ptr(55.3),
+				Pressure:    intPtr(101102),
+				PM2_5:       float64Ptr(11.2),
+				CO2:         intPtr(201),
+				VOC:         intPtr(10),
+				NOx:         intPtr(2),
+				Luminosity:  float64Ptr(13026.67),
+				Sequence:    intPtr(205),
+				Flags:       intPtr(0),
+				MAC:         stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:        intPtr(-75),
+				Address:     stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName:   stringPtr(""),
+			},
+		},
+		{
+			name: "Maximum format 6 values",
+			data: airQualityMaximum,
+			want: &events.AirQualityMeasurementData{
+				DataFormat:  intPtr(6),
+				Temperature: float64Ptr(163.835),
+				Humidity:    float64Ptr(100),
+				Pressure:    intPtr(115534),
+				PM2_5:       float64Ptr(1000),
+				CO2:         intPtr(40000),
+				VOC:         intPtr(500),
+				NOx:         intPtr(500),
+				Luminosity:  float64Ptr(65535),
+				Sequence:    intPtr(255),
+				Flags:       intPtr(0),
+				MAC:         stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:        intPtr(-75),
+				Address:     stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName:   stringPtr(""),
+			},
+		},
+		{
+			name: "Invalid format 6 values",
+			data: airQualityInvalid,
+			want: &events.AirQualityMeasurementData{
+				DataFormat: intPtr(6),
+				Sequence:   intPtr(0),
+				Flags:      intPtr(7),
+				MAC:        stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:       intPtr(-75),
+				Address:    stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName:  stringPtr(""),
+			},
+		},
+		{
+			name: "Valid format E1 payload",
+			data: extendedValid,
+			want: &events.ExtendedAirQualityMeasurementData{
+				DataFormat:   intPtr(0xE1),
+				Temperature:  float64Ptr(29.5),
+				Humidity:     float64Ptr(55.3),
+				Pressure:     intPtr(101102),
+				PM1_0:        float64Ptr(10.1),
+				PM2_5:        float64Ptr(11.2),
+				PM4_0:        float64Ptr(121.3),
+				PM10_0:       float64Ptr(455.4),
+				CO2:          intPtr(201),
+				VOC:          intPtr(20),
+				NOx:          intPtr(4),
+				Luminosity:   float64Ptr(13027),
+				SoundInstant: float64Ptr(40.2),
+				SoundAverage: float64Ptr(37.2),
+				SoundPeak:    float64Ptr(50),
+				Sequence:     intPtr(14601710),
+				Flags:        intPtr(1),
+				MAC:          stringPtr("CB:B8:33:4C:88:4F"),
+				RSSI:         intPtr(-75),
+				Address:      stringPtr("CB:B8:33:4C:88:4F"),
+				LocalName:    stringPtr(""),
+			},
+		},
+		{
+			name: "Invalid format E1 values",
+			data: extendedInvalid,
+			want: &events.ExtendedAirQualityMeasurementData{
+				DataFormat: intPtr(0xE1),
+				Flags:      intPtr(0),
+				MAC:        stringPtr("E8:D3:AD:C4:6E:18"),
+				RSSI:       intPtr(-75),
+				Address:    stringPtr("E8:D3:AD:C4:6E:18"),
+				LocalName:  stringPtr(""),
+			},
+		},
+		{
+			name:    "Truncated payload",
+			data:    rawV2Valid[:20],
+			wantErr: events.ErrPayloadTooShort,
+		},
+		{
+			name:    "Truncated format 6 payload",
+			data:    airQualityValid[:38],
+			wantErr: events.ErrPayloadTooShort,
+		},
+		{
+			name:    "Truncated format E1 payload",
+			data:    extendedValid[:78],
+			wantErr: events.ErrPayloadTooShort,
+		},
+		{
+			name:    "Unsupported data format",
+			data:    "FF" + rawV2Valid[2:],
+			wantErr: events.ErrUnsupportedDataFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := events.RawAdvertisementData{
+				Data: stringPtr(tt.data),
+				MAC:  stringPtr("e8:d3:ad:c4:6e:18"),
+				RSSI: intPtr(-75),
+			}
+
+			got, err := raw.Decode()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RawAdvertisementData.Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RawAdvertisementData.Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawAdvertisementData_DecodeInvalidHex(t *testing.T) {
+	raw := events.RawAdvertisementData{
+		Data: stringPtr("not hex"),
+		MAC:  stringPtr("E8:D3:AD:C4:6E:18"),
+		RSSI: intPtr(-75),
+	}
+
+	if _, err := raw.Decode(); err == nil {
+		t.Error("RawAdvertisementData.Decode() expected an error for invalid hex")
+	}
+}
+
+func measurement(format int, temperature, humidity float64, pressure, x, y, z, battery, txPower, movement, sequence int, mac string, rssi int) *events.NewMeasurementData {
+	return &events.NewMeasurementData{
+		DataFormat:  intPtr(format),
+		Temperature: float64Ptr(temperature),
+		Humidity:    float64Ptr(humidity),
+		Pressure:    intPtr(pressure),
+		Acceleration: structPtr(struct {
+			X *int `json:"X"`
+			Y *int `json:"Y"`
+			Z *int `json:"Z"`
+		}{X: intPtr(x), Y: intPtr(y), Z: intPtr(z)}),
+		Battery:   intPtr(battery),
+		TXPower:   intPtr(txPower),
+		Movement:  intPtr(movement),
+		Sequence:  intPtr(sequence),
+		MAC:       stringPtr(mac),
+		RSSI:      intPtr(rssi),
+		Address:   stringPtr(mac),
+		LocalName: stringPtr(""),
+	}
+}
@@ -0,0 +1,163 @@
+package events
+
+//go:generate go run ../../cmd/schemagen -out ../../docs/schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaVersions lists the schema versions of produced events.
+var SchemaVersions = []string{SchemaVersion, EnvelopeSchemaVersion}
+
+// measurementModels are the data models that the data of a measurement may
+// have, one per supported data format.
+var measurementModels = []reflect.Type{
+	reflect.TypeOf(RAWv1MeasurementData{}),
+	reflect.TypeOf(NewMeasurementData{}),
+	reflect.TypeOf(AirQualityMeasurementData{}),
+	reflect.TypeOf(ExtendedAirQualityMeasurementData{}),
+}
+
+// JSONSchema returns the JSON Schema of the events of a schema version,
+// generated from the Go types that are produced. The data of a measurement
+// is one of the data models of the supported data formats. The root of the
+// envelope schema allows other fields, so that it also describes the events
+// produced in both formats.
+func JSONSchema(version string) ([]byte, error) {
+	root, err := rootType(version)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	schema := g.object(root)
+	if version == EnvelopeSchemaVersion {
+		// Events produced in both formats carry the fields of the flat
+		// event next to those of the envelope
+		delete(schema, "additionalProperties")
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = fmt.Sprintf("%s, schema version %s", root.Name(), version)
+	schema["$defs"] = g.defs
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// rootType returns the type of the events of a schema version.
+func rootType(version string) (reflect.Type, error) {
+	switch version {
+	case SchemaVersion:
+		return reflect.TypeOf(RuuviKafkaEvent{}), nil
+	case EnvelopeSchemaVersion:
+		return reflect.TypeOf(Envelope{}), nil
+	default:
+		return nil, fmt.Errorf("unknown schema version %q", version)
+	}
+}
+
+type schemaGenerator struct {
+	// Schemas of the named struct types, referenced by their name
+	defs map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return nullable(g.schema(t.Elem()))
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Reserve the name before generating, in case the type refers
+			// to itself
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case t.Kind() == reflect.Struct:
+		return g.object(t)
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case t.Kind() == reflect.Interface:
+		var models []interface{}
+		for _, model := range measurementModels {
+			models = append(models, g.schema(model))
+		}
+		return map[string]interface{}{"oneOf": models}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{"type": "integer"}
+	}
+}
+
+// object returns the schema of a struct. Fields with omitempty are optional
+// and all others are required.
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for _, field := range encodedFields(t) {
+		properties[field.name] = g.schema(field.Type)
+		if !field.omitempty {
+			required = append(required, field.name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// encodedField is a field of a struct as it is encoded in JSON.
+type encodedField struct {
+	reflect.StructField
+	name      string
+	omitempty bool
+}
+
+// encodedFields returns the fields of a struct that are encoded in JSON, in
+// order.
+func encodedFields(t reflect.Type) []encodedField {
+	var fields []encodedField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, encodedField{field, name, strings.Contains(options, "omitempty")})
+	}
+	return fields
+}
+
+// nullable allows null in addition to the values of schema.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		nullable := make(map[string]interface{}, len(schema))
+		for key, value := range schema {
+			nullable[key] = value
+		}
+		nullable["type"] = []string{typ, "null"}
+		return nullable
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
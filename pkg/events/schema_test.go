@@ -0,0 +1,176 @@
+package events_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+// The records produced in each format are valid against the schema in
+// docs/schemas of the version in their schema_version header
+func TestJSONSchema_ValidatesProducedEvents(t *testing.T) {
+	receivedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	observedAt := receivedAt.Add(-time.Second)
+	dewPoint := 8.9
+	min := -40.0
+
+	event := events.RuuviKafkaEvent{
+		EventID:    "0b7a3f4e-5c1d-4f8a-9e2b-6d4c3a2b1f0e",
+		Type:       events.NewMeasurement,
+		Data:       measurement(5, 22.34, 42.975, 97465, -8, -20, 1056, 2857, 4, 75, 6256, "E8:D3:AD:C4:6E:18", -75),
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		ObservedAt: &observedAt,
+		ReceivedAt: receivedAt,
+		Receptions: []events.Reception{
+			{SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b", RSSI: intPtr(-75), ReceivedAt: receivedAt, Strongest: true},
+			{SourceUuid: "AA:BB:CC:DD:EE:FF", ReceivedAt: receivedAt},
+		},
+		Derived:         &events.Derived{DewPoint: &dewPoint},
+		RangeViolations: []events.RangeViolation{{Field: "Temperature", Value: -50, Min: &min}},
+	}
+
+	for _, format := range []events.EventFormat{events.LegacyFormat, events.EnvelopeFormat, events.BothFormats} {
+		t.Run(string(format), func(t *testing.T) {
+			b, err := os.ReadFile("../../docs/schemas/v" + format.SchemaVersion() + ".schema.json")
+			if err != nil {
+				t.Fatalf("failed to read schema: %v", err)
+			}
+			var schema map[string]interface{}
+			if err := json.Unmarshal(b, &schema); err != nil {
+				t.Fatalf("failed to unmarshal schema: %v", err)
+			}
+
+			value, err := event.Marshal(format)
+			if err != nil {
+				t.Fatalf("RuuviKafkaEvent.Marshal() error = %v", err)
+			}
+			var record interface{}
+			if err := json.Unmarshal(value, &record); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+
+			v := &schemaValidator{defs: schema["$defs"].(map[string]interface{})}
+			if err := v.validate(schema, record, "$"); err != nil {
+				t.Errorf("event does not match schema version %s: %v", format.SchemaVersion(), err)
+			}
+		})
+	}
+}
+
+// schemaValidator validates values against the subset of JSON Schema that
+// the schema generator uses.
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		return v.validate(v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{}), value, path)
+	}
+
+	if schemas, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, s := range schemas {
+			if v.validate(s.(map[string]interface{}), value, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s matches %d schemas of oneOf", path, matches)
+		}
+		return nil
+	}
+
+	if schemas, ok := schema["anyOf"].([]interface{}); ok {
+		for _, s := range schemas {
+			if v.validate(s.(map[string]interface{}), value, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s matches no schema of anyOf", path)
+	}
+
+	var types []string
+	switch typ := schema["type"].(type) {
+	case string:
+		types = []string{typ}
+	case []interface{}:
+		for _, t := range typ {
+			types = append(types, t.(string))
+		}
+	}
+
+	for _, typ := range types {
+		if hasType(value, typ) {
+			return v.validateType(schema, value, path)
+		}
+	}
+	return fmt.Errorf("%s is not of type %v", path, types)
+}
+
+func (v *schemaValidator) validateType(schema map[string]interface{}, value interface{}, path string) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range schema["required"].([]interface{}) {
+			if _, ok := value[name.(string)]; !ok {
+				return fmt.Errorf("%s.%s is missing", path, name)
+			}
+		}
+		for name, field := range value {
+			if property, ok := properties[name]; ok {
+				if err := v.validate(property.(map[string]interface{}), field, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s.%s is not allowed", path, name)
+				}
+			case map[string]interface{}:
+				if err := v.validate(additional, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if err := v.validate(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case string:
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				return fmt.Errorf("%s is not a date-time: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func hasType(value interface{}, typ string) bool {
+	switch value := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || typ == "integer" && value == math.Trunc(value)
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package events
+
+// SequenceNumber returns the measurement sequence number of the tag and the
+// number of values its counter takes before wrapping around to zero. Data
+// format 3 has no sequence number.
+func SequenceNumber(data MeasurementData) (sequence, modulus int, ok bool) {
+	switch d := data.(type) {
+	case *NewMeasurementData:
+		// 0xFFFF marks an invalid sequence number
+		return intValue(d.Sequence), 0xFFFF, d.Sequence != nil
+	case *AirQualityMeasurementData:
+		return intValue(d.Sequence), 0x100, d.Sequence != nil
+	case *ExtendedAirQualityMeasurementData:
+		// 0xFFFFFF marks an invalid sequence number
+		return intValue(d.Sequence), 0xFFFFFF, d.Sequence != nil
+	default:
+		return 0, 0, false
+	}
+}
+
+// SignalStrength returns the RSSI the measurement was received with.
+func SignalStrength(data MeasurementData) (int, bool) {
+	switch d := data.(type) {
+	case *NewMeasurementData:
+		return intValue(d.RSSI), d.RSSI != nil
+	case *RAWv1MeasurementData:
+		return intValue(d.RSSI), d.RSSI != nil
+	case *AirQualityMeasurementData:
+		return intValue(d.RSSI), d.RSSI != nil
+	case *ExtendedAirQualityMeasurementData:
+		return intValue(d.RSSI), d.RSSI != nil
+	default:
+		return 0, false
+	}
+}
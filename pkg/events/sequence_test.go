@@ -0,0 +1,59 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestSequenceNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         events.MeasurementData
+		wantSequence int
+		wantModulus  int
+		wantOk       bool
+	}{
+		{
+			name:         "RAWv2",
+			data:         &events.NewMeasurementData{Sequence: intPtr(6256)},
+			wantSequence: 6256,
+			wantModulus:  0xFFFF,
+			wantOk:       true,
+		},
+		{
+			name:         "format 6",
+			data:         &events.AirQualityMeasurementData{Sequence: intPtr(200)},
+			wantSequence: 200,
+			wantModulus:  0x100,
+			wantOk:       true,
+		},
+		{
+			name:         "format E1",
+			data:         &events.ExtendedAirQualityMeasurementData{Sequence: intPtr(70000)},
+			wantSequence: 70000,
+			wantModulus:  0xFFFFFF,
+			wantOk:       true,
+		},
+		{
+			name: "missing sequence number",
+			data: &events.NewMeasurementData{},
+		},
+		{
+			name: "RAWv1 has no sequence number",
+			data: &events.RAWv1MeasurementData{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sequence, modulus, ok := events.SequenceNumber(tt.data)
+			if ok != tt.wantOk {
+				t.Fatalf("SequenceNumber() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (sequence != tt.wantSequence || modulus != tt.wantModulus) {
+				t.Errorf("SequenceNumber() = %d, %d, want %d, %d", sequence, modulus, tt.wantSequence, tt.wantModulus)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package kafkawrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/spool"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// writeFailureWindow is how long a failed write keeps the producer from
+	// being ready, unless a later write succeeds. Without traffic a failure
+	// would otherwise be reported forever.
+	writeFailureWindow = 30 * time.Second
+
+	// defaultStallTimeout is used when the producer has no stall timeout
+	// configured.
+	defaultStallTimeout = time.Minute
+)
+
+// ErrSpooling is returned by Ready when Kafka is unavailable but messages are
+// still accepted into the spool.
+var ErrSpooling = errors.New("kafka is unavailable, messages are spooled")
+
+// deliveryState records the outcome of the latest writes.
+type deliveryState struct {
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     error
+}
+
+func (d *deliveryState) record(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err != nil {
+		d.lastFailure = time.Now()
+		d.lastErr = err
+	} else {
+		d.lastSuccess = time.Now()
+	}
+}
+
+// failure returns the error of the latest write if it failed recently.
+func (d *deliveryState) failure() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.lastFailure.After(d.lastSuccess) && time.Since(d.lastFailure) < writeFailureWindow {
+		return fmt.Errorf("write failed %s ago: %w", time.Since(d.lastFailure).Round(time.Second), d.lastErr)
+	}
+	return nil
+}
+
+// Ready returns an error if the producer cannot currently deliver messages:
+// a recent write has failed, broker metadata cannot be fetched or the spool
+// is full. If Kafka is unavailable but the spool still accepts messages, the
+// error wraps ErrSpooling.
+func (k *KafkaProducer) Ready(ctx context.Context) error {
+	if k.spoolFull.Load() {
+		return spool.ErrFull
+	}
+
+	err := k.delivery.failure()
+	if err == nil && k.fetchMetadata != nil {
+		if err = k.fetchMetadata(ctx); err != nil {
+			err = fmt.Errorf("broker metadata unavailable: %w", err)
+		}
+	}
+
+	if err != nil && k.spool != nil {
+		return fmt.Errorf("%w: %v", ErrSpooling, err)
+	}
+	return err
+}
+
+// Live returns an error if a message received by ProduceMessagesFromChan has
+// not been handed over to Kafka or the spool within the stall timeout, which
+// means that producing is stuck.
+func (k *KafkaProducer) Live() error {
+	busySince := k.busySince.Load()
+	if busySince == 0 {
+		return nil
+	}
+
+	stallTimeout := k.stallTimeout
+	if stallTimeout == 0 {
+		stallTimeout = defaultStallTimeout
+	}
+
+	if busy := time.Since(time.Unix(0, busySince)); busy > stallTimeout {
+		return fmt.Errorf("producing a message has been stuck for %s", busy.Round(time.Second))
+	}
+	return nil
+}
+
+// metadataFetcher returns a function that fetches the metadata of topic from
+// the brokers, to check that they are reachable.
+func metadataFetcher(addr string, transport kafka.RoundTripper, topic string) func(context.Context) error {
+	client := &kafka.Client{
+		Addr:      kafka.TCP(addr),
+		Transport: transport,
+	}
+
+	return func(ctx context.Context) error {
+		metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+		if err != nil {
+			return err
+		}
+
+		for _, t := range metadata.Topics {
+			if t.Error != nil {
+				return t.Error
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package kafkawrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/spool"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReady(t *testing.T) {
+	unreachable := func(ctx context.Context) error { return errors.New("connection refused") }
+	reachable := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name          string
+		writer        IWriter
+		fetchMetadata func(context.Context) error
+		spool         bool
+		spoolFull     bool
+		wantErr       bool
+		wantSpooling  bool
+	}{
+		{name: "Delivering", writer: &MockWriter{}, fetchMetadata: reachable},
+		{name: "Write failed", writer: &FailingWriter{}, fetchMetadata: reachable, wantErr: true},
+		{name: "Brokers unreachable", writer: &MockWriter{}, fetchMetadata: unreachable, wantErr: true},
+		{name: "Spooling", writer: &FailingWriter{}, fetchMetadata: unreachable, spool: true, wantErr: true, wantSpooling: true},
+		{name: "Spool full", writer: &FailingWriter{}, fetchMetadata: unreachable, spool: true, spoolFull: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &KafkaProducer{
+				w:             tt.writer,
+				log:           zaptest.NewLogger(t).Sugar(),
+				fetchMetadata: tt.fetchMetadata,
+			}
+			if tt.spool {
+				sp, err := spool.Open(spool.Config{Dir: t.TempDir()})
+				if err != nil {
+					t.Fatalf("Failed to open spool: %v", err)
+				}
+				defer sp.Close()
+				producer.spool = sp
+			}
+			producer.spoolFull.Store(tt.spoolFull)
+
+			producer.writeMessages(kafka.Message{Topic: "status", Value: []byte("alive")})
+
+			err := producer.Ready(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ready() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrSpooling) != tt.wantSpooling {
+				t.Errorf("Ready() error = %v, want spooling %v", err, tt.wantSpooling)
+			}
+		})
+	}
+}
+
+func TestReadyRecoversFromWriteFailure(t *testing.T) {
+	producer := &KafkaProducer{log: zaptest.NewLogger(t).Sugar()}
+
+	producer.delivery.record(errors.New("kafka unavailable"))
+	if err := producer.Ready(context.Background()); err == nil {
+		t.Error("Expected producer not to be ready after a failed write")
+	}
+
+	producer.delivery.record(nil)
+	if err := producer.Ready(context.Background()); err != nil {
+		t.Errorf("Expected producer to be ready after a successful write, got %v", err)
+	}
+
+	producer.delivery.record(errors.New("kafka unavailable"))
+	producer.delivery.lastFailure = time.Now().Add(-writeFailureWindow)
+	if err := producer.Ready(context.Background()); err != nil {
+		t.Errorf("Expected an old write failure to be ignored, got %v", err)
+	}
+}
+
+func TestLive(t *testing.T) {
+	producer := &KafkaProducer{stallTimeout: time.Minute}
+
+	if err := producer.Live(); err != nil {
+		t.Errorf("Expected idle producer to be live, got %v", err)
+	}
+
+	producer.busySince.Store(time.Now().Add(-time.Second).UnixNano())
+	if err := producer.Live(); err != nil {
+		t.Errorf("Expected busy producer to be live, got %v", err)
+	}
+
+	producer.busySince.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if err := producer.Live(); err == nil {
+		t.Error("Expected stuck producer not to be live")
+	}
+}
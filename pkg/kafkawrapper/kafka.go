@@ -5,8 +5,11 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/Tuhis/edge-receiver/pkg/spool"
 	"github.com/segmentio/kafka-go"
 	"github.com/segmentio/kafka-go/sasl/scram"
 	"go.uber.org/zap"
@@ -22,14 +25,77 @@ type KafkaProducer struct {
 	log         *zap.SugaredLogger
 	statusTopic string
 	ownName     string
+
+	// async is set when the writer acknowledges messages through its
+	// completion handler instead of the return value of WriteMessages.
+	async bool
+
+	// pending counts messages handed to the async writer that have not
+	// been acknowledged yet.
+	pending atomic.Int64
+
+	// delivery records the outcome of the latest writes for Ready, and
+	// fetchMetadata, if set, checks that the brokers are reachable.
+	delivery      deliveryState
+	fetchMetadata func(context.Context) error
+
+	// busySince is the time, in Unix nanoseconds, at which
+	// ProduceMessagesFromChan started producing the current message, or
+	// zero when it is waiting for messages.
+	busySince    atomic.Int64
+	stallTimeout time.Duration
+
+	// spool, if set, persists messages on disk until they are delivered.
+	spool     *spool.Spool
+	spoolFull atomic.Bool
+	stopDrain chan struct{}
+	drainDone chan struct{}
 }
 
 type IKafkaProducer interface {
 	Close() error
 	ProduceMessage(message string, topic string) error
-	ProduceMessagesFromChan(messages <-chan string, topic string)
+	ProduceMessagesFromChan(messages <-chan Message, topic string)
+	PendingMessages() int
+	SpoolDepth() int
+	SpoolFull() bool
+	Ready(ctx context.Context) error
+	Live() error
+}
+
+// Message is a record passed to the producer through a channel.
+type Message struct {
+	// Key, if set, selects the partition of the message so that messages
+	// with the same key keep their order.
+	Key     []byte
+	Value   []byte
+	Headers []Header
+	// Time, if set, is the timestamp of the message. Otherwise the time the
+	// message is written is used.
+	Time time.Time
+
+	// Done, if set, is called once Kafka has acknowledged the message or
+	// the write has failed. Such messages are written to Kafka directly,
+	// bypassing the spool, since the caller is waiting for the broker's
+	// acknowledgement.
+	Done func(error)
 }
 
+// Header is a Kafka record header.
+type Header = kafka.Header
+
+// Names of the record headers attached to ingested messages.
+const (
+	HeaderEventID       = "event_id"
+	HeaderEventType     = "event_type"
+	HeaderSourceUuid    = "source_uuid"
+	HeaderDataFormat    = "data_format"
+	HeaderIngestedAt    = "ingested_at"
+	HeaderReceiver      = "receiver"
+	HeaderSchemaVersion = "schema_version"
+	HeaderTraceID       = "trace_id"
+)
+
 type AuthMechanism string
 
 const (
@@ -44,6 +110,10 @@ type envConfig struct {
 	AuthMechanism AuthMechanism
 	Username      string
 	Password      string
+	RequiredAcks  kafka.RequiredAcks
+	BatchTimeout  time.Duration
+	StallTimeout  time.Duration
+	Spool         *spool.Config
 }
 
 func NewKafkaProducer(logger *zap.SugaredLogger) IKafkaProducer {
@@ -66,21 +136,32 @@ func NewKafkaProducer(logger *zap.SugaredLogger) IKafkaProducer {
 		transport.SASL = mechanism
 	}
 
+	producer := &KafkaProducer{
+		log:           logger,
+		statusTopic:   config.StatusTopic,
+		ownName:       config.OwnName,
+		async:         true,
+		fetchMetadata: metadataFetcher(config.Brokers, transport, config.StatusTopic),
+		stallTimeout:  config.StallTimeout,
+	}
+
 	kafkaWriter := &kafka.Writer{
 		Addr:                   kafka.TCP(config.Brokers),
-		Balancer:               &kafka.LeastBytes{},
-		RequiredAcks:           kafka.RequireOne,
-		BatchTimeout:           1 * time.Second,
+		Balancer:               &keyAwareBalancer{keyed: &kafka.Hash{}, unkeyed: &kafka.LeastBytes{}},
+		RequiredAcks:           config.RequiredAcks,
+		BatchTimeout:           config.BatchTimeout,
 		Logger:                 kafka.LoggerFunc(logger.Infof),
 		ErrorLogger:            kafka.LoggerFunc(logger.Errorf),
 		AllowAutoTopicCreation: false,
 		Async:                  true,
-		Completion:             completionHandlerWithLogger(logger),
+		Completion:             producer.completionHandler(),
 		Transport:              transport, // Use the custom transport
 	}
 
+	producer.w = kafkaWriter
+
 	// Test the writer
-	err = kafkaWriter.WriteMessages(context.Background(),
+	err = producer.writeMessages(
 		kafka.Message{
 			Topic: config.StatusTopic,
 			Value: []byte(config.OwnName + " is alive"),
@@ -89,15 +170,26 @@ func NewKafkaProducer(logger *zap.SugaredLogger) IKafkaProducer {
 
 	if err != nil {
 		logger.Errorf("Failed to write message to Kafka: %v", err)
-		panic(err)
+
+		// Without a spool there is nowhere to keep messages until Kafka is
+		// reachable, so refuse to start.
+		if config.Spool == nil {
+			panic(err)
+		}
 	}
 
-	return &KafkaProducer{
-		w:           kafkaWriter,
-		log:         logger,
-		statusTopic: config.StatusTopic,
-		ownName:     config.OwnName,
+	if config.Spool != nil {
+		sp, err := spool.Open(*config.Spool)
+		if err != nil {
+			logger.Errorf("Failed to open spool in %s: %v", config.Spool.Dir, err)
+			panic(err)
+		}
+
+		logger.Infow("Spooling messages on disk", "dir", config.Spool.Dir, "depth", sp.Depth())
+		producer.startSpool(sp)
 	}
+
+	return producer
 }
 
 func configFromEnvironment() (*envConfig, error) {
@@ -123,6 +215,28 @@ func configFromEnvironment() (*envConfig, error) {
 		}
 	}
 
+	requiredAcks := kafka.RequireOne
+	if s := os.Getenv("KAFKA_REQUIRED_ACKS"); s != "" {
+		if err := requiredAcks.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+	}
+
+	batchTimeout, err := durationFromEnv("KAFKA_BATCH_TIMEOUT", 1*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	stallTimeout, err := durationFromEnv("KAFKA_PRODUCER_STALL_TIMEOUT", defaultStallTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	spoolConfig, err := spoolConfigFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
 	return &envConfig{
 		Brokers:       brokers,
 		StatusTopic:   statusTopic,
@@ -130,9 +244,76 @@ func configFromEnvironment() (*envConfig, error) {
 		AuthMechanism: authMechanism,
 		Username:      username,
 		Password:      password,
+		RequiredAcks:  requiredAcks,
+		BatchTimeout:  batchTimeout,
+		StallTimeout:  stallTimeout,
+		Spool:         spoolConfig,
 	}, nil
 }
 
+// spoolConfigFromEnvironment reads the spool configuration. It returns nil if
+// KAFKA_SPOOL_DIR is not set, which disables spooling.
+func spoolConfigFromEnvironment() (*spool.Config, error) {
+	dir := os.Getenv("KAFKA_SPOOL_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+
+	maxBytes, err := int64FromEnv("KAFKA_SPOOL_MAX_BYTES", 1<<30)
+	if err != nil {
+		return nil, err
+	}
+
+	segmentBytes, err := int64FromEnv("KAFKA_SPOOL_SEGMENT_BYTES", 64<<20)
+	if err != nil {
+		return nil, err
+	}
+
+	fsync, err := spool.ParseFsyncPolicy(os.Getenv("KAFKA_SPOOL_FSYNC"))
+	if err != nil {
+		return nil, err
+	}
+
+	fsyncInterval, err := durationFromEnv("KAFKA_SPOOL_FSYNC_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spool.Config{
+		Dir:           dir,
+		MaxBytes:      maxBytes,
+		SegmentBytes:  segmentBytes,
+		Fsync:         fsync,
+		FsyncInterval: fsyncInterval,
+	}, nil
+}
+
+func int64FromEnv(name string, defaultValue int64) (int64, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || i < 0 {
+		return 0, errors.New("invalid " + name + ": " + s)
+	}
+	return i, nil
+}
+
+func durationFromEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid " + name + ": " + s)
+	}
+	return d, nil
+}
+
 func parseAuthMechanism(s string) (AuthMechanism, error) {
 	switch s {
 	case string(AuthMechanismPlain), "":
@@ -144,43 +325,145 @@ func parseAuthMechanism(s string) (AuthMechanism, error) {
 	}
 }
 
-func completionHandlerWithLogger(logger *zap.SugaredLogger) func([]kafka.Message, error) {
+// keyAwareBalancer hashes keyed messages to partitions, so that all messages
+// with the same key end up in the same partition, and spreads unkeyed messages
+// by load.
+type keyAwareBalancer struct {
+	keyed   kafka.Balancer
+	unkeyed kafka.Balancer
+}
+
+func (b *keyAwareBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	if msg.Key != nil {
+		return b.keyed.Balance(msg, partitions...)
+	}
+	return b.unkeyed.Balance(msg, partitions...)
+}
+
+// completionHandler returns the completion handler of the async writer.
+// Delivery failures are logged rather than treated as fatal; messages that
+// carry a completion callback are notified of the outcome, which lets the
+// spool retry them.
+func (k *KafkaProducer) completionHandler() func([]kafka.Message, error) {
 	return func(messages []kafka.Message, err error) {
-		logger.Info(strconv.Itoa(len(messages)) + " messages produced")
+		k.pending.Add(-int64(len(messages)))
+		k.delivery.record(err)
+
 		if err != nil {
-			panic(err)
+			k.log.Errorf("Failed to produce %d messages: %v", len(messages), err)
+		} else {
+			k.log.Info(strconv.Itoa(len(messages)) + " messages produced")
+		}
+
+		notifyCompletion(messages, err)
+	}
+}
+
+// completionCallback is stored in kafka.Message.WriterData to be notified
+// when the message has been acknowledged or has failed.
+type completionCallback func(error)
+
+// pendingWrite replaces the WriterData of a message while it is written to
+// Kafka, to measure how long the write takes.
+type pendingWrite struct {
+	started  time.Time
+	callback completionCallback
+}
+
+// notifyCompletion records the outcome of the write of every message and
+// calls their completion callbacks.
+func notifyCompletion(messages []kafka.Message, err error) {
+	for _, message := range messages {
+		write, ok := message.WriterData.(*pendingWrite)
+		if !ok {
+			continue
+		}
+
+		metrics.WriteDuration.WithLabelValues(message.Topic).Observe(time.Since(write.started).Seconds())
+		if err != nil {
+			metrics.MessagesFailed.WithLabelValues(message.Topic).Inc()
+		} else {
+			metrics.MessagesProduced.WithLabelValues(message.Topic).Inc()
+		}
+
+		if write.callback != nil {
+			write.callback(err)
 		}
 	}
 }
 
+// PendingMessages returns the number of messages written to Kafka that have
+// not been acknowledged yet. Spooled messages are not included.
+func (k *KafkaProducer) PendingMessages() int {
+	return int(k.pending.Load())
+}
+
+// Close stops delivering spooled messages, then flushes all buffered
+// messages to Kafka and closes the writer.
 func (k *KafkaProducer) Close() error {
+	if k.spool != nil {
+		close(k.stopDrain)
+		<-k.drainDone
+
+		if err := k.spool.Close(); err != nil {
+			k.log.Errorf("Failed to close spool: %v", err)
+		}
+	}
+
 	return k.w.Close()
 }
 
 func (k *KafkaProducer) ProduceMessage(message string, topic string) error {
-	return k.w.WriteMessages(context.Background(),
-		kafka.Message{
-			Topic: topic,
-			Value: []byte(message),
-		},
-	)
+	return k.produce(kafka.Message{
+		Topic: topic,
+		Value: []byte(message),
+	})
 }
 
 func (k *KafkaProducer) ProduceRawMessage(message []byte, topic string) error {
-	return k.w.WriteMessages(context.Background(),
-		kafka.Message{
-			Topic: topic,
-			Value: message,
-		},
-	)
+	return k.produce(kafka.Message{
+		Topic: topic,
+		Value: message,
+	})
 }
 
-func (k *KafkaProducer) ProduceMessagesFromChan(messages <-chan string, topic string) {
+// ProduceMessagesFromChan produces every message received from the channel
+// to the topic until the channel is closed. The name of this receiver
+// instance is added to the headers of every message.
+func (k *KafkaProducer) ProduceMessagesFromChan(messages <-chan Message, topic string) {
 	for message := range messages {
-		err := k.ProduceMessage(message, topic)
-		if err != nil {
+		k.busySince.Store(time.Now().UnixNano())
+		metrics.ChannelBufferedBytes.Sub(float64(len(message.Value)))
+
+		kafkaMessage := kafka.Message{
+			Topic:   topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: message.Headers,
+			Time:    message.Time,
+		}
+
+		if k.ownName != "" {
+			kafkaMessage.Headers = append(kafkaMessage.Headers, Header{Key: HeaderReceiver, Value: []byte(k.ownName)})
+		}
+
+		var err error
+
+		if message.Done != nil {
+			// The sender is waiting for the acknowledgement, which also
+			// reports any error, so the message must not be spooled.
+			kafkaMessage.WriterData = completionCallback(message.Done)
+			err = k.writeMessages(kafkaMessage)
+		} else {
+			err = k.produce(kafkaMessage)
+		}
+
+		// Messages dropped by a full spool have already been logged
+		if err != nil && !errors.Is(err, spool.ErrFull) {
 			k.log.Errorf("Failed to produce message: %v", err)
 		}
+
+		k.busySince.Store(0)
 	}
 }
 
@@ -192,3 +475,41 @@ func (k *KafkaProducer) ProduceMessagesFromRawChan(messages <-chan []byte, topic
 		}
 	}
 }
+
+// produce appends the message to the spool, or writes it directly to Kafka
+// when spooling is disabled.
+func (k *KafkaProducer) produce(message kafka.Message) error {
+	if k.spool != nil {
+		return k.appendToSpool(message)
+	}
+
+	return k.writeMessages(message)
+}
+
+// writeMessages writes messages to Kafka. Completion callbacks of the messages
+// are called once the outcome of the write is known.
+func (k *KafkaProducer) writeMessages(messages ...kafka.Message) error {
+	started := time.Now()
+	for i := range messages {
+		callback, _ := messages[i].WriterData.(completionCallback)
+		messages[i].WriterData = &pendingWrite{started: started, callback: callback}
+	}
+
+	if k.async {
+		k.pending.Add(int64(len(messages)))
+	}
+
+	err := k.w.WriteMessages(context.Background(), messages...)
+
+	// A synchronous write has already completed, and a failed asynchronous
+	// write never reaches the completion handler.
+	if !k.async || err != nil {
+		k.delivery.record(err)
+		if k.async {
+			k.pending.Add(-int64(len(messages)))
+		}
+		notifyCompletion(messages, err)
+	}
+
+	return err
+}
@@ -2,10 +2,13 @@ package kafkawrapper
 
 import (
 	"context"
+	"errors"
 	"os"
 	"reflect"
 	"testing"
 
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap/zaptest"
 )
@@ -19,6 +22,7 @@ func TestConfigFromEnvironment(t *testing.T) {
 		authMechanism string
 		username      string
 		password      string
+		requiredAcks  string
 		wantErr       bool
 	}{
 		{
@@ -54,6 +58,22 @@ func TestConfigFromEnvironment(t *testing.T) {
 			ownName:     "test",
 			wantErr:     false,
 		},
+		{
+			name:         "All ISR acknowledgements",
+			brokers:      "localhost:9092",
+			statusTopic:  "status",
+			ownName:      "test",
+			requiredAcks: "all",
+			wantErr:      false,
+		},
+		{
+			name:         "Invalid KAFKA_REQUIRED_ACKS",
+			brokers:      "localhost:9092",
+			statusTopic:  "status",
+			ownName:      "test",
+			requiredAcks: "some",
+			wantErr:      true,
+		},
 		{
 			name:        "Missing KAFKA_BROKERS",
 			statusTopic: "status",
@@ -82,6 +102,7 @@ func TestConfigFromEnvironment(t *testing.T) {
 			os.Setenv("KAFKA_AUTH_MECHANISM", tt.authMechanism)
 			os.Setenv("KAFKA_USERNAME", tt.username)
 			os.Setenv("KAFKA_PASSWORD", tt.password)
+			os.Setenv("KAFKA_REQUIRED_ACKS", tt.requiredAcks)
 
 			_, err := configFromEnvironment()
 
@@ -132,8 +153,8 @@ func TestKafkaProducer(t *testing.T) {
 	}
 
 	// Test ProduceMessagesFromChan
-	messageChan := make(chan string, 1)
-	messageChan <- "test message from chan"
+	messageChan := make(chan Message, 1)
+	messageChan <- Message{Value: []byte("test message from chan")}
 	close(messageChan)
 	producer.ProduceMessagesFromChan(messageChan, "test topic")
 
@@ -143,14 +164,128 @@ func TestKafkaProducer(t *testing.T) {
 	close(rawMessageChan)
 	producer.ProduceMessagesFromRawChan(rawMessageChan, "test topic")
 
-	// Check that the messages were written correctly
+	// Check that the messages were written correctly. WriterData only
+	// tracks the write in progress.
+	for i := range writer.Messages {
+		writer.Messages[i].WriterData = nil
+	}
 	expectedMessages := []kafka.Message{
 		{Topic: "test topic", Value: []byte("test message")},
 		{Topic: "test topic", Value: []byte("test raw message")},
-		{Topic: "test topic", Value: []byte("test message from chan")},
+		{Topic: "test topic", Value: []byte("test message from chan"), Headers: []kafka.Header{{Key: HeaderReceiver, Value: []byte("test")}}},
 		{Topic: "test topic", Value: []byte("test raw message from chan")},
 	}
 	if !reflect.DeepEqual(writer.Messages, expectedMessages) {
 		t.Errorf("Expected messages %v, got %v", expectedMessages, writer.Messages)
 	}
 }
+
+type FailingWriter struct{}
+
+func (fw *FailingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return errors.New("kafka unavailable")
+}
+
+func (fw *FailingWriter) Close() error {
+	return nil
+}
+
+func TestProduceMessagesFromChanAcknowledgesMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		writer  IWriter
+		wantErr bool
+	}{
+		{name: "Acknowledged", writer: &MockWriter{}, wantErr: false},
+		{name: "Failed", writer: &FailingWriter{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &KafkaProducer{
+				w:   tt.writer,
+				log: zaptest.NewLogger(t).Sugar(),
+			}
+
+			acks := make(chan error, 1)
+			messageChan := make(chan Message, 1)
+			messageChan <- Message{
+				Value: []byte("test message"),
+				Done:  func(err error) { acks <- err },
+			}
+			close(messageChan)
+
+			producer.ProduceMessagesFromChan(messageChan, "test topic")
+
+			select {
+			case err := <-acks:
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Expected acknowledgement error %v, got %v", tt.wantErr, err)
+				}
+			default:
+				t.Error("Message was not acknowledged")
+			}
+		})
+	}
+}
+
+type fixedBalancer int
+
+func (b fixedBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	return int(b)
+}
+
+func TestKeyAwareBalancer(t *testing.T) {
+	balancer := &keyAwareBalancer{keyed: &kafka.Hash{}, unkeyed: fixedBalancer(-1)}
+	partitions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+	if got := balancer.Balance(kafka.Message{Value: []byte("value")}, partitions...); got != -1 {
+		t.Errorf("Expected unkeyed message to use the unkeyed balancer, got partition %d", got)
+	}
+
+	// Messages with the same key always go to the same partition
+	first := balancer.Balance(kafka.Message{Key: []byte("E8:D3:AD:C4:6E:18")}, partitions...)
+	for i := 0; i < 10; i++ {
+		got := balancer.Balance(kafka.Message{Key: []byte("E8:D3:AD:C4:6E:18"), Value: []byte{byte(i)}}, partitions...)
+		if got != first {
+			t.Fatalf("Expected keyed messages in partition %d, got %d", first, got)
+		}
+	}
+}
+
+func TestWriteMessagesRecordsMetrics(t *testing.T) {
+	tests := []struct {
+		name         string
+		writer       IWriter
+		topic        string
+		wantProduced float64
+		wantFailed   float64
+	}{
+		{name: "Produced", writer: &MockWriter{}, topic: "metrics-produced", wantProduced: 2},
+		{name: "Failed", writer: &FailingWriter{}, topic: "metrics-failed", wantFailed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &KafkaProducer{
+				w:   tt.writer,
+				log: zaptest.NewLogger(t).Sugar(),
+			}
+
+			producer.writeMessages(
+				kafka.Message{Topic: tt.topic, Value: []byte("a")},
+				kafka.Message{Topic: tt.topic, Value: []byte("b")},
+			)
+
+			if got := testutil.ToFloat64(metrics.MessagesProduced.WithLabelValues(tt.topic)); got != tt.wantProduced {
+				t.Errorf("Expected %v produced messages, got %v", tt.wantProduced, got)
+			}
+			if got := testutil.ToFloat64(metrics.MessagesFailed.WithLabelValues(tt.topic)); got != tt.wantFailed {
+				t.Errorf("Expected %v failed messages, got %v", tt.wantFailed, got)
+			}
+			if got := testutil.CollectAndCount(metrics.WriteDuration, "edge_receiver_kafka_write_duration_seconds"); got == 0 {
+				t.Error("Expected write duration to be observed")
+			}
+		})
+	}
+}
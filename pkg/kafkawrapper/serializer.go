@@ -0,0 +1,80 @@
+package kafkawrapper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+// Serializer encodes the events produced to the ingress topic into the values
+// of its records.
+type Serializer interface {
+	Serialize(event *events.RuuviKafkaEvent) ([]byte, error)
+}
+
+// Encoding is the encoding of the values of a topic.
+type Encoding string
+
+const (
+	EncodingJSON     Encoding = "json"
+	EncodingProtobuf Encoding = "protobuf"
+	// EncodingAvro and EncodingJSONSchema keep the schema in a Schema
+	// Registry.
+	EncodingAvro       Encoding = "avro"
+	EncodingJSONSchema Encoding = "json-schema"
+)
+
+// ParseEncoding returns the encoding with the given name.
+func ParseEncoding(s string) (Encoding, error) {
+	switch encoding := Encoding(s); encoding {
+	case EncodingJSON, EncodingProtobuf, EncodingAvro, EncodingJSONSchema:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("unknown encoding %q", s)
+	}
+}
+
+// TopicEncoding returns the encoding of the events produced to the topic. It
+// is read from KAFKA_TOPIC_ENCODINGS, a comma-separated list of topic=encoding
+// pairs, and defaults to KAFKA_VALUE_SERIALIZER or else JSON. It applies to
+// the ingress topic only, as the status messages are plain text.
+func TopicEncoding(topic string) (Encoding, error) {
+	name := os.Getenv("KAFKA_VALUE_SERIALIZER")
+
+	if s := os.Getenv("KAFKA_TOPIC_ENCODINGS"); s != "" {
+		for _, pair := range strings.Split(s, ",") {
+			t, encoding, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return "", fmt.Errorf("invalid KAFKA_TOPIC_ENCODINGS entry %q, expected topic=encoding", pair)
+			}
+			if t == topic {
+				name = encoding
+			}
+		}
+	}
+
+	if name == "" {
+		return EncodingJSON, nil
+	}
+	return ParseEncoding(name)
+}
+
+// ProtobufSerializer encodes events in protobuf.
+type ProtobufSerializer struct {
+	format events.EventFormat
+}
+
+// NewProtobufSerializer returns a serializer encoding events in the given
+// format in protobuf.
+func NewProtobufSerializer(format events.EventFormat) (*ProtobufSerializer, error) {
+	if format == events.BothFormats {
+		return nil, fmt.Errorf("event format %q is not supported in protobuf", format)
+	}
+	return &ProtobufSerializer{format: format}, nil
+}
+
+func (s *ProtobufSerializer) Serialize(event *events.RuuviKafkaEvent) ([]byte, error) {
+	return event.MarshalProto(s.format)
+}
@@ -0,0 +1,54 @@
+package kafkawrapper
+
+import (
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+func TestTopicEncoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer string
+		encodings  string
+		want       Encoding
+		wantErr    bool
+	}{
+		{name: "Default", want: EncodingJSON},
+		{name: "Serializer of every topic", serializer: "avro", want: EncodingAvro},
+		{name: "Encoding of the topic", serializer: "avro", encodings: "other=json, ruuvi=protobuf", want: EncodingProtobuf},
+		{name: "Encoding of another topic", encodings: "other=protobuf", want: EncodingJSON},
+		{name: "Unknown encoding", encodings: "ruuvi=xml", wantErr: true},
+		{name: "Invalid entry", encodings: "ruuvi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KAFKA_VALUE_SERIALIZER", tt.serializer)
+			t.Setenv("KAFKA_TOPIC_ENCODINGS", tt.encodings)
+
+			got, err := TopicEncoding("ruuvi")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TopicEncoding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TopicEncoding() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProtobufSerializer(t *testing.T) {
+	if _, err := NewProtobufSerializer(events.BothFormats); err == nil {
+		t.Error("NewProtobufSerializer() expected an error for both formats")
+	}
+
+	serializer, err := NewProtobufSerializer(events.EnvelopeFormat)
+	if err != nil {
+		t.Fatalf("NewProtobufSerializer() error = %v", err)
+	}
+	event := &events.RuuviKafkaEvent{Type: events.NewMeasurement, Data: &events.NewMeasurementData{}}
+	if value, err := serializer.Serialize(event); err != nil || len(value) == 0 {
+		t.Errorf("ProtobufSerializer.Serialize() = %v, %v", value, err)
+	}
+}
@@ -0,0 +1,228 @@
+package kafkawrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/Tuhis/edge-receiver/pkg/spool"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// spoolBatchSize is the maximum number of spooled messages delivered at
+	// once.
+	spoolBatchSize = 500
+
+	spoolRetryMinBackoff = 500 * time.Millisecond
+	spoolRetryMaxBackoff = 30 * time.Second
+
+	// spoolPollInterval is how often the spool is checked for messages in
+	// case an append notification was missed.
+	spoolPollInterval = time.Second
+
+	// spoolReportInterval is how often the spool depth is logged while there
+	// are undelivered messages.
+	spoolReportInterval = 30 * time.Second
+)
+
+// spooledMessage is the on-disk representation of a message in the spool.
+type spooledMessage struct {
+	Topic   string         `json:"topic"`
+	Key     []byte         `json:"key,omitempty"`
+	Value   []byte         `json:"value"`
+	Headers []kafka.Header `json:"headers,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// startSpool makes the producer persist messages in sp and starts delivering
+// them to Kafka in the background, beginning with any messages left over
+// from a previous run.
+func (k *KafkaProducer) startSpool(sp *spool.Spool) {
+	k.spool = sp
+	k.stopDrain = make(chan struct{})
+	k.drainDone = make(chan struct{})
+
+	go k.drainSpool()
+}
+
+// SpoolDepth returns the number of messages waiting in the spool.
+func (k *KafkaProducer) SpoolDepth() int {
+	if k.spool == nil {
+		return 0
+	}
+	return k.spool.Depth()
+}
+
+// SpoolFull reports whether the spool rejected the latest message because it
+// is full. Messages produced while it is full are dropped.
+func (k *KafkaProducer) SpoolFull() bool {
+	return k.spoolFull.Load()
+}
+
+func (k *KafkaProducer) appendToSpool(message kafka.Message) error {
+	record, err := json.Marshal(spooledMessage{
+		Topic:   message.Topic,
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: message.Headers,
+		Time:    message.Time,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = k.spool.Append(record)
+	if errors.Is(err, spool.ErrFull) {
+		k.spoolFull.Store(true)
+		k.log.Errorf("Dropped message to %s, the spool is full with %d messages", message.Topic, k.spool.Depth())
+		metrics.SpoolDropped.Inc()
+	} else if err == nil {
+		k.spoolFull.Store(false)
+	}
+	return err
+}
+
+// drainSpool delivers spooled messages to Kafka in order. A batch is removed
+// from the spool only after every message in it has been acknowledged;
+// otherwise the whole batch is retried with exponential backoff. Corrupted
+// records are dropped, as retrying them would block the spool forever.
+func (k *KafkaProducer) drainSpool() {
+	defer close(k.drainDone)
+
+	backoff := spoolRetryMinBackoff
+	lastReport := time.Now()
+
+	for {
+		if depth := k.spool.Depth(); depth > 0 && time.Since(lastReport) >= spoolReportInterval {
+			k.log.Infow("Spool depth", "messages", depth, "bytes", k.spool.Size())
+			lastReport = time.Now()
+		}
+
+		batch, err := k.spool.Read(spoolBatchSize)
+		if errors.Is(err, spool.ErrClosed) {
+			return
+		}
+		if err != nil {
+			k.log.Errorf("Failed to read from spool: %v", err)
+			if !k.waitForRetry(&backoff) {
+				return
+			}
+			continue
+		}
+
+		if len(batch.Records) == 0 && batch.Corrupted == 0 {
+			select {
+			case <-k.stopDrain:
+				return
+			case <-k.spool.Notify():
+			case <-time.After(spoolPollInterval):
+			}
+			continue
+		}
+
+		messages, undecodable := decodeSpooledMessages(batch.Records)
+		if err := k.writeAndWait(messages); err != nil {
+			k.log.Warnf("Failed to deliver %d spooled messages, %d waiting in spool: %v",
+				len(batch.Records), k.spool.Depth(), err)
+			if !k.waitForRetry(&backoff) {
+				return
+			}
+			continue
+		}
+
+		backoff = spoolRetryMinBackoff
+
+		if err := k.spool.Commit(batch); err != nil {
+			k.log.Errorf("Failed to commit delivered messages to spool: %v", err)
+			continue
+		}
+
+		if corrupted := batch.Corrupted + undecodable; corrupted > 0 {
+			k.log.Errorf("Dropped %d corrupted spool records", corrupted)
+			metrics.SpoolCorrupted.Add(float64(corrupted))
+		}
+
+		// Committing freed space for new messages
+		k.spoolFull.Store(false)
+	}
+}
+
+// waitForRetry sleeps for the current backoff and doubles it. It returns
+// false if the producer is closed while waiting.
+func (k *KafkaProducer) waitForRetry(backoff *time.Duration) bool {
+	select {
+	case <-k.stopDrain:
+		return false
+	case <-time.After(*backoff):
+	}
+
+	*backoff *= 2
+	if *backoff > spoolRetryMaxBackoff {
+		*backoff = spoolRetryMaxBackoff
+	}
+	return true
+}
+
+// writeAndWait writes messages to Kafka and waits until every one of them has
+// been acknowledged. It returns the first delivery error.
+func (k *KafkaProducer) writeAndWait(messages []kafka.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	wg.Add(len(messages))
+	for i := range messages {
+		messages[i].WriterData = completionCallback(func(err error) {
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+			wg.Done()
+		})
+	}
+
+	if err := k.writeMessages(messages...); err != nil {
+		return err
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// decodeSpooledMessages converts spool records back into Kafka messages.
+// Records that cannot be decoded are skipped, as retrying them would block
+// the spool forever, and their number is returned.
+func decodeSpooledMessages(records [][]byte) ([]kafka.Message, int) {
+	messages := make([]kafka.Message, 0, len(records))
+	undecodable := 0
+
+	for _, record := range records {
+		var message spooledMessage
+		if err := json.Unmarshal(record, &message); err != nil {
+			undecodable++
+			continue
+		}
+
+		messages = append(messages, kafka.Message{
+			Topic:   message.Topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: message.Headers,
+			Time:    message.Time,
+		})
+	}
+
+	return messages, undecodable
+}
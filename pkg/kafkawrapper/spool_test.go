@@ -0,0 +1,278 @@
+package kafkawrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/metrics"
+	"github.com/Tuhis/edge-receiver/pkg/spool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap/zaptest"
+)
+
+// FlakyWriter fails every write until it is told that Kafka is reachable.
+type FlakyWriter struct {
+	mu        sync.Mutex
+	available bool
+	Messages  []kafka.Message
+}
+
+func (fw *FlakyWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if !fw.available {
+		return errors.New("kafka unavailable")
+	}
+
+	for _, msg := range msgs {
+		fw.Messages = append(fw.Messages, kafka.Message{Topic: msg.Topic, Key: msg.Key, Value: msg.Value, Headers: msg.Headers})
+	}
+	return nil
+}
+
+func (fw *FlakyWriter) Close() error {
+	return nil
+}
+
+func (fw *FlakyWriter) setAvailable(available bool) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	fw.available = available
+}
+
+func (fw *FlakyWriter) written() []kafka.Message {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return append([]kafka.Message(nil), fw.Messages...)
+}
+
+func newSpooledProducer(t *testing.T, writer IWriter, dir string) *KafkaProducer {
+	t.Helper()
+
+	sp, err := spool.Open(spool.Config{Dir: dir, Fsync: spool.FsyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &KafkaProducer{
+		w:   writer,
+		log: zaptest.NewLogger(t).Sugar(),
+	}
+	producer.startSpool(sp)
+	return producer
+}
+
+func waitForSpoolDepth(t *testing.T, producer *KafkaProducer, depth int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for producer.SpoolDepth() != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("spool depth = %d, want %d", producer.SpoolDepth(), depth)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKafkaProducerSpoolsWhileKafkaIsUnavailable(t *testing.T) {
+	dir := t.TempDir()
+	writer := &FlakyWriter{}
+
+	producer := newSpooledProducer(t, writer, dir)
+
+	for _, message := range []string{"first", "second"} {
+		if err := producer.ProduceMessage(message, "test topic"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	messageChan := make(chan Message, 1)
+	messageChan <- Message{
+		Key:     []byte("key"),
+		Value:   []byte("third"),
+		Headers: []Header{{Key: HeaderTraceID, Value: []byte("trace")}},
+	}
+	close(messageChan)
+	producer.ProduceMessagesFromChan(messageChan, "test topic")
+
+	if depth := producer.SpoolDepth(); depth != 3 {
+		t.Errorf("Expected 3 spooled messages, got %d", depth)
+	}
+
+	// Restart the producer before Kafka becomes reachable
+	producer.Close()
+	producer = newSpooledProducer(t, writer, dir)
+	defer producer.Close()
+
+	writer.setAvailable(true)
+	waitForSpoolDepth(t, producer, 0)
+
+	expectedMessages := []kafka.Message{
+		{Topic: "test topic", Value: []byte("first")},
+		{Topic: "test topic", Value: []byte("second")},
+		{Topic: "test topic", Key: []byte("key"), Value: []byte("third"), Headers: []kafka.Header{{Key: HeaderTraceID, Value: []byte("trace")}}},
+	}
+	if got := writer.written(); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("Expected messages %v, got %v", expectedMessages, got)
+	}
+}
+
+func TestKafkaProducerDropsCorruptedSpoolRecords(t *testing.T) {
+	dir := t.TempDir()
+	writer := &FlakyWriter{}
+
+	producer := newSpooledProducer(t, writer, dir)
+	for _, message := range []string{"first", "second", "third"} {
+		if err := producer.ProduceMessage(message, "test topic"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	producer.Close()
+
+	// Flip a byte in the value of the second message
+	path := filepath.Join(dir, "00000000000000000000.seg")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := bytes.Index(b, []byte(base64.StdEncoding.EncodeToString([]byte("second"))))
+	if i < 0 {
+		t.Fatal("second message not found in the spool")
+	}
+	b[i] ^= 0xff
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted := testutil.ToFloat64(metrics.SpoolCorrupted)
+
+	producer = newSpooledProducer(t, writer, dir)
+	defer producer.Close()
+
+	writer.setAvailable(true)
+	waitForSpoolDepth(t, producer, 0)
+
+	expectedMessages := []kafka.Message{
+		{Topic: "test topic", Value: []byte("first")},
+		{Topic: "test topic", Value: []byte("third")},
+	}
+	if got := writer.written(); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("Expected messages %v, got %v", expectedMessages, got)
+	}
+	if got := testutil.ToFloat64(metrics.SpoolCorrupted) - corrupted; got != 1 {
+		t.Errorf("Expected 1 corrupted message to be counted, got %v", got)
+	}
+}
+
+func TestKafkaProducerDropsMessagesWhenSpoolIsFull(t *testing.T) {
+	sp, err := spool.Open(spool.Config{Dir: t.TempDir(), MaxBytes: 120, Fsync: spool.FsyncNever})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &KafkaProducer{
+		w:   &FlakyWriter{},
+		log: zaptest.NewLogger(t).Sugar(),
+	}
+	producer.startSpool(sp)
+	defer producer.Close()
+
+	dropped := testutil.ToFloat64(metrics.SpoolDropped)
+
+	if err := producer.ProduceMessage("first", "test topic"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if producer.SpoolFull() {
+		t.Error("Expected the spool not to be full")
+	}
+
+	if err := producer.ProduceMessage("second", "test topic"); !errors.Is(err, spool.ErrFull) {
+		t.Fatalf("Expected %v, got %v", spool.ErrFull, err)
+	}
+	if !producer.SpoolFull() {
+		t.Error("Expected the spool to be full")
+	}
+	if got := testutil.ToFloat64(metrics.SpoolDropped) - dropped; got != 1 {
+		t.Errorf("Expected 1 dropped message to be counted, got %v", got)
+	}
+}
+
+func TestSpoolConfigFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *spool.Config
+		wantErr bool
+	}{
+		{
+			name: "Spool disabled",
+			env:  map[string]string{},
+			want: nil,
+		},
+		{
+			name: "Defaults",
+			env:  map[string]string{"KAFKA_SPOOL_DIR": "/var/spool/edge-receiver"},
+			want: &spool.Config{
+				Dir:           "/var/spool/edge-receiver",
+				MaxBytes:      1 << 30,
+				SegmentBytes:  64 << 20,
+				Fsync:         spool.FsyncInterval,
+				FsyncInterval: time.Second,
+			},
+		},
+		{
+			name: "All settings",
+			env: map[string]string{
+				"KAFKA_SPOOL_DIR":            "/spool",
+				"KAFKA_SPOOL_MAX_BYTES":      "1048576",
+				"KAFKA_SPOOL_SEGMENT_BYTES":  "65536",
+				"KAFKA_SPOOL_FSYNC":          "always",
+				"KAFKA_SPOOL_FSYNC_INTERVAL": "5s",
+			},
+			want: &spool.Config{
+				Dir:           "/spool",
+				MaxBytes:      1048576,
+				SegmentBytes:  65536,
+				Fsync:         spool.FsyncAlways,
+				FsyncInterval: 5 * time.Second,
+			},
+		},
+		{
+			name:    "Invalid fsync policy",
+			env:     map[string]string{"KAFKA_SPOOL_DIR": "/spool", "KAFKA_SPOOL_FSYNC": "sometimes"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid max bytes",
+			env:     map[string]string{"KAFKA_SPOOL_DIR": "/spool", "KAFKA_SPOOL_MAX_BYTES": "lots"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"KAFKA_SPOOL_DIR", "KAFKA_SPOOL_MAX_BYTES", "KAFKA_SPOOL_SEGMENT_BYTES", "KAFKA_SPOOL_FSYNC", "KAFKA_SPOOL_FSYNC_INTERVAL"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			got, err := spoolConfigFromEnvironment()
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("spoolConfigFromEnvironment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spoolConfigFromEnvironment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
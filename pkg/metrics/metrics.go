@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "edge_receiver"
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by handler and status code.",
+	}, []string{"handler", "code"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to respond to HTTP requests by handler.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler"})
+
+	// Events counts ingested events by event type and the HTTP status code
+	// of their outcome.
+	Events = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_total",
+		Help:      "Ingested events by event type and status code.",
+	}, []string{"event_type", "status"})
+
+	// ValidationFailures counts rejected events and request bodies by the
+	// reason they were rejected.
+	ValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_failures_total",
+		Help:      "Rejected events by reason.",
+	}, []string{"reason"})
+
+	// RateLimited counts events rejected by the rate limits by the type of
+	// the key that was limited.
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Events rejected by rate limits by key type.",
+	}, []string{"key_type"})
+
+	// Duplicates counts repeated copies of measurements that were
+	// acknowledged but not produced.
+	Duplicates = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_events_total",
+		Help:      "Repeated copies of measurements that were not produced.",
+	})
+
+	// ClockSkew observes how far the observation times supplied by gateways
+	// are ahead of the time the events were received. Buffered uploads show
+	// up as large negative values.
+	ClockSkew = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "clock_skew_seconds",
+		Help:      "Observation time of events minus the time they were received.",
+		Buckets:   []float64{-3600, -300, -60, -10, -1, 0, 1, 10, 60, 300, 3600},
+	})
+
+	// FutureTimestamps counts events observed further in the future than
+	// the clock skew tolerance by the action taken.
+	FutureTimestamps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "future_timestamps_total",
+		Help:      "Events observed too far in the future by action.",
+	}, []string{"action"})
+
+	// RangeViolations counts readings outside their bounds by field and the
+	// action taken.
+	RangeViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "range_violations_total",
+		Help:      "Readings outside their bounds by field and action.",
+	}, []string{"field", "action"})
+
+	// MessagesProduced counts messages acknowledged by Kafka per topic.
+	MessagesProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_produced_total",
+		Help:      "Messages acknowledged by Kafka by topic.",
+	}, []string{"topic"})
+
+	// MessagesFailed counts messages whose write to Kafka failed per topic.
+	MessagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_failed_total",
+		Help:      "Messages that could not be written to Kafka by topic.",
+	}, []string{"topic"})
+
+	// WriteDuration observes the time from handing a message to the Kafka
+	// writer until its write has completed.
+	WriteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_write_duration_seconds",
+		Help:      "Time taken to write messages to Kafka by topic.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"topic"})
+
+	// SpoolCorrupted counts spooled messages that were dropped because they
+	// could not be read back from the spool.
+	SpoolCorrupted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spool_corrupted_messages_total",
+		Help:      "Spooled messages dropped because they were corrupted.",
+	})
+
+	// SpoolDropped counts accepted messages that were dropped because the
+	// spool was full.
+	SpoolDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spool_dropped_messages_total",
+		Help:      "Messages dropped because the spool was full.",
+	})
+
+	// ChannelBufferedBytes is the size of the message values waiting in the
+	// messaging channel.
+	ChannelBufferedBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "channel_buffered_bytes",
+		Help:      "Bytes of messages waiting in the messaging channel.",
+	})
+)
+
+// Handler returns the handler serving the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// InstrumentHandler counts the requests of handler and observes their
+// latency, labeled with the given handler name.
+func InstrumentHandler(name string, handler http.HandlerFunc) http.Handler {
+	labels := prometheus.Labels{"handler": name}
+
+	return promhttp.InstrumentHandlerDuration(requestDuration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(requests.MustCurryWith(labels), handler))
+}
+
+// RegisterGauge registers a gauge whose value is read from f when the
+// metrics are collected.
+func RegisterGauge(name, help string, f func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, f)
+}
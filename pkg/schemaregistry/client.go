@@ -0,0 +1,171 @@
+// Package schemaregistry serializes events for a Confluent Schema Registry.
+// Records are prefixed with the ID of their schema in the registry, so
+// consumers can look the schema up to decode them.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaType is the type of a schema in the registry.
+type SchemaType string
+
+const (
+	Avro       SchemaType = "AVRO"
+	JSONSchema SchemaType = "JSON"
+)
+
+// contentType is the media type of the requests and responses of the
+// registry API.
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// ErrSchemaNotFound is returned when looking up a schema that has not been
+// registered under the subject.
+var ErrSchemaNotFound = errors.New("schema is not registered")
+
+// Client looks schemas up in a Schema Registry and registers them. The IDs
+// of schemas are cached, so each schema is sent to the registry only once.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	username   string
+	password   string
+
+	mu  sync.Mutex
+	ids map[schemaKey]int
+}
+
+type schemaKey struct {
+	subject string
+	schema  string
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithBasicAuth authenticates to the registry with a username and password.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for the requests to the registry.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient returns a client of the registry at the given URL.
+func NewClient(registryURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		url:        strings.TrimSuffix(registryURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		ids:        make(map[schemaKey]int),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type schemaRequest struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+}
+
+type schemaResponse struct {
+	ID int `json:"id"`
+}
+
+type errorResponse struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register registers the schema under the subject and returns its ID. A
+// schema that is already registered keeps its ID.
+func (c *Client) Register(ctx context.Context, subject string, schemaType SchemaType, schema string) (int, error) {
+	return c.schemaID(ctx, "/subjects/"+url.PathEscape(subject)+"/versions", subject, schemaType, schema)
+}
+
+// Lookup returns the ID of the schema registered under the subject, or
+// ErrSchemaNotFound.
+func (c *Client) Lookup(ctx context.Context, subject string, schemaType SchemaType, schema string) (int, error) {
+	return c.schemaID(ctx, "/subjects/"+url.PathEscape(subject), subject, schemaType, schema)
+}
+
+func (c *Client) schemaID(ctx context.Context, path, subject string, schemaType SchemaType, schema string) (int, error) {
+	key := schemaKey{subject: subject, schema: schema}
+
+	c.mu.Lock()
+	id, ok := c.ids[key]
+	c.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	// Avro is the default type, which registries predating other types do
+	// not accept explicitly
+	request := schemaRequest{Schema: schema}
+	if schemaType != Avro {
+		request.SchemaType = schemaType
+	}
+
+	var response schemaResponse
+	if err := c.post(ctx, path, request, &response); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.ids[key] = response.ID
+	c.mu.Unlock()
+	return response.ID, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, body, result interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrSchemaNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResponse errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResponse); err != nil || errResponse.Message == "" {
+			return fmt.Errorf("schema registry responded with %s", resp.Status)
+		}
+		return fmt.Errorf("schema registry responded with %s: %s (%d)", resp.Status, errResponse.Message, errResponse.ErrorCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
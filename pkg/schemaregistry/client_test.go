@@ -0,0 +1,128 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Tuhis/edge-receiver/pkg/schemaregistry"
+)
+
+// fakeRegistry is an in-process stand-in for the Schema Registry API.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	schemas  map[string]int
+	requests int
+	// Last request body and credentials
+	request  map[string]string
+	username string
+	password string
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *httptest.Server) {
+	registry := &fakeRegistry{schemas: make(map[string]int)}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	return registry, server
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+	f.username, f.password, _ = r.BasicAuth()
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/subjects/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.request = nil
+	if err := json.NewDecoder(r.Body).Decode(&f.request); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 42201, "message": "Invalid schema"})
+		return
+	}
+
+	subject, register := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions")
+	key := subject + "\n" + f.request["schema"]
+	id, ok := f.schemas[key]
+	switch {
+	case !ok && register:
+		id = len(f.schemas) + 1
+		f.schemas[key] = id
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"subject": subject, "id": id, "version": 1})
+}
+
+func TestClient_Register(t *testing.T) {
+	registry, server := newFakeRegistry(t)
+	client := schemaregistry.NewClient(server.URL+"/", schemaregistry.WithBasicAuth("edge", "secret"))
+	ctx := context.Background()
+
+	first, err := client.Register(ctx, "ruuvi-value", schemaregistry.JSONSchema, `{"type":"object"}`)
+	if err != nil {
+		t.Fatalf("Client.Register() error = %v", err)
+	}
+	if registry.request["schemaType"] != "JSON" {
+		t.Errorf("Client.Register() sent schema type %q, want JSON", registry.request["schemaType"])
+	}
+	if registry.username != "edge" || registry.password != "secret" {
+		t.Errorf("Client.Register() authenticated as %q:%q", registry.username, registry.password)
+	}
+
+	// The ID is cached
+	again, err := client.Register(ctx, "ruuvi-value", schemaregistry.JSONSchema, `{"type":"object"}`)
+	if err != nil || again != first || registry.requests != 1 {
+		t.Errorf("Client.Register() = %d, %v after %d requests, want cached %d", again, err, registry.requests, first)
+	}
+
+	other, err := client.Register(ctx, "ruuvi-value", schemaregistry.Avro, `"string"`)
+	if err != nil || other == first {
+		t.Errorf("Client.Register() = %d, %v for another schema", other, err)
+	}
+	if _, ok := registry.request["schemaType"]; ok {
+		t.Error("Client.Register() sent the schema type of an Avro schema")
+	}
+}
+
+func TestClient_Lookup(t *testing.T) {
+	_, server := newFakeRegistry(t)
+	client := schemaregistry.NewClient(server.URL)
+	ctx := context.Background()
+
+	if _, err := client.Lookup(ctx, "ruuvi-value", schemaregistry.Avro, `"string"`); !errors.Is(err, schemaregistry.ErrSchemaNotFound) {
+		t.Fatalf("Client.Lookup() error = %v, want ErrSchemaNotFound", err)
+	}
+
+	id, err := schemaregistry.NewClient(server.URL).Register(ctx, "ruuvi-value", schemaregistry.Avro, `"string"`)
+	if err != nil {
+		t.Fatalf("Client.Register() error = %v", err)
+	}
+	if got, err := client.Lookup(ctx, "ruuvi-value", schemaregistry.Avro, `"string"`); err != nil || got != id {
+		t.Errorf("Client.Lookup() = %d, %v, want %d", got, err, id)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error_code":409,"message":"Schema being registered is incompatible with an earlier schema"}`))
+	}))
+	defer server.Close()
+
+	_, err := schemaregistry.NewClient(server.URL).Register(context.Background(), "ruuvi-value", schemaregistry.Avro, `"string"`)
+	if err == nil || !strings.Contains(err.Error(), "incompatible") {
+		t.Errorf("Client.Register() error = %v, want the message of the registry", err)
+	}
+}
@@ -0,0 +1,136 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+)
+
+// magicByte starts every record in the Confluent wire format.
+const magicByte = 0
+
+// ErrNotFramed is returned when unframing a value that is not in the
+// Confluent wire format.
+var ErrNotFramed = errors.New("value is not in the Confluent wire format")
+
+// Frame prefixes the payload with the magic byte and the big-endian schema
+// ID of the Confluent wire format.
+func Frame(id int, payload []byte) []byte {
+	value := make([]byte, 5, 5+len(payload))
+	value[0] = magicByte
+	binary.BigEndian.PutUint32(value[1:], uint32(id))
+	return append(value, payload...)
+}
+
+// Unframe returns the schema ID and the payload of a value in the Confluent
+// wire format.
+func Unframe(value []byte) (int, []byte, error) {
+	if len(value) < 5 || value[0] != magicByte {
+		return 0, nil, ErrNotFramed
+	}
+	return int(binary.BigEndian.Uint32(value[1:5])), value[5:], nil
+}
+
+// Serializer encodes events with a schema registered in a Schema Registry.
+type Serializer struct {
+	client       *Client
+	subject      string
+	schemaType   SchemaType
+	schema       string
+	autoRegister bool
+	encode       func(*events.RuuviKafkaEvent) ([]byte, error)
+}
+
+// SerializerOption configures a Serializer.
+type SerializerOption func(*Serializer)
+
+// WithoutAutoRegister only looks the schema up instead of registering it,
+// for registries where schemas are registered by their owners.
+func WithoutAutoRegister() SerializerOption {
+	return func(s *Serializer) {
+		s.autoRegister = false
+	}
+}
+
+// SubjectForTopic returns the subject of the values of a topic, following
+// the default TopicNameStrategy of the Confluent serializers.
+func SubjectForTopic(topic string) string {
+	return topic + "-value"
+}
+
+// NewAvroSerializer returns a serializer encoding events in the given format
+// in Avro, with the schema registered under the subject.
+func NewAvroSerializer(client *Client, subject string, format events.EventFormat, opts ...SerializerOption) (*Serializer, error) {
+	if format == events.BothFormats {
+		return nil, fmt.Errorf("event format %q is not supported in Avro", format)
+	}
+
+	schema, err := events.AvroSchema(format.SchemaVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	encode := func(event *events.RuuviKafkaEvent) ([]byte, error) {
+		return event.MarshalAvro(format)
+	}
+	return newSerializer(client, subject, Avro, string(schema), encode, opts), nil
+}
+
+// NewJSONSchemaSerializer returns a serializer encoding events in the given
+// format in JSON, with their JSON Schema registered under the subject.
+func NewJSONSchemaSerializer(client *Client, subject string, format events.EventFormat, opts ...SerializerOption) (*Serializer, error) {
+	if format == events.BothFormats {
+		return nil, fmt.Errorf("event format %q is not supported with JSON Schema", format)
+	}
+
+	schema, err := events.JSONSchema(format.SchemaVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	encode := func(event *events.RuuviKafkaEvent) ([]byte, error) {
+		return event.Marshal(format)
+	}
+	return newSerializer(client, subject, JSONSchema, string(schema), encode, opts), nil
+}
+
+func newSerializer(client *Client, subject string, schemaType SchemaType, schema string, encode func(*events.RuuviKafkaEvent) ([]byte, error), opts []SerializerOption) *Serializer {
+	s := &Serializer{
+		client:       client,
+		subject:      subject,
+		schemaType:   schemaType,
+		schema:       schema,
+		autoRegister: true,
+		encode:       encode,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SchemaID returns the ID of the schema of the serializer, registering it
+// if needed. The ID is cached after the first call.
+func (s *Serializer) SchemaID(ctx context.Context) (int, error) {
+	if s.autoRegister {
+		return s.client.Register(ctx, s.subject, s.schemaType, s.schema)
+	}
+	return s.client.Lookup(ctx, s.subject, s.schemaType, s.schema)
+}
+
+// Serialize encodes the event in the Confluent wire format.
+func (s *Serializer) Serialize(event *events.RuuviKafkaEvent) ([]byte, error) {
+	id, err := s.SchemaID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ID of schema for %s: %w", s.subject, err)
+	}
+
+	payload, err := s.encode(event)
+	if err != nil {
+		return nil, err
+	}
+	return Frame(id, payload), nil
+}
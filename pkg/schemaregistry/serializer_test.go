@@ -0,0 +1,121 @@
+package schemaregistry_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tuhis/edge-receiver/pkg/events"
+	"github.com/Tuhis/edge-receiver/pkg/schemaregistry"
+)
+
+func TestFrame(t *testing.T) {
+	value := schemaregistry.Frame(258, []byte("payload"))
+	if !bytes.Equal(value[:5], []byte{0, 0, 0, 1, 2}) {
+		t.Errorf("Frame() header = %v", value[:5])
+	}
+
+	id, payload, err := schemaregistry.Unframe(value)
+	if err != nil || id != 258 || string(payload) != "payload" {
+		t.Errorf("Unframe() = %d, %q, %v", id, payload, err)
+	}
+
+	if _, _, err := schemaregistry.Unframe([]byte(`{"type":"new_measurement"}`)); !errors.Is(err, schemaregistry.ErrNotFramed) {
+		t.Errorf("Unframe() of JSON error = %v, want ErrNotFramed", err)
+	}
+}
+
+func TestSerializer_Serialize(t *testing.T) {
+	format := 5
+	event := &events.RuuviKafkaEvent{
+		Type:       events.NewMeasurement,
+		Data:       &events.NewMeasurementData{DataFormat: &format},
+		SourceUuid: "7d01818b-0332-4adf-99c1-13f833e59c6b",
+		ReceivedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name       string
+		serializer func(*schemaregistry.Client) (*schemaregistry.Serializer, error)
+		schemaType string
+		encode     func() ([]byte, error)
+	}{
+		{
+			name: "avro",
+			serializer: func(c *schemaregistry.Client) (*schemaregistry.Serializer, error) {
+				return schemaregistry.NewAvroSerializer(c, "ruuvi-value", events.LegacyFormat)
+			},
+			encode: func() ([]byte, error) { return event.MarshalAvro(events.LegacyFormat) },
+		},
+		{
+			name: "json schema",
+			serializer: func(c *schemaregistry.Client) (*schemaregistry.Serializer, error) {
+				return schemaregistry.NewJSONSchemaSerializer(c, "ruuvi-value", events.LegacyFormat)
+			},
+			schemaType: "JSON",
+			encode:     func() ([]byte, error) { return event.Marshal(events.LegacyFormat) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, server := newFakeRegistry(t)
+			serializer, err := tt.serializer(schemaregistry.NewClient(server.URL))
+			if err != nil {
+				t.Fatalf("failed to create serializer: %v", err)
+			}
+
+			for i := 0; i < 2; i++ {
+				value, err := serializer.Serialize(event)
+				if err != nil {
+					t.Fatalf("Serializer.Serialize() error = %v", err)
+				}
+
+				id, payload, err := schemaregistry.Unframe(value)
+				if err != nil || id != 1 {
+					t.Fatalf("Unframe() = %d, %v", id, err)
+				}
+				want, _ := tt.encode()
+				if !bytes.Equal(payload, want) {
+					t.Errorf("Serializer.Serialize() payload = %q, want %q", payload, want)
+				}
+			}
+
+			if registry.requests != 1 {
+				t.Errorf("Serializer.Serialize() made %d requests to the registry, want 1", registry.requests)
+			}
+			if registry.request["schemaType"] != tt.schemaType {
+				t.Errorf("Serializer.Serialize() registered schema type %q, want %q", registry.request["schemaType"], tt.schemaType)
+			}
+			if !json.Valid([]byte(registry.request["schema"])) {
+				t.Errorf("Serializer.Serialize() registered invalid schema %q", registry.request["schema"])
+			}
+		})
+	}
+}
+
+func TestSerializer_WithoutAutoRegister(t *testing.T) {
+	_, server := newFakeRegistry(t)
+	serializer, err := schemaregistry.NewAvroSerializer(schemaregistry.NewClient(server.URL), "ruuvi-value", events.EnvelopeFormat, schemaregistry.WithoutAutoRegister())
+	if err != nil {
+		t.Fatalf("failed to create serializer: %v", err)
+	}
+
+	if _, err := serializer.Serialize(&events.RuuviKafkaEvent{}); !errors.Is(err, schemaregistry.ErrSchemaNotFound) {
+		t.Errorf("Serializer.Serialize() error = %v, want ErrSchemaNotFound", err)
+	}
+}
+
+func TestNewAvroSerializer_BothFormats(t *testing.T) {
+	if _, err := schemaregistry.NewAvroSerializer(schemaregistry.NewClient("http://localhost"), "ruuvi-value", events.BothFormats); err == nil {
+		t.Error("NewAvroSerializer() expected an error for both formats")
+	}
+}
+
+func TestNewJSONSchemaSerializer_BothFormats(t *testing.T) {
+	if _, err := schemaregistry.NewJSONSchemaSerializer(schemaregistry.NewClient("http://localhost"), "ruuvi-value", events.BothFormats); err == nil {
+		t.Error("NewJSONSchemaSerializer() expected an error for both formats")
+	}
+}
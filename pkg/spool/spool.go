@@ -0,0 +1,624 @@
+// Package spool implements a persistent, append-only write-ahead queue on
+// local disk. Records are appended to segment files and read back in the
+// same order. A record is only removed once the reader commits it, so
+// records survive restarts until they have been delivered.
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FsyncPolicy string
+
+const (
+	// FsyncAlways syncs every append to disk before returning.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval syncs appended data periodically.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever leaves syncing to the operating system.
+	FsyncNever FsyncPolicy = "never"
+)
+
+const (
+	segmentSuffix = ".seg"
+	cursorFile    = "cursor"
+
+	// recordHeaderSize is the size of the length and checksum preceding
+	// every record.
+	recordHeaderSize = 8
+
+	// MaxRecordBytes is the largest record that can be appended.
+	MaxRecordBytes = 16 << 20
+)
+
+var (
+	ErrFull           = errors.New("spool is full")
+	ErrClosed         = errors.New("spool is closed")
+	ErrRecordTooLarge = errors.New("record is too large")
+)
+
+type Config struct {
+	// Dir is the directory holding the segment files. It is created if it
+	// does not exist.
+	Dir string
+	// MaxBytes limits the total size of undelivered records. Zero means no
+	// limit.
+	MaxBytes int64
+	// SegmentBytes is the size after which a new segment file is started.
+	SegmentBytes int64
+	// Fsync is the policy for syncing appended records to disk.
+	Fsync FsyncPolicy
+	// FsyncInterval is how often data is synced with FsyncInterval.
+	FsyncInterval time.Duration
+}
+
+// Position identifies a record in the spool.
+type Position struct {
+	Segment uint64
+	Offset  int64
+}
+
+// Batch is a set of consecutive records read from the spool.
+type Batch struct {
+	Records [][]byte
+	// Corrupted is the number of corrupted records that were skipped. They
+	// are removed from the spool with the batch.
+	Corrupted int
+
+	next  Position
+	bytes int64
+}
+
+type Spool struct {
+	cfg Config
+
+	mu      sync.Mutex
+	closed  bool
+	dirty   bool
+	cursor  Position
+	segment uint64 // active write segment
+	file    *os.File
+	size    int64 // size of the active write segment
+	depth   int   // undelivered records
+	pending int64 // undelivered bytes, including record headers
+
+	notify chan struct{}
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// ParseFsyncPolicy parses an fsync policy name. An empty string selects
+// FsyncInterval.
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch FsyncPolicy(s) {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return FsyncPolicy(s), nil
+	case "":
+		return FsyncInterval, nil
+	default:
+		return "", errors.New("invalid fsync policy: " + s)
+	}
+}
+
+// Open opens the spool in cfg.Dir, recovering any records left undelivered
+// by a previous run. A record that was only partially written before a crash
+// is discarded.
+func Open(cfg Config) (*Spool, error) {
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = 64 << 20
+	}
+	if cfg.Fsync == "" {
+		cfg.Fsync = FsyncInterval
+	}
+	if cfg.FsyncInterval <= 0 {
+		cfg.FsyncInterval = time.Second
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Spool{
+		cfg:    cfg,
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Fsync == FsyncInterval {
+		s.wg.Add(1)
+		go s.syncPeriodically()
+	}
+
+	return s, nil
+}
+
+// Append adds a record to the end of the spool.
+func (s *Spool) Append(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if len(record) > MaxRecordBytes {
+		return ErrRecordTooLarge
+	}
+
+	recordSize := int64(recordHeaderSize + len(record))
+	if s.cfg.MaxBytes > 0 && s.pending+recordSize > s.cfg.MaxBytes {
+		return ErrFull
+	}
+
+	if s.size >= s.cfg.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(record))
+	copy(buf[recordHeaderSize:], record)
+
+	if _, err := s.file.Write(buf); err != nil {
+		// Drop the partial write so the segment stays readable
+		s.file.Truncate(s.size)
+		s.file.Seek(s.size, io.SeekStart)
+		return err
+	}
+
+	if s.cfg.Fsync == FsyncAlways {
+		if err := s.file.Sync(); err != nil {
+			return err
+		}
+	} else {
+		s.dirty = true
+	}
+
+	s.size += recordSize
+	s.depth++
+	s.pending += recordSize
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Read returns up to max undelivered records starting from the oldest one.
+// The records stay in the spool until the batch is committed, so reading
+// again without committing returns the same records.
+func (s *Spool) Read(max int) (*Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	batch := &Batch{next: s.cursor}
+	if s.depth == 0 {
+		return batch, nil
+	}
+
+	for len(batch.Records) < max {
+		f, err := os.Open(s.segmentPath(batch.next.Segment))
+		if err != nil {
+			return nil, err
+		}
+
+		records, corrupted, offset, err := readRecords(f, batch.next.Offset, max-len(batch.Records))
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Records = append(batch.Records, records...)
+		batch.Corrupted += corrupted
+		batch.bytes += offset - batch.next.Offset
+		batch.next.Offset = offset
+
+		if len(batch.Records) >= max || batch.next.Segment >= s.segment {
+			break
+		}
+
+		// Continue from the beginning of the next segment
+		batch.next = Position{Segment: batch.next.Segment + 1}
+	}
+
+	return batch, nil
+}
+
+// Commit removes the records of the batch from the spool. Batches must be
+// committed in the order they were read.
+func (s *Spool) Commit(batch *Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	if err := s.writeCursor(batch.next); err != nil {
+		return err
+	}
+
+	// Remove segments that have been read completely
+	for segment := s.cursor.Segment; segment < batch.next.Segment; segment++ {
+		if err := os.Remove(s.segmentPath(segment)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	s.cursor = batch.next
+	s.depth -= len(batch.Records) + batch.Corrupted
+	s.pending -= batch.bytes
+
+	// The records lost with a corrupted length are not known, so the depth
+	// is only exact again once the spool has been emptied
+	if s.pending == 0 || s.depth < 0 {
+		s.depth = 0
+	}
+
+	return nil
+}
+
+// Notify returns a channel that receives a value after records have been
+// appended.
+func (s *Spool) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// Depth returns the number of undelivered records.
+func (s *Spool) Depth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.depth
+}
+
+// Size returns the size of the undelivered records in bytes.
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Close syncs and closes the spool.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+func (s *Spool) syncPeriodically() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.FsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty {
+				s.file.Sync()
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// recover loads the cursor, counts the undelivered records and opens the
+// last segment for appending.
+func (s *Spool) recover() error {
+	segments, err := s.listSegments()
+	if err != nil {
+		return err
+	}
+
+	cursor, err := s.readCursor()
+	if err != nil {
+		return err
+	}
+
+	// Segments before the cursor have already been delivered
+	for len(segments) > 0 && segments[0] < cursor.Segment {
+		if err := os.Remove(s.segmentPath(segments[0])); err != nil {
+			return err
+		}
+		segments = segments[1:]
+	}
+
+	if len(segments) == 0 {
+		s.cursor = Position{Segment: cursor.Segment}
+		s.segment = cursor.Segment
+		return s.openSegment()
+	}
+
+	if segments[0] > cursor.Segment {
+		cursor = Position{Segment: segments[0]}
+	}
+	s.cursor = cursor
+
+	for i, segment := range segments {
+		f, err := os.OpenFile(s.segmentPath(segment), os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+
+		start := int64(0)
+		if segment == cursor.Segment {
+			start = cursor.Offset
+		}
+
+		count, end, err := countRecords(f, start)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		// Discard a torn write at the end of the segment
+		if end < info.Size() {
+			if err := f.Truncate(end); err != nil {
+				f.Close()
+				return err
+			}
+		}
+
+		s.depth += count
+		s.pending += end - start
+
+		if i < len(segments)-1 {
+			f.Close()
+			continue
+		}
+
+		if _, err := f.Seek(end, io.SeekStart); err != nil {
+			f.Close()
+			return err
+		}
+		s.segment = segment
+		s.file = f
+		s.size = end
+	}
+
+	return nil
+}
+
+func (s *Spool) rotate() error {
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	s.segment++
+	s.dirty = false
+	return s.openSegment()
+}
+
+func (s *Spool) openSegment() error {
+	f, err := os.OpenFile(s.segmentPath(s.segment), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	s.file = f
+	s.size = 0
+	return nil
+}
+
+func (s *Spool) segmentPath(segment uint64) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%020d%s", segment, segmentSuffix))
+}
+
+func (s *Spool) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		segment, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+func (s *Spool) readCursor() (Position, error) {
+	b, err := os.ReadFile(filepath.Join(s.cfg.Dir, cursorFile))
+	if os.IsNotExist(err) {
+		return Position{}, nil
+	}
+	if err != nil {
+		return Position{}, err
+	}
+
+	var cursor Position
+	if _, err := fmt.Sscanf(string(b), "%d %d", &cursor.Segment, &cursor.Offset); err != nil {
+		return Position{}, fmt.Errorf("corrupted spool cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+// writeCursor replaces the cursor file atomically.
+func (s *Spool) writeCursor(cursor Position) error {
+	path := filepath.Join(s.cfg.Dir, cursorFile)
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(f, "%d %d\n", cursor.Segment, cursor.Offset); err != nil {
+		f.Close()
+		return err
+	}
+
+	if s.cfg.Fsync != FsyncNever {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readRecords reads up to max records from f starting at offset, skipping
+// corrupted records. It returns the records, the number of corrupted records
+// and the offset following the last one.
+//
+// A record whose checksum does not match is skipped by its length. A record
+// with an invalid length gives no way to find the records after it, so the
+// rest of the segment is skipped, as recovery discards it.
+func readRecords(f *os.File, offset int64, max int) ([][]byte, int, int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, offset, err
+	}
+
+	r := bufio.NewReader(f)
+	var records [][]byte
+	corrupted := 0
+
+	for len(records) < max {
+		record, err := readRecord(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if errors.Is(err, errChecksum) {
+			corrupted++
+			offset += int64(recordHeaderSize + len(record))
+			continue
+		}
+		if errors.Is(err, errCorrupted) {
+			info, err := f.Stat()
+			if err != nil {
+				return nil, 0, offset, err
+			}
+			return records, corrupted + 1, info.Size(), nil
+		}
+		if err != nil {
+			return nil, 0, offset, err
+		}
+
+		records = append(records, record)
+		offset += int64(recordHeaderSize + len(record))
+	}
+
+	return records, corrupted, offset, nil
+}
+
+// countRecords counts the records in f starting at offset, including those
+// whose checksum does not match, which are skipped when they are read. It
+// returns the count and the offset following the last record.
+func countRecords(f *os.File, offset int64) (int, int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, offset, err
+	}
+
+	r := bufio.NewReader(f)
+	count := 0
+
+	for {
+		record, err := readRecord(r)
+		if err != nil && !errors.Is(err, errChecksum) {
+			// Anything after the last complete record is a torn write
+			return count, offset, nil
+		}
+
+		count++
+		offset += int64(recordHeaderSize + len(record))
+	}
+}
+
+var (
+	errCorrupted = errors.New("corrupted spool record")
+	// errChecksum is returned with the contents of a record whose checksum
+	// does not match, so that the record can be skipped.
+	errChecksum = fmt.Errorf("%w: checksum mismatch", errCorrupted)
+)
+
+func readRecord(r io.Reader) ([]byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:])
+	if length > MaxRecordBytes {
+		return nil, errCorrupted
+	}
+
+	record := make([]byte, length)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, err
+	}
+
+	if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:]) {
+		return record, errChecksum
+	}
+
+	return record, nil
+}
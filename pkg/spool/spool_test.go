@@ -0,0 +1,290 @@
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openTestSpool(t *testing.T, dir string, cfg Config) *Spool {
+	t.Helper()
+
+	cfg.Dir = dir
+	s, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return s
+}
+
+func appendAll(t *testing.T, s *Spool, records ...string) {
+	t.Helper()
+
+	for _, record := range records {
+		if err := s.Append([]byte(record)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+}
+
+func readStrings(t *testing.T, s *Spool, max int) (*Batch, []string) {
+	t.Helper()
+
+	batch, err := s.Read(max)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	var records []string
+	for _, record := range batch.Records {
+		records = append(records, string(record))
+	}
+	return batch, records
+}
+
+func TestSpoolReadCommit(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), Config{Fsync: FsyncAlways})
+	defer s.Close()
+
+	appendAll(t, s, "one", "two", "three")
+
+	if depth := s.Depth(); depth != 3 {
+		t.Errorf("Depth() = %v, want 3", depth)
+	}
+
+	batch, got := readStrings(t, s, 2)
+	if want := []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+
+	// Reading without committing returns the same records
+	_, got = readStrings(t, s, 2)
+	if want := []string{"one", "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() again = %v, want %v", got, want)
+	}
+
+	if err := s.Commit(batch); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	batch, got = readStrings(t, s, 10)
+	if want := []string{"three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() after commit = %v, want %v", got, want)
+	}
+
+	if err := s.Commit(batch); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if depth, size := s.Depth(), s.Size(); depth != 0 || size != 0 {
+		t.Errorf("Depth(), Size() = %v, %v, want 0, 0", depth, size)
+	}
+}
+
+func TestSpoolRecoversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestSpool(t, dir, Config{Fsync: FsyncNever, SegmentBytes: 32})
+	appendAll(t, s, "first record", "second record", "third record", "fourth record")
+
+	batch, _ := readStrings(t, s, 1)
+	if err := s.Commit(batch); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	s.Close()
+
+	s = openTestSpool(t, dir, Config{Fsync: FsyncNever, SegmentBytes: 32})
+	defer s.Close()
+
+	if depth := s.Depth(); depth != 3 {
+		t.Errorf("Depth() after restart = %v, want 3", depth)
+	}
+
+	appendAll(t, s, "fifth record")
+
+	_, got := readStrings(t, s, 10)
+	want := []string{"second record", "third record", "fourth record", "fifth record"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() after restart = %v, want %v", got, want)
+	}
+}
+
+func TestSpoolRemovesDeliveredSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestSpool(t, dir, Config{SegmentBytes: 16})
+	defer s.Close()
+
+	appendAll(t, s, "record 1", "record 2", "record 3", "record 4")
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if len(segments) != 4 {
+		t.Fatalf("got %d segments, want 4", len(segments))
+	}
+
+	batch, _ := readStrings(t, s, 3)
+	if err := s.Commit(batch); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	segments, _ = filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	if len(segments) != 2 {
+		t.Errorf("got %d segments after commit, want 2", len(segments))
+	}
+}
+
+func TestSpoolDiscardsTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestSpool(t, dir, Config{Fsync: FsyncAlways})
+	appendAll(t, s, "complete", "torn")
+	s.Close()
+
+	// Cut the last record in half
+	path := filepath.Join(dir, "00000000000000000000"+segmentSuffix)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openTestSpool(t, dir, Config{Fsync: FsyncAlways})
+	defer s.Close()
+
+	appendAll(t, s, "after restart")
+
+	_, got := readStrings(t, s, 10)
+	if want := []string{"complete", "after restart"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+}
+
+// corruptRecord flips a byte of the record at index in the first segment, at
+// offset from the start of the record including its header.
+func corruptRecord(t *testing.T, dir string, index int, offset int64) {
+	t.Helper()
+
+	path := filepath.Join(dir, "00000000000000000000"+segmentSuffix)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := int64(0)
+	for i := 0; i < index; i++ {
+		start += recordHeaderSize + int64(binary.BigEndian.Uint32(b[start:]))
+	}
+	b[start+offset] ^= 0xff
+
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSpoolSkipsCorruptedRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		offset  int64
+		restart bool
+		want    []string
+	}{
+		{
+			name:   "checksum mismatch",
+			offset: recordHeaderSize,
+			want:   []string{"first", "third", "after corruption"},
+		},
+		{
+			name:    "checksum mismatch after restart",
+			offset:  recordHeaderSize,
+			restart: true,
+			want:    []string{"first", "third", "after corruption"},
+		},
+		{
+			// The following records of the segment cannot be found, but
+			// the next segment is read
+			name:   "invalid length",
+			offset: 0,
+			want:   []string{"first", "after corruption"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// The first three records fill the first segment
+			cfg := Config{Fsync: FsyncAlways, SegmentBytes: 40}
+			s := openTestSpool(t, dir, cfg)
+			appendAll(t, s, "first", "second", "third")
+			corruptRecord(t, dir, 1, tt.offset)
+			if tt.restart {
+				s.Close()
+				s = openTestSpool(t, dir, cfg)
+			}
+			defer s.Close()
+
+			appendAll(t, s, "after corruption")
+
+			batch, got := readStrings(t, s, 10)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %v, want %v", got, tt.want)
+			}
+			if batch.Corrupted != 1 {
+				t.Errorf("Corrupted = %d, want 1", batch.Corrupted)
+			}
+
+			if err := s.Commit(batch); err != nil {
+				t.Fatalf("Commit() error = %v", err)
+			}
+			if depth := s.Depth(); depth != 0 {
+				t.Errorf("Depth() = %d, want 0", depth)
+			}
+		})
+	}
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), Config{MaxBytes: 2 * (recordHeaderSize + 4)})
+	defer s.Close()
+
+	appendAll(t, s, "1234", "5678")
+
+	if err := s.Append([]byte("9")); !errors.Is(err, ErrFull) {
+		t.Errorf("Append() error = %v, want %v", err, ErrFull)
+	}
+
+	batch, _ := readStrings(t, s, 1)
+	if err := s.Commit(batch); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if err := s.Append([]byte("9")); err != nil {
+		t.Errorf("Append() after commit error = %v", err)
+	}
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    FsyncPolicy
+		wantErr bool
+	}{
+		{input: "always", want: FsyncAlways},
+		{input: "interval", want: FsyncInterval},
+		{input: "never", want: FsyncNever},
+		{input: "", want: FsyncInterval},
+		{input: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFsyncPolicy(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFsyncPolicy(%q) = %v, %v, want %v, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}